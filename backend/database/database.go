@@ -61,6 +61,38 @@ func Migrate() {
 		&models.DepreciationEntry{},
 		&models.CCAClass{},
 		&models.OwnerPayment{},
+		&models.Account{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
+		&models.AccountingPeriod{},
+		&models.AccountingPeriodEvent{},
+		&models.ExchangeRate{},
+		&models.BankAccount{},
+		&models.BankTransaction{},
+		&models.CSVImportProfile{},
+		&models.CategorizationRule{},
+		&models.RecurringTemplate{},
+		&models.RecurringOccurrence{},
+		&models.Vendor{},
+		&models.Bill{},
+		&models.BillItem{},
+		&models.BillPayment{},
+		&models.BillPaymentAllocation{},
+		&models.Budget{},
+		&models.BudgetLine{},
+		&models.BudgetMonth{},
+		&models.Project{},
+		&models.InvoiceTemplate{},
+		&models.Payment{},
+		&models.PaymentAllocation{},
+		&models.CreditNote{},
+		&models.CreditNoteItem{},
+		&models.CreditNoteApplication{},
+		&models.Estimate{},
+		&models.EstimateItem{},
+		&models.RecurringInvoice{},
+		&models.RecurringInvoiceItem{},
+		&models.RecurringInvoiceOccurrence{},
 	)
 
 	if err != nil {
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// closedPeriodContaining returns the closed accounting period of a company that contains
+// any of the given dates, or nil when all dates fall in open periods. Zero dates are ignored.
+func closedPeriodContaining(companyID uint, dates ...time.Time) (*models.AccountingPeriod, error) {
+	var periods []models.AccountingPeriod
+	if err := database.DB.Where("company_id = ? AND status = ?", companyID, "closed").Find(&periods).Error; err != nil {
+		return nil, err
+	}
+
+	for _, date := range dates {
+		if date.IsZero() {
+			continue
+		}
+		for i := range periods {
+			// The end date is inclusive of the whole day
+			if !date.Before(periods[i].StartDate) && date.Before(periods[i].EndDate.AddDate(0, 0, 1)) {
+				return &periods[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// rejectClosedPeriod responds with 409 Conflict and returns true when any of the dates
+// falls in a closed accounting period of the company
+func rejectClosedPeriod(c *gin.Context, companyID uint, dates ...time.Time) bool {
+	period, err := closedPeriodContaining(companyID, dates...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check accounting periods"})
+		return true
+	}
+	if period == nil {
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"error": fmt.Sprintf("Accounting period %s to %s is closed; records dated in it cannot be changed",
+			period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02")),
+		"accounting_period_id": period.ID,
+	})
+	return true
+}
+
+// ListAccountingPeriods lists the accounting periods of a company
+func ListAccountingPeriods(c *gin.Context) {
+	var periods []models.AccountingPeriod
+
+	query := database.DB.Model(&models.AccountingPeriod{})
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("start_date DESC").Find(&periods).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounting periods"})
+		return
+	}
+
+	c.JSON(http.StatusOK, periods)
+}
+
+// GetAccountingPeriod retrieves an accounting period with its close and reopen history
+func GetAccountingPeriod(c *gin.Context) {
+	periodID := c.Param("id")
+
+	var period models.AccountingPeriod
+	if err := database.DB.Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Events.User").First(&period, periodID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Accounting period not found"})
+		return
+	}
+
+	// Hide passwords from response
+	for i := range period.Events {
+		period.Events[i].User.Password = ""
+	}
+
+	c.JSON(http.StatusOK, period)
+}
+
+// CloseAccountingPeriod closes a fiscal period of a company (admin only). Closing a
+// previously reopened period with the same dates closes it again.
+func CloseAccountingPeriod(c *gin.Context) {
+	var req models.CloseAccountingPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Parse dates
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+		return
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must not be before start date"})
+		return
+	}
+
+	// Reject periods overlapping one that is already closed
+	var overlapping int64
+	if err := database.DB.Model(&models.AccountingPeriod{}).
+		Where("company_id = ? AND status = ? AND start_date <= ? AND end_date >= ?", req.CompanyID, "closed", endDate, startDate).
+		Count(&overlapping).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check accounting periods"})
+		return
+	}
+	if overlapping > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Period overlaps an accounting period that is already closed"})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	now := time.Now()
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	// Reuse a reopened period with the same dates
+	var period models.AccountingPeriod
+	err = tx.Where("company_id = ? AND start_date = ? AND end_date = ?", req.CompanyID, startDate, endDate).First(&period).Error
+	if err == nil {
+		updates := map[string]interface{}{
+			"status":       "closed",
+			"closed_at":    now,
+			"closed_by_id": userID,
+		}
+		if req.Notes != nil {
+			updates["notes"] = *req.Notes
+		}
+		if err := tx.Model(&period).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close accounting period"})
+			return
+		}
+	} else {
+		period = models.AccountingPeriod{
+			StartDate:  startDate,
+			EndDate:    endDate,
+			Status:     "closed",
+			ClosedAt:   &now,
+			ClosedByID: &userID,
+			Notes:      req.Notes,
+			CompanyID:  req.CompanyID,
+		}
+		if err := tx.Create(&period).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close accounting period"})
+			return
+		}
+	}
+
+	// Record the close in the audit trail
+	event := models.AccountingPeriodEvent{
+		AccountingPeriodID: period.ID,
+		Action:             "close",
+		Reason:             req.Notes,
+		UserID:             userID,
+		CompanyID:          req.CompanyID,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record period close"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load period with its history
+	if err := database.DB.Preload("Events").First(&period, period.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load accounting period"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, period)
+}
+
+// ReopenAccountingPeriod reopens a closed accounting period (admin only). A reason is
+// required and recorded in the period's audit trail.
+func ReopenAccountingPeriod(c *gin.Context) {
+	periodID := c.Param("id")
+
+	var req models.ReopenAccountingPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var period models.AccountingPeriod
+	if err := database.DB.First(&period, periodID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Accounting period not found"})
+		return
+	}
+
+	if period.Status != "closed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Accounting period is not closed"})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	now := time.Now()
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	if err := tx.Model(&period).Updates(map[string]interface{}{
+		"status":         "open",
+		"reopened_at":    now,
+		"reopened_by_id": userID,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reopen accounting period"})
+		return
+	}
+
+	// Record the reopen in the audit trail
+	event := models.AccountingPeriodEvent{
+		AccountingPeriodID: period.ID,
+		Action:             "reopen",
+		Reason:             &req.Reason,
+		UserID:             userID,
+		CompanyID:          period.CompanyID,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record period reopen"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load period with its history
+	if err := database.DB.Preload("Events").First(&period, period.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load accounting period"})
+		return
+	}
+
+	c.JSON(http.StatusOK, period)
+}
+
+// optionalDate returns the date a pointer refers to, or the zero time when it is nil
+func optionalDate(date *time.Time) time.Time {
+	if date == nil {
+		return time.Time{}
+	}
+	return *date
+}
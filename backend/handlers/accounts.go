@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultChartOfAccounts is the chart of accounts template for a Canadian small
+// corporation. System accounts are used by the automatic ledger postings.
+var defaultChartOfAccounts = []models.Account{
+	// Assets
+	{Code: accountCash, Name: "Cash", Type: "asset", GIFICode: "1001", IsSystem: true},
+	{Code: accountAccountsReceivable, Name: "Accounts Receivable", Type: "asset", GIFICode: "1060", IsSystem: true},
+	{Code: accountHSTReceivable, Name: "HST Receivable (Input Tax Credits)", Type: "asset", GIFICode: "1483", IsSystem: true},
+	{Code: accountCapitalAssets, Name: "Capital Assets", Type: "asset", GIFICode: "1740", IsSystem: true},
+	{Code: accountAccumulatedDepreciation, Name: "Accumulated Depreciation", Type: "asset", GIFICode: "1741", IsSystem: true},
+
+	// Liabilities
+	{Code: accountAccountsPayable, Name: "Accounts Payable", Type: "liability", GIFICode: "2620", IsSystem: true},
+	{Code: accountHSTPayable, Name: "HST Payable", Type: "liability", GIFICode: "2680", IsSystem: true},
+	{Code: accountDividendsPayable, Name: "Dividends Payable", Type: "liability", GIFICode: "2960", IsSystem: true},
+	{Code: accountDueToShareholder, Name: "Due to Shareholder", Type: "liability", GIFICode: "2780", IsSystem: true},
+	{Code: accountClientCredits, Name: "Client Credits", Type: "liability", GIFICode: "2920", IsSystem: true},
+
+	// Equity
+	{Code: accountShareCapital, Name: "Share Capital", Type: "equity", GIFICode: "3500", IsSystem: true},
+	{Code: accountRetainedEarnings, Name: "Retained Earnings", Type: "equity", GIFICode: "3600", IsSystem: true},
+	{Code: accountDividendsDeclared, Name: "Dividends Declared", Type: "equity", GIFICode: "3700", IsSystem: true},
+
+	// Revenue
+	{Code: accountRevenue, Name: "Sales of Services", Type: "revenue", GIFICode: "8000", IsSystem: true},
+	{Code: accountOtherIncome, Name: "Other Income", Type: "revenue", GIFICode: "8230", IsSystem: true},
+	{Code: accountGainOnDisposal, Name: "Gain/Loss on Disposal of Assets", Type: "revenue", GIFICode: "8210", IsSystem: true},
+	{Code: accountForeignExchange, Name: "Foreign Exchange Gain/Loss", Type: "revenue", GIFICode: "8231", IsSystem: true},
+
+	// Expenses
+	{Code: "5100", Name: "Office Supplies", Type: "expense", GIFICode: "8811"},
+	{Code: "5110", Name: "Travel", Type: "expense", GIFICode: "9200"},
+	{Code: "5120", Name: "Meals and Entertainment", Type: "expense", GIFICode: "8523"},
+	{Code: "5130", Name: "Professional Fees", Type: "expense", GIFICode: "8860"},
+	{Code: "5140", Name: "Software and Subscriptions", Type: "expense", GIFICode: "9150"},
+	{Code: "5150", Name: "Advertising and Promotion", Type: "expense", GIFICode: "8520"},
+	{Code: "5160", Name: "Equipment and Technology", Type: "expense", GIFICode: "9150"},
+	{Code: "5170", Name: "Utilities", Type: "expense", GIFICode: "9220"},
+	{Code: "5180", Name: "Insurance", Type: "expense", GIFICode: "8690"},
+	{Code: "5190", Name: "Bank Charges", Type: "expense", GIFICode: "8715"},
+	{Code: accountDepreciationExpense, Name: "Depreciation", Type: "expense", GIFICode: "8670", IsSystem: true},
+	{Code: accountOtherExpenses, Name: "Other Expenses", Type: "expense", GIFICode: "9270", IsSystem: true},
+}
+
+// SeedChartOfAccounts creates any accounts from the default template that a company is missing
+func SeedChartOfAccounts(db *gorm.DB, companyID uint) error {
+	var existingCodes []string
+	if err := db.Model(&models.Account{}).Where("company_id = ?", companyID).Pluck("code", &existingCodes).Error; err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(existingCodes))
+	for _, code := range existingCodes {
+		existing[code] = true
+	}
+
+	for _, template := range defaultChartOfAccounts {
+		if existing[template.Code] {
+			continue
+		}
+		account := template
+		account.CompanyID = companyID
+		account.IsActive = true
+		if err := db.Create(&account).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expenseAccountCode returns the expense account an expense category posts to
+func expenseAccountCode(db *gorm.DB, categoryID uint) (string, error) {
+	var category models.ExpenseCategory
+	if err := db.First(&category, categoryID).Error; err != nil {
+		return "", err
+	}
+	if category.AccountCode == nil || *category.AccountCode == "" {
+		return accountOtherExpenses, nil
+	}
+	return *category.AccountCode, nil
+}
+
+// isDebitNormal reports whether accounts of the given type normally carry a debit balance
+func isDebitNormal(accountType string) bool {
+	return accountType == "asset" || accountType == "expense"
+}
+
+// accountActivity holds the debit and credit totals posted to an account
+type accountActivity struct {
+	AccountCode string
+	Debit       models.Money
+	Credit      models.Money
+}
+
+// ledgerActivity sums the debits and credits posted to each account of a company
+// between two dates (inclusive). A nil start date means from the beginning of the ledger.
+func ledgerActivity(db *gorm.DB, companyID uint, startDate *time.Time, endDate time.Time) (map[string]accountActivity, error) {
+	var rows []accountActivity
+	query := db.Table("journal_lines").
+		Select("journal_lines.account_code, SUM(journal_lines.debit) AS debit, SUM(journal_lines.credit) AS credit").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_lines.company_id = ? AND journal_entries.entry_date <= ?", companyID, endDate)
+	if startDate != nil {
+		query = query.Where("journal_entries.entry_date >= ?", *startDate)
+	}
+	if err := query.Group("journal_lines.account_code").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	activity := make(map[string]accountActivity, len(rows))
+	for _, row := range rows {
+		activity[row.AccountCode] = row
+	}
+	return activity, nil
+}
+
+// normalBalance returns the balance of an account in the direction of its normal balance
+func normalBalance(accountType string, activity accountActivity) models.Money {
+	if isDebitNormal(accountType) {
+		return activity.Debit - activity.Credit
+	}
+	return activity.Credit - activity.Debit
+}
+
+// retainedEarningsAsOf returns retained earnings at a date: the retained earnings
+// account plus all net income earned to date less all dividends declared to date
+func retainedEarningsAsOf(accounts []models.Account, balances map[string]accountActivity) models.Money {
+	var total models.Money
+	for _, account := range accounts {
+		activity := balances[account.Code]
+		switch {
+		case account.Code == accountRetainedEarnings:
+			total += normalBalance(account.Type, activity)
+		case account.Code == accountDividendsDeclared:
+			total -= activity.Debit - activity.Credit
+		case account.Type == "revenue":
+			total += activity.Credit - activity.Debit
+		case account.Type == "expense":
+			total -= activity.Debit - activity.Credit
+		}
+	}
+	return total
+}
+
+// GIFILine represents one line of a GIFI export
+type GIFILine struct {
+	GIFICode    string       `json:"gifi_code"`
+	Description string       `json:"description"`
+	Amount      models.Money `json:"amount"`
+}
+
+// CreateAccount creates a new account in a company's chart of accounts
+func CreateAccount(c *gin.Context) {
+	var req models.CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Check if account code is already in use
+	var existingAccount models.Account
+	if err := database.DB.Where("company_id = ? AND code = ?", req.CompanyID, req.Code).First(&existingAccount).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account with this code already exists"})
+		return
+	}
+
+	// Create account
+	account := models.Account{
+		Code:        req.Code,
+		Name:        req.Name,
+		Type:        req.Type,
+		GIFICode:    req.GIFICode,
+		Description: req.Description,
+		IsActive:    true,
+		CompanyID:   req.CompanyID,
+	}
+
+	if err := database.DB.Create(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// GetAccount retrieves an account by ID
+func GetAccount(c *gin.Context) {
+	accountID := c.Param("id")
+
+	var account models.Account
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// UpdateAccount updates an account
+func UpdateAccount(c *gin.Context) {
+	accountID := c.Param("id")
+
+	var req models.UpdateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find account
+	var account models.Account
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.GIFICode != nil {
+		updates["gifi_code"] = *req.GIFICode
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.IsActive != nil {
+		if account.IsSystem && !*req.IsActive {
+			c.JSON(http.StatusConflict, gin.H{"error": "System accounts cannot be deactivated"})
+			return
+		}
+		updates["is_active"] = *req.IsActive
+	}
+
+	if err := database.DB.Model(&account).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+
+	// Load updated account
+	if err := database.DB.First(&account, account.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated account data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// DeleteAccount deletes an account
+func DeleteAccount(c *gin.Context) {
+	accountID := c.Param("id")
+
+	// Find account
+	var account models.Account
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	if account.IsSystem {
+		c.JSON(http.StatusConflict, gin.H{"error": "System accounts cannot be deleted"})
+		return
+	}
+
+	// Check if account has postings
+	var lineCount int64
+	if err := database.DB.Model(&models.JournalLine{}).Where("company_id = ? AND account_code = ?", account.CompanyID, account.Code).Count(&lineCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check account dependencies"})
+		return
+	}
+
+	if lineCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete account with journal postings; deactivate it instead"})
+		return
+	}
+
+	// Soft delete account
+	if err := database.DB.Delete(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// ListAccounts lists the chart of accounts
+func ListAccounts(c *gin.Context) {
+	var accounts []models.Account
+
+	// Get filter parameters
+	companyID := c.Query("company_id")
+	accountType := c.Query("type")
+	activeOnly := c.Query("active") == "true"
+
+	query := database.DB.Model(&models.Account{})
+
+	// Apply filters
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if accountType != "" {
+		query = query.Where("type = ?", accountType)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Order("code ASC").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// SeedAccounts adds any missing default accounts to a company's chart of accounts
+func SeedAccounts(c *gin.Context) {
+	companyID, err := strconv.ParseUint(c.Query("company_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company_id"})
+		return
+	}
+
+	var company models.Company
+	if err := database.DB.First(&company, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	if err := SeedChartOfAccounts(database.DB, company.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to seed chart of accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chart of accounts seeded successfully"})
+}
+
+// ExportGIFI exports account balances grouped by GIFI code for import into T2 preparation
+// software. Balance sheet items are reported as at the end date and income statement
+// items for the period. Use format=csv for a CSV file.
+func ExportGIFI(c *gin.Context) {
+	companyID, err := strconv.ParseUint(c.Query("company_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company_id"})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	var accounts []models.Account
+	if err := database.DB.Where("company_id = ?", companyID).Order("code ASC").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	balances, err := ledgerActivity(database.DB, uint(companyID), nil, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate account balances"})
+		return
+	}
+	periodActivity, err := ledgerActivity(database.DB, uint(companyID), &startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate account activity"})
+		return
+	}
+
+	amounts := make(map[string]models.Money)
+	descriptions := make(map[string]string)
+	add := func(gifiCode, description string, amount models.Money) {
+		amounts[gifiCode] += amount
+		if _, exists := descriptions[gifiCode]; !exists {
+			descriptions[gifiCode] = description
+		}
+	}
+
+	var totalAssets, totalLiabilities, totalEquity, totalRevenue, totalExpenses models.Money
+	for _, account := range accounts {
+		switch account.Type {
+		case "asset", "liability", "equity":
+			// Retained earnings absorbs cumulative net income and dividends below
+			if account.Code == accountRetainedEarnings || account.Code == accountDividendsDeclared {
+				continue
+			}
+			balance := normalBalance(account.Type, balances[account.Code])
+			add(account.GIFICode, account.Name, balance)
+			switch account.Type {
+			case "asset":
+				totalAssets += balance
+			case "liability":
+				totalLiabilities += balance
+			case "equity":
+				totalEquity += balance
+			}
+		case "revenue":
+			amount := normalBalance(account.Type, periodActivity[account.Code])
+			add(account.GIFICode, account.Name, amount)
+			totalRevenue += amount
+		case "expense":
+			amount := normalBalance(account.Type, periodActivity[account.Code])
+			add(account.GIFICode, account.Name, amount)
+			totalExpenses += amount
+		}
+	}
+
+	retainedEarnings := retainedEarningsAsOf(accounts, balances)
+	add("3600", "Retained earnings/deficit", retainedEarnings)
+	totalEquity += retainedEarnings
+
+	dividendsDeclared := periodActivity[accountDividendsDeclared]
+	if amount := dividendsDeclared.Debit - dividendsDeclared.Credit; amount != 0 {
+		add("3700", "Dividends declared", amount)
+	}
+
+	add("2599", "Total assets", totalAssets)
+	add("3499", "Total liabilities", totalLiabilities)
+	add("3620", "Total shareholder equity", totalEquity)
+	add("3640", "Total liabilities and shareholder equity", totalLiabilities+totalEquity)
+	add("8299", "Total revenue", totalRevenue)
+	add("9368", "Total expenses", totalExpenses)
+	add("9999", "Net income/loss after taxes and extraordinary items", totalRevenue-totalExpenses)
+
+	lines := make([]GIFILine, 0, len(amounts))
+	for gifiCode, amount := range amounts {
+		lines = append(lines, GIFILine{GIFICode: gifiCode, Description: descriptions[gifiCode], Amount: amount})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].GIFICode < lines[j].GIFICode })
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"company_id": companyID,
+			"start_date": startDate.Format("2006-01-02"),
+			"end_date":   endDate.Format("2006-01-02"),
+			"lines":      lines,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"gifi_code", "description", "amount"})
+	for _, line := range lines {
+		writer.Write([]string{line.GIFICode, line.Description, line.Amount.String()})
+	}
+	writer.Flush()
+
+	filename := fmt.Sprintf("GIFI_%s_%s.csv", startDate.Format("20060102"), endDate.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
@@ -85,6 +85,12 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	// Seed the company's chart of accounts
+	if err := SeedChartOfAccounts(database.DB, company.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chart of accounts"})
+		return
+	}
+
 	// Create user
 	user := models.User{
 		Email:     req.Email,
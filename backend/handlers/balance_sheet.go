@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// BalanceSheetLine represents a single account line on the balance sheet
+type BalanceSheetLine struct {
+	AccountCode string       `json:"account_code"`
+	Name        string       `json:"name"`
+	GIFICode    string       `json:"gifi_code"`
+	Amount      models.Money `json:"amount"`
+}
+
+// BalanceSheetSection groups the lines of one side of the balance sheet
+type BalanceSheetSection struct {
+	Lines []BalanceSheetLine `json:"lines"`
+	Total models.Money       `json:"total"`
+}
+
+// CapitalAssetBookValue represents a capital asset's book value as of the balance sheet date
+type CapitalAssetBookValue struct {
+	ID                      uint         `json:"id"`
+	Description             string       `json:"description"`
+	PurchaseDate            time.Time    `json:"purchase_date"`
+	TotalCost               models.Money `json:"total_cost"`
+	AccumulatedDepreciation models.Money `json:"accumulated_depreciation"`
+	BookValue               models.Money `json:"book_value"`
+}
+
+// BalanceSheetData contains the balance sheet as of a given date
+type BalanceSheetData struct {
+	Company                   *models.Company         `json:"company"`
+	AsOfDate                  time.Time               `json:"as_of_date"`
+	Assets                    BalanceSheetSection     `json:"assets"`
+	Liabilities               BalanceSheetSection     `json:"liabilities"`
+	Equity                    BalanceSheetSection     `json:"equity"`
+	TotalLiabilitiesAndEquity models.Money            `json:"total_liabilities_and_equity"`
+	CapitalAssets             []CapitalAssetBookValue `json:"capital_assets"`
+}
+
+// generateBalanceSheetData builds the balance sheet of a company from the general ledger
+func generateBalanceSheetData(companyID uint, asOfDate time.Time) (*BalanceSheetData, error) {
+	data := BalanceSheetData{AsOfDate: asOfDate}
+
+	// Get company information
+	var company models.Company
+	if err := database.DB.First(&company, companyID).Error; err != nil {
+		return nil, fmt.Errorf("company not found")
+	}
+	data.Company = &company
+
+	var accounts []models.Account
+	if err := database.DB.Where("company_id = ?", companyID).Order("code ASC").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %v", err)
+	}
+
+	balances, err := ledgerActivity(database.DB, companyID, nil, asOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate account balances: %v", err)
+	}
+
+	for _, account := range accounts {
+		balance := normalBalance(account.Type, balances[account.Code])
+		line := BalanceSheetLine{AccountCode: account.Code, Name: account.Name, GIFICode: account.GIFICode, Amount: balance}
+
+		switch account.Type {
+		case "asset":
+			// Capital assets are presented at net book value
+			if account.Code == accountCapitalAssets {
+				line.Name = "Capital Assets (net book value)"
+				line.Amount = balance + normalBalance(account.Type, balances[accountAccumulatedDepreciation])
+			} else if account.Code == accountAccumulatedDepreciation {
+				continue
+			}
+			if line.Amount == 0 && !account.IsSystem {
+				continue
+			}
+			data.Assets.Lines = append(data.Assets.Lines, line)
+			data.Assets.Total += line.Amount
+		case "liability":
+			if line.Amount == 0 && !account.IsSystem {
+				continue
+			}
+			data.Liabilities.Lines = append(data.Liabilities.Lines, line)
+			data.Liabilities.Total += line.Amount
+		case "equity":
+			// Retained earnings is calculated from cumulative income and dividends
+			if account.Code == accountDividendsDeclared {
+				continue
+			}
+			if account.Code == accountRetainedEarnings {
+				line.Amount = retainedEarningsAsOf(accounts, balances)
+			}
+			data.Equity.Lines = append(data.Equity.Lines, line)
+			data.Equity.Total += line.Amount
+		}
+	}
+
+	data.TotalLiabilitiesAndEquity = data.Liabilities.Total + data.Equity.Total
+
+	// Capital asset detail at book value as of the balance sheet date
+	var assets []models.CapitalAsset
+	if err := database.DB.Preload("DepreciationEntries").
+		Where("company_id = ? AND purchase_date <= ? AND (disposal_date IS NULL OR disposal_date > ?)", companyID, asOfDate, asOfDate).
+		Order("purchase_date ASC").
+		Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch capital assets: %v", err)
+	}
+	for _, asset := range assets {
+		var accumulated models.Money
+		for _, entry := range asset.DepreciationEntries {
+			if !entry.EntryDate.After(asOfDate) {
+				accumulated += entry.DepreciationAmount
+			}
+		}
+		data.CapitalAssets = append(data.CapitalAssets, CapitalAssetBookValue{
+			ID:                      asset.ID,
+			Description:             asset.Description,
+			PurchaseDate:            asset.PurchaseDate,
+			TotalCost:               asset.TotalCost,
+			AccumulatedDepreciation: accumulated,
+			BookValue:               asset.TotalCost - accumulated,
+		})
+	}
+
+	return &data, nil
+}
+
+// generateBalanceSheetPDF creates a balance sheet PDF
+func generateBalanceSheetPDF(data *BalanceSheetData) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+
+	// Header
+	pdf.SetFont("Arial", "B", 18)
+	pdf.Cell(0, 12, "BALANCE SHEET")
+	pdf.Ln(10)
+
+	if data.Company != nil {
+		pdf.SetFont("Arial", "B", 14)
+		pdf.Cell(0, 10, data.Company.Name)
+		pdf.Ln(8)
+	}
+
+	pdf.SetFont("Arial", "", 11)
+	pdf.Cell(0, 7, fmt.Sprintf("As of %s", data.AsOfDate.Format("January 2, 2006")))
+	pdf.Ln(12)
+
+	// writeSection renders one side of the balance sheet with its total
+	writeSection := func(title string, section BalanceSheetSection, totalLabel string) {
+		pdf.SetFont("Arial", "B", 13)
+		pdf.Cell(0, 9, title)
+		pdf.Ln(9)
+
+		pdf.SetFont("Arial", "", 10)
+		for _, line := range section.Lines {
+			pdf.CellFormat(20, 7, line.GIFICode, "", 0, "L", false, 0, "")
+			pdf.CellFormat(110, 7, line.Name, "", 0, "L", false, 0, "")
+			pdf.CellFormat(50, 7, fmt.Sprintf("$%s", line.Amount), "", 1, "R", false, 0, "")
+		}
+
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(130, 8, totalLabel, "T", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 8, fmt.Sprintf("$%s", section.Total), "T", 1, "R", false, 0, "")
+		pdf.Ln(6)
+	}
+
+	writeSection("ASSETS", data.Assets, "Total Assets")
+	writeSection("LIABILITIES", data.Liabilities, "Total Liabilities")
+	writeSection("SHAREHOLDER'S EQUITY", data.Equity, "Total Shareholder's Equity")
+
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(130, 9, "Total Liabilities and Shareholder's Equity", "TB", 0, "L", false, 0, "")
+	pdf.CellFormat(50, 9, fmt.Sprintf("$%s", data.TotalLiabilitiesAndEquity), "TB", 1, "R", false, 0, "")
+	pdf.Ln(12)
+
+	// Capital asset schedule
+	if len(data.CapitalAssets) > 0 {
+		if pdf.GetY() > 200 {
+			pdf.AddPage()
+		}
+
+		pdf.SetFont("Arial", "B", 13)
+		pdf.Cell(0, 9, "CAPITAL ASSETS AT BOOK VALUE")
+		pdf.Ln(9)
+
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(65, 8, "Asset Description", "1", 0, "C", false, 0, "")
+		pdf.CellFormat(25, 8, "Purchased", "1", 0, "C", false, 0, "")
+		pdf.CellFormat(30, 8, "Cost", "1", 0, "C", false, 0, "")
+		pdf.CellFormat(30, 8, "Accum. Dep.", "1", 0, "C", false, 0, "")
+		pdf.CellFormat(30, 8, "Book Value", "1", 1, "C", false, 0, "")
+
+		pdf.SetFont("Arial", "", 9)
+		for _, asset := range data.CapitalAssets {
+			pdf.CellFormat(65, 7, asset.Description, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(25, 7, asset.PurchaseDate.Format("2006-01-02"), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(30, 7, fmt.Sprintf("$%s", asset.TotalCost), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(30, 7, fmt.Sprintf("$%s", asset.AccumulatedDepreciation), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(30, 7, fmt.Sprintf("$%s", asset.BookValue), "1", 1, "R", false, 0, "")
+		}
+	}
+
+	// Output to bytes buffer
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
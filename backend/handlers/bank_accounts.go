@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BookItem is the net effect of a source record on the ledger account of a bank
+// account, i.e. what the books expect to see on the bank statement
+type BookItem struct {
+	SourceType  string       `json:"source_type"`
+	SourceID    uint         `json:"source_id"` // Journal entry ID for manual entries
+	Date        time.Time    `json:"date"`
+	Description string       `json:"description"`
+	Amount      models.Money `json:"amount"` // Positive for deposits, negative for withdrawals
+}
+
+// farFuture bounds date range queries that have no end date
+var farFuture = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// bookItemKey identifies the source record of a book item
+type bookItemKey struct {
+	SourceType string
+	SourceID   uint
+}
+
+// BankReconciliationReport reconciles the statement balance of a bank account with the
+// balance of its ledger account. Outstanding items are recorded in the books but not yet
+// on the statement; unrecorded transactions are on the statement but not in the books.
+type BankReconciliationReport struct {
+	BankAccount                 models.BankAccount       `json:"bank_account"`
+	AsOfDate                    time.Time                `json:"as_of_date"`
+	StatementBalance            models.Money             `json:"statement_balance"`
+	OutstandingDeposits         []BookItem               `json:"outstanding_deposits"`
+	OutstandingWithdrawals      []BookItem               `json:"outstanding_withdrawals"`
+	TotalOutstandingDeposits    models.Money             `json:"total_outstanding_deposits"`
+	TotalOutstandingWithdrawals models.Money             `json:"total_outstanding_withdrawals"`
+	AdjustedStatementBalance    models.Money             `json:"adjusted_statement_balance"`
+	BookBalance                 models.Money             `json:"book_balance"`
+	UnrecordedTransactions      []models.BankTransaction `json:"unrecorded_transactions"`
+	TotalUnrecorded             models.Money             `json:"total_unrecorded"`
+	AdjustedBookBalance         models.Money             `json:"adjusted_book_balance"`
+	Difference                  models.Money             `json:"difference"`
+	IsReconciled                bool                     `json:"is_reconciled"`
+}
+
+// bankBookItems returns the net postings of each source record to a bank account's
+// ledger account between two dates (inclusive), oldest first. Reversed entries are
+// excluded, so edited records appear once with their current amount.
+func bankBookItems(db *gorm.DB, account models.BankAccount, startDate, endDate time.Time) ([]BookItem, error) {
+	var rows []struct {
+		EntryID     uint
+		SourceType  string
+		SourceID    uint
+		EntryDate   time.Time
+		Description string
+		Debit       models.Money
+		Credit      models.Money
+	}
+	if err := db.Table("journal_lines").
+		Select("journal_entries.id AS entry_id, journal_entries.source_type, journal_entries.source_id, journal_entries.entry_date, journal_entries.description, journal_lines.debit, journal_lines.credit").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_lines.company_id = ? AND journal_lines.account_code = ?", account.CompanyID, account.AccountCode).
+		Where("journal_entries.reversal_of_id IS NULL AND journal_entries.reversed_by_id IS NULL").
+		Where("journal_entries.entry_date >= ? AND journal_entries.entry_date <= ?", startDate, endDate).
+		Order("journal_entries.entry_date, journal_entries.id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var items []BookItem
+	index := make(map[bookItemKey]int)
+	for _, row := range rows {
+		key := bookItemKey{SourceType: row.SourceType, SourceID: row.SourceID}
+		if row.SourceType == sourceManual {
+			key.SourceID = row.EntryID
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(items)
+			index[key] = i
+			items = append(items, BookItem{SourceType: key.SourceType, SourceID: key.SourceID})
+		}
+		items[i].Date = row.EntryDate
+		items[i].Description = row.Description
+		items[i].Amount += row.Debit - row.Credit
+	}
+
+	// Drop records whose postings to the account cancel out
+	result := items[:0]
+	for _, item := range items {
+		if item.Amount != 0 {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// matchedBookItems returns the source records matched to a bank account's statement lines
+// posted on or before a date. Bank accounts can share a ledger account, so matches on one
+// account's statement never settle items on another's.
+func matchedBookItems(db *gorm.DB, bankAccountID uint, asOfDate time.Time) (map[bookItemKey]bool, error) {
+	var transactions []models.BankTransaction
+	if err := db.Where("bank_account_id = ? AND status = ? AND posted_date <= ?", bankAccountID, "matched", asOfDate).
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	matched := make(map[bookItemKey]bool, len(transactions))
+	for _, transaction := range transactions {
+		if transaction.MatchedSourceType != nil && transaction.MatchedSourceID != nil {
+			matched[bookItemKey{SourceType: *transaction.MatchedSourceType, SourceID: *transaction.MatchedSourceID}] = true
+		}
+	}
+	return matched, nil
+}
+
+// verifyBankLedgerAccount reports whether an account code is an asset account in the company's chart of accounts
+func verifyBankLedgerAccount(companyID uint, code string) bool {
+	var account models.Account
+	if err := database.DB.Where("company_id = ? AND code = ?", companyID, code).First(&account).Error; err != nil {
+		return false
+	}
+	return account.Type == "asset"
+}
+
+// ListBankAccounts lists the bank accounts of a company
+func ListBankAccounts(c *gin.Context) {
+	var bankAccounts []models.BankAccount
+
+	query := database.DB.Model(&models.BankAccount{})
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if c.Query("active") == "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Order("name").Find(&bankAccounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bank accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bankAccounts)
+}
+
+// CreateBankAccount creates a new bank account
+func CreateBankAccount(c *gin.Context) {
+	var req models.CreateBankAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Parse opening date
+	openingDate, err := time.Parse("2006-01-02", req.OpeningDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid opening date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	// Verify the ledger account
+	accountCode := req.AccountCode
+	if accountCode == "" {
+		accountCode = accountCash
+	}
+	if !verifyBankLedgerAccount(req.CompanyID, accountCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account code is not an asset account in the chart of accounts"})
+		return
+	}
+
+	bankAccount := models.BankAccount{
+		Name:           req.Name,
+		Institution:    req.Institution,
+		AccountNumber:  req.AccountNumber,
+		AccountCode:    accountCode,
+		OpeningBalance: req.OpeningBalance,
+		OpeningDate:    openingDate,
+		IsActive:       true,
+		CompanyID:      req.CompanyID,
+	}
+
+	if err := database.DB.Create(&bankAccount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bank account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bankAccount)
+}
+
+// GetBankAccount retrieves a bank account by ID
+func GetBankAccount(c *gin.Context) {
+	bankAccountID := c.Param("id")
+
+	var bankAccount models.BankAccount
+	if err := database.DB.First(&bankAccount, bankAccountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bankAccount)
+}
+
+// UpdateBankAccount updates a bank account
+func UpdateBankAccount(c *gin.Context) {
+	bankAccountID := c.Param("id")
+
+	var req models.UpdateBankAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find bank account
+	var bankAccount models.BankAccount
+	if err := database.DB.First(&bankAccount, bankAccountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank account not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Institution != nil {
+		updates["institution"] = *req.Institution
+	}
+	if req.AccountNumber != nil {
+		updates["account_number"] = *req.AccountNumber
+	}
+	if req.AccountCode != nil {
+		if !verifyBankLedgerAccount(bankAccount.CompanyID, *req.AccountCode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account code is not an asset account in the chart of accounts"})
+			return
+		}
+		updates["account_code"] = *req.AccountCode
+	}
+	if req.OpeningBalance != nil {
+		updates["opening_balance"] = *req.OpeningBalance
+	}
+	if req.OpeningDate != nil {
+		openingDate, err := time.Parse("2006-01-02", *req.OpeningDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid opening date format. Use YYYY-MM-DD"})
+			return
+		}
+		updates["opening_date"] = openingDate
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if err := database.DB.Model(&bankAccount).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bank account"})
+		return
+	}
+
+	// Load updated bank account
+	if err := database.DB.First(&bankAccount, bankAccount.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated bank account data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bankAccount)
+}
+
+// DeleteBankAccount deletes a bank account and its statement lines
+func DeleteBankAccount(c *gin.Context) {
+	bankAccountID := c.Param("id")
+
+	// Find bank account
+	var bankAccount models.BankAccount
+	if err := database.DB.First(&bankAccount, bankAccountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank account not found"})
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	// Soft delete statement lines
+	if err := tx.Where("bank_account_id = ?", bankAccount.ID).Delete(&models.BankTransaction{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bank transactions"})
+		return
+	}
+
+	// Soft delete bank account
+	if err := tx.Delete(&bankAccount).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bank account"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bank account deleted successfully"})
+}
+
+// GetBankReconciliation reconciles a bank account as of a date (default today). The
+// statement balance is the opening balance plus the statement lines imported since the
+// opening date, unless a statement_balance is given; the book balance is the balance of
+// the bank account's ledger account.
+func GetBankReconciliation(c *gin.Context) {
+	bankAccountID := c.Param("id")
+
+	var bankAccount models.BankAccount
+	if err := database.DB.First(&bankAccount, bankAccountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank account not found"})
+		return
+	}
+
+	// Parse as-of date
+	asOfDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := c.Query("as_of_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as of date format. Use YYYY-MM-DD"})
+			return
+		}
+		asOfDate = parsed
+	}
+
+	report := BankReconciliationReport{
+		BankAccount:            bankAccount,
+		AsOfDate:               asOfDate,
+		OutstandingDeposits:    []BookItem{},
+		OutstandingWithdrawals: []BookItem{},
+	}
+
+	// Statement lines up to the as-of date
+	var transactions []models.BankTransaction
+	if err := database.DB.Where("bank_account_id = ? AND posted_date >= ? AND posted_date <= ?", bankAccount.ID, bankAccount.OpeningDate, asOfDate).
+		Order("posted_date, id").Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bank transactions"})
+		return
+	}
+
+	report.StatementBalance = bankAccount.OpeningBalance
+	report.UnrecordedTransactions = []models.BankTransaction{}
+	for _, transaction := range transactions {
+		report.StatementBalance += transaction.Amount
+		if transaction.Status != "matched" {
+			report.UnrecordedTransactions = append(report.UnrecordedTransactions, transaction)
+			report.TotalUnrecorded += transaction.Amount
+		}
+	}
+	if value := c.Query("statement_balance"); value != "" {
+		statementBalance, err := models.ParseMoney(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid statement balance"})
+			return
+		}
+		report.StatementBalance = statementBalance
+	}
+
+	// Book items not yet matched to a statement line
+	items, err := bankBookItems(database.DB, bankAccount, bankAccount.OpeningDate, asOfDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ledger postings"})
+		return
+	}
+	matched, err := matchedBookItems(database.DB, bankAccount.ID, asOfDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch matched transactions"})
+		return
+	}
+	for _, item := range items {
+		if matched[bookItemKey{SourceType: item.SourceType, SourceID: item.SourceID}] {
+			continue
+		}
+		if item.Amount > 0 {
+			report.OutstandingDeposits = append(report.OutstandingDeposits, item)
+			report.TotalOutstandingDeposits += item.Amount
+		} else {
+			report.OutstandingWithdrawals = append(report.OutstandingWithdrawals, item)
+			report.TotalOutstandingWithdrawals += item.Amount
+		}
+	}
+
+	// Book balance of the ledger account
+	activity, err := ledgerActivity(database.DB, bankAccount.CompanyID, nil, asOfDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate book balance"})
+		return
+	}
+	report.BookBalance = activity[bankAccount.AccountCode].Debit - activity[bankAccount.AccountCode].Credit
+
+	report.AdjustedStatementBalance = report.StatementBalance + report.TotalOutstandingDeposits + report.TotalOutstandingWithdrawals
+	report.AdjustedBookBalance = report.BookBalance + report.TotalUnrecorded
+	report.Difference = report.AdjustedStatementBalance - report.AdjustedBookBalance
+	report.IsReconciled = report.Difference == 0
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,875 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxStatementFileSize is the largest bank statement file accepted for import
+const maxStatementFileSize = 10 * 1024 * 1024 // 10MB
+
+// statementLine is a transaction read from an imported statement file
+type statementLine struct {
+	FITID       string // Bank's unique ID for the transaction, if any
+	PostedDate  time.Time
+	Amount      models.Money // Positive for deposits, negative for withdrawals
+	Description string
+	Reference   string
+	References  []string // Structured remittance references, such as invoice numbers
+	Remittance  string   // Unstructured remittance information
+}
+
+// openStatementUpload finds the bank account of an import request and opens the uploaded
+// statement file. It responds with an error and returns false when either is missing.
+func openStatementUpload(c *gin.Context) (*models.BankAccount, multipart.File, bool) {
+	bankAccountID := c.Param("id")
+
+	var bankAccount models.BankAccount
+	if err := database.DB.First(&bankAccount, bankAccountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank account not found"})
+		return nil, nil, false
+	}
+
+	// Get the uploaded file
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return nil, nil, false
+	}
+	if file.Size > maxStatementFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds 10MB limit"})
+		return nil, nil, false
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return nil, nil, false
+	}
+
+	return &bankAccount, src, true
+}
+
+// selectStatements returns the indexes of the statements in a file to import: those for
+// the bank's account number in the account_id form field, or all of them when the file
+// covers a single account. It responds with an error and returns false otherwise.
+func selectStatements(c *gin.Context, accountIDs []string) ([]int, bool) {
+	accountID := c.PostForm("account_id")
+
+	var selected []int
+	distinct := make(map[string]bool)
+	for i, id := range accountIDs {
+		distinct[id] = true
+		if accountID == "" || id == accountID {
+			selected = append(selected, i)
+		}
+	}
+	if len(selected) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No statement for account_id " + accountID + " in the file"})
+		return nil, false
+	}
+	if accountID == "" && len(distinct) > 1 {
+		ids := make([]string, 0, len(distinct))
+		for id := range distinct {
+			ids = append(ids, id)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File contains statements for several accounts; choose one with account_id", "account_ids": ids})
+		return nil, false
+	}
+	return selected, true
+}
+
+// syntheticFITID derives a stable ID for a transaction without a FITID, so re-importing
+// the same file still skips it. Identical transactions on the same day are told apart
+// by their order in the file.
+func syntheticFITID(line statementLine, occurrence int) string {
+	key := fmt.Sprintf("%s|%s|%s|%d", line.PostedDate.Format("2006-01-02"), line.Amount, line.Description, occurrence)
+	sum := sha1.Sum([]byte(key))
+	return "gen-" + hex.EncodeToString(sum[:])[:20]
+}
+
+// saveStatementLines adds imported statement lines to a bank account, skipping lines whose
+// FITID was already imported, even if since deleted, and tags each line with
+// the categorization rule it matches. It returns the new bank transactions with the
+// statement lines they came from, and the number of duplicates skipped.
+func saveStatementLines(tx *gorm.DB, bankAccount *models.BankAccount, lines []statementLine) ([]models.BankTransaction, []statementLine, int, error) {
+	var existingFITIDs []string
+	if err := tx.Unscoped().Model(&models.BankTransaction{}).
+		Where("bank_account_id = ? AND fitid IS NOT NULL", bankAccount.ID).
+		Pluck("fitid", &existingFITIDs).Error; err != nil {
+		return nil, nil, 0, err
+	}
+	seen := make(map[string]bool, len(existingFITIDs))
+	for _, fitid := range existingFITIDs {
+		seen[fitid] = true
+	}
+
+	rules, err := newCategorizer(tx, bankAccount.CompanyID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var transactions []models.BankTransaction
+	var saved []statementLine
+	duplicates := 0
+	occurrences := make(map[string]int)
+	for _, line := range lines {
+		if line.PostedDate.IsZero() || line.Amount == 0 {
+			continue
+		}
+
+		fitid := line.FITID
+		if fitid == "" {
+			key := line.PostedDate.Format("2006-01-02") + line.Amount.String() + line.Description
+			occurrences[key]++
+			fitid = syntheticFITID(line, occurrences[key])
+		}
+		if seen[fitid] {
+			duplicates++
+			continue
+		}
+		seen[fitid] = true
+
+		transaction := models.BankTransaction{
+			BankAccountID: bankAccount.ID,
+			PostedDate:    line.PostedDate,
+			Description:   line.Description,
+			Amount:        line.Amount,
+			Status:        "unmatched",
+			FITID:         &fitid,
+			CompanyID:     bankAccount.CompanyID,
+		}
+		if rule := rules.match(transaction.Description, transaction.Amount, &bankAccount.ID, nil); rule != nil {
+			transaction.CategorizationRuleID = &rule.ID
+		}
+		if line.Reference != "" {
+			reference := line.Reference
+			transaction.Reference = &reference
+		}
+		transactions = append(transactions, transaction)
+		saved = append(saved, line)
+	}
+
+	if len(transactions) > 0 {
+		if err := tx.CreateInBatches(&transactions, 500).Error; err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	return transactions, saved, duplicates, nil
+}
+
+// ofxStatement is a bank or credit card statement read from an OFX file
+type ofxStatement struct {
+	AccountID     string
+	Currency      string
+	StartDate     time.Time
+	EndDate       time.Time
+	LedgerBalance *models.Money
+	Transactions  []ofxTransaction
+}
+
+// ofxTransaction is a STMTTRN record of an OFX statement
+type ofxTransaction struct {
+	FITID      string
+	Type       string
+	PostedDate time.Time
+	Amount     models.Money
+	Name       string
+	Memo       string
+	Reference  string
+}
+
+// Description returns the payee name with the memo when it adds anything
+func (t ofxTransaction) Description() string {
+	switch {
+	case t.Name == "":
+		return t.Memo
+	case t.Memo == "" || t.Memo == t.Name:
+		return t.Name
+	default:
+		return t.Name + " - " + t.Memo
+	}
+}
+
+// statementLine converts the transaction to a statement line for import
+func (t ofxTransaction) statementLine() statementLine {
+	line := statementLine{
+		FITID:       t.FITID,
+		PostedDate:  t.PostedDate,
+		Amount:      t.Amount,
+		Description: t.Description(),
+		Reference:   t.Reference,
+	}
+	if line.Description == "" {
+		line.Description = t.Type
+	}
+	return line
+}
+
+// ofxTagPattern matches an OFX element and the text that follows it. OFX 1.x is SGML,
+// where leaf elements have no closing tag, and OFX 2.x is XML; reading each tag with
+// the text up to the next tag handles both.
+var ofxTagPattern = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)[^>]*>([^<]*)`)
+
+// parseOFX reads the statements of an OFX 1.x or 2.x (or QFX) file
+func parseOFX(r io.Reader) ([]ofxStatement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(strings.ToUpper(string(data)), "<OFX>") {
+		return nil, fmt.Errorf("file is not an OFX statement")
+	}
+
+	var statements []ofxStatement
+	var statement *ofxStatement
+	var transaction *ofxTransaction
+	inLedgerBalance := false
+
+	for _, match := range ofxTagPattern.FindAllStringSubmatch(string(data), -1) {
+		closing := match[1] == "/"
+		tag := strings.ToUpper(match[2])
+		value := strings.TrimSpace(html.UnescapeString(match[3]))
+
+		switch tag {
+		case "STMTRS", "CCSTMTRS":
+			if closing {
+				if statement != nil {
+					statements = append(statements, *statement)
+				}
+				statement = nil
+			} else {
+				statement = &ofxStatement{}
+			}
+			continue
+		case "STMTTRN":
+			if closing {
+				if statement != nil && transaction != nil {
+					statement.Transactions = append(statement.Transactions, *transaction)
+				}
+				transaction = nil
+			} else {
+				transaction = &ofxTransaction{}
+			}
+			continue
+		case "LEDGERBAL":
+			inLedgerBalance = !closing
+			continue
+		}
+		if closing || value == "" || statement == nil {
+			continue
+		}
+
+		if transaction != nil {
+			switch tag {
+			case "FITID":
+				transaction.FITID = value
+			case "TRNTYPE":
+				transaction.Type = value
+			case "DTPOSTED":
+				if transaction.PostedDate, err = parseOFXDate(value); err != nil {
+					return nil, err
+				}
+			case "TRNAMT":
+				if transaction.Amount, err = parseOFXAmount(value); err != nil {
+					return nil, err
+				}
+			case "NAME", "PAYEE":
+				transaction.Name = value
+			case "MEMO":
+				transaction.Memo = value
+			case "CHECKNUM", "REFNUM":
+				if transaction.Reference == "" {
+					transaction.Reference = value
+				}
+			}
+			continue
+		}
+
+		switch tag {
+		case "ACCTID":
+			statement.AccountID = value
+		case "CURDEF":
+			statement.Currency = value
+		case "DTSTART":
+			if statement.StartDate, err = parseOFXDate(value); err != nil {
+				return nil, err
+			}
+		case "DTEND":
+			if statement.EndDate, err = parseOFXDate(value); err != nil {
+				return nil, err
+			}
+		case "BALAMT":
+			if inLedgerBalance {
+				balance, err := parseOFXAmount(value)
+				if err != nil {
+					return nil, err
+				}
+				statement.LedgerBalance = &balance
+			}
+		}
+	}
+
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no bank or credit card statements found in the file")
+	}
+	return statements, nil
+}
+
+// parseOFXDate parses the date part of an OFX datetime such as "20240115120000.000[-5:EST]"
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid OFX date %q", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid OFX date %q", value)
+	}
+	return date, nil
+}
+
+// parseOFXAmount parses an OFX amount, which some banks write with a decimal comma
+func parseOFXAmount(value string) (models.Money, error) {
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	amount, err := models.ParseMoney(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid OFX amount %q", value)
+	}
+	return amount, nil
+}
+
+// ImportOFXStatement imports the transactions of an OFX or QFX statement file into a bank
+// account. Transactions already imported, identified by their FITID, are skipped so the
+// same file can be imported again safely. Files with statements for several accounts
+// need the bank's account_id form field to choose one.
+func ImportOFXStatement(c *gin.Context) {
+	bankAccount, src, ok := openStatementUpload(c)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	statements, err := parseOFX(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Choose the statement to import
+	accountIDs := make([]string, len(statements))
+	for i, statement := range statements {
+		accountIDs[i] = statement.AccountID
+	}
+	selected, ok := selectStatements(c, accountIDs)
+	if !ok {
+		return
+	}
+
+	var lines []statementLine
+	for _, i := range selected {
+		for _, transaction := range statements[i].Transactions {
+			lines = append(lines, transaction.statementLine())
+		}
+	}
+
+	// Save the lines in one transaction so a failed import leaves nothing behind
+	var transactions []models.BankTransaction
+	var duplicates int
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transactions, _, duplicates, err = saveStatementLines(tx, bankAccount, lines)
+		return err
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bank transactions"})
+		return
+	}
+
+	// Summarize the imported statement
+	statement := statements[selected[0]]
+	summary := gin.H{
+		"account_id": statement.AccountID,
+		"currency":   statement.Currency,
+	}
+	if !statement.StartDate.IsZero() {
+		summary["start_date"] = statement.StartDate.Format("2006-01-02")
+	}
+	if !statement.EndDate.IsZero() {
+		summary["end_date"] = statement.EndDate.Format("2006-01-02")
+	}
+	if statement.LedgerBalance != nil {
+		summary["ledger_balance"] = *statement.LedgerBalance
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported":     len(transactions),
+		"duplicates":   duplicates,
+		"statement":    summary,
+		"transactions": transactions,
+	})
+}
+
+// camtDocument is an ISO 20022 camt.053 bank-to-customer statement. Element names match
+// in any namespace, so the message versions in use (001.02 to 001.08) all parse.
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+// camtStatement is the statement of one account
+type camtStatement struct {
+	ID       string        `xml:"Id"`
+	IBAN     string        `xml:"Acct>Id>IBAN"`
+	Other    string        `xml:"Acct>Id>Othr>Id"`
+	Currency string        `xml:"Acct>Ccy"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+// AccountID returns the IBAN or other identification of the statement's account
+func (s camtStatement) AccountID() string {
+	if s.IBAN != "" {
+		return s.IBAN
+	}
+	return s.Other
+}
+
+// camtAmount is an amount with its currency attribute
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// camtDate is a date given as a date or a date and time
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// Time parses the date, ignoring any time of day
+func (d camtDate) Time() (time.Time, error) {
+	value := strings.TrimSpace(d.Date)
+	if value == "" {
+		value = strings.TrimSpace(d.DateTime)
+	}
+	if len(value) < 10 {
+		return time.Time{}, fmt.Errorf("invalid camt.053 date %q", value)
+	}
+	return time.Parse("2006-01-02", value[:10])
+}
+
+// camtBalance is an opening, closing or other balance of a statement
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"` // OPBD, CLBD, ...
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      camtDate   `xml:"Dt"`
+}
+
+// camtStatus is the status of an entry, given as text up to version 001.07 and as a
+// code from version 001.08
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+// camtEntry is an entry of a statement. An entry can batch several transactions.
+type camtEntry struct {
+	Reference         string                   `xml:"NtryRef"`
+	Amount            camtAmount               `xml:"Amt"`
+	Indicator         string                   `xml:"CdtDbtInd"` // CRDT or DBIT
+	Status            camtStatus               `xml:"Sts"`
+	BookingDate       camtDate                 `xml:"BookgDt"`
+	ServicerReference string                   `xml:"AcctSvcrRef"`
+	Details           []camtTransactionDetails `xml:"NtryDtls>TxDtls"`
+	AdditionalInfo    string                   `xml:"AddtlNtryInf"`
+}
+
+// camtTransactionDetails are the references, parties and remittance information of a
+// transaction in an entry
+type camtTransactionDetails struct {
+	EndToEndID        string                     `xml:"Refs>EndToEndId"`
+	ServicerReference string                     `xml:"Refs>AcctSvcrRef"`
+	DebtorName        string                     `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPartyName   string                     `xml:"RltdPties>Dbtr>Pty>Nm"` // Version 001.08
+	CreditorName      string                     `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPartyName string                     `xml:"RltdPties>Cdtr>Pty>Nm"` // Version 001.08
+	Unstructured      []string                   `xml:"RmtInf>Ustrd"`
+	Structured        []camtStructuredRemittance `xml:"RmtInf>Strd"`
+	AdditionalInfo    string                     `xml:"AddtlTxInf"`
+}
+
+// camtStructuredRemittance is structured remittance information, such as the number of
+// the invoice being paid
+type camtStructuredRemittance struct {
+	DocumentNumbers   []string `xml:"RfrdDocInf>Nb"`
+	CreditorReference string   `xml:"CdtrRefInf>Ref"`
+}
+
+// parseCAMT053 reads the statements of a camt.053 file
+func parseCAMT053(r io.Reader) ([]camtStatement, error) {
+	var document camtDocument
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 file: %v", err)
+	}
+	if len(document.Statements) == 0 {
+		return nil, fmt.Errorf("no statements found; expected a camt.053 BkToCstmrStmt document")
+	}
+	return document.Statements, nil
+}
+
+// Booked reports whether the entry is booked; pending and information-only entries are
+// not on the books yet
+func (e camtEntry) Booked() bool {
+	status := strings.TrimSpace(e.Status.Code)
+	if status == "" {
+		status = strings.TrimSpace(e.Status.Text)
+	}
+	return status == "" || status == "BOOK"
+}
+
+// statementLine converts an entry to a statement line for import. The description is the
+// counterparty with the remittance information; the reference is the first structured
+// reference, such as an invoice number, or else the end-to-end ID.
+func (e camtEntry) statementLine() (statementLine, error) {
+	line := statementLine{FITID: strings.TrimSpace(e.ServicerReference)}
+	if line.FITID == "" {
+		line.FITID = strings.TrimSpace(e.Reference)
+	}
+
+	var err error
+	if line.PostedDate, err = e.BookingDate.Time(); err != nil {
+		return line, err
+	}
+	if line.Amount, err = models.ParseMoney(strings.TrimSpace(e.Amount.Value)); err != nil {
+		return line, fmt.Errorf("invalid camt.053 amount %q", e.Amount.Value)
+	}
+	if strings.TrimSpace(e.Indicator) == "DBIT" {
+		line.Amount = -line.Amount.Abs()
+	}
+
+	var counterparties, remittance []string
+	var endToEndID string
+	for _, details := range e.Details {
+		counterparty := details.DebtorName + details.DebtorPartyName
+		if line.Amount < 0 {
+			counterparty = details.CreditorName + details.CreditorPartyName
+		}
+		if counterparty = strings.TrimSpace(counterparty); counterparty != "" {
+			counterparties = append(counterparties, counterparty)
+		}
+		for _, text := range details.Unstructured {
+			if text = strings.TrimSpace(text); text != "" {
+				remittance = append(remittance, text)
+			}
+		}
+		for _, structured := range details.Structured {
+			for _, reference := range append(structured.DocumentNumbers, structured.CreditorReference) {
+				if reference = strings.TrimSpace(reference); reference != "" {
+					line.References = append(line.References, reference)
+				}
+			}
+		}
+		if text := strings.TrimSpace(details.AdditionalInfo); text != "" && len(details.Unstructured) == 0 {
+			remittance = append(remittance, text)
+		}
+		if id := strings.TrimSpace(details.EndToEndID); id != "" && id != "NOTPROVIDED" && endToEndID == "" {
+			endToEndID = id
+		}
+		if line.FITID == "" {
+			line.FITID = strings.TrimSpace(details.ServicerReference)
+		}
+	}
+	line.Remittance = strings.Join(remittance, " ")
+
+	line.Description = strings.Join(counterparties, ", ")
+	switch {
+	case line.Description == "":
+		line.Description = line.Remittance
+	case line.Remittance != "":
+		line.Description += " - " + line.Remittance
+	}
+	if line.Description == "" {
+		line.Description = strings.TrimSpace(e.AdditionalInfo)
+	}
+
+	switch {
+	case len(line.References) > 0:
+		line.Reference = line.References[0]
+	case endToEndID != "":
+		line.Reference = endToEndID
+	default:
+		line.Reference = strings.TrimSpace(e.Reference)
+	}
+	return line, nil
+}
+
+// invoiceNumberIn reports whether an invoice number appears in text as a whole token
+func invoiceNumberIn(number, text string) bool {
+	pattern := `(?i)(^|[^0-9A-Za-z])` + regexp.QuoteMeta(number) + `($|[^0-9A-Za-z])`
+	matched, _ := regexp.MatchString(pattern, text)
+	return matched
+}
+
+// matchStatementInvoices matches imported deposits to the invoices they pay by the invoice
+// numbers in their remittance information. An invoice marked paid without a payment is
+// matched when its receipt equals the deposit; for an open CAD invoice whose balance is
+// the deposit amount, a payment is recorded on the booking date and matched. Other
+// references are reported for manual review.
+func matchStatementInvoices(tx *gorm.DB, bankAccount *models.BankAccount, transactions []models.BankTransaction, lines []statementLine, userID uint) ([]gin.H, error) {
+	results := []gin.H{}
+
+	// Invoice receipts post to the cash account
+	if bankAccount.AccountCode != accountCash {
+		return results, nil
+	}
+
+	var invoices []models.Invoice
+	if err := tx.Where("company_id = ? AND status IN ?", bankAccount.CompanyID, []string{"sent", "overdue", "partially_paid", "paid"}).
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	if len(invoices) == 0 {
+		return results, nil
+	}
+
+	var matchedIDs []uint
+	if err := tx.Model(&models.BankTransaction{}).
+		Where("company_id = ? AND status = ? AND matched_source_type = ?", bankAccount.CompanyID, "matched", sourceInvoice).
+		Pluck("matched_source_id", &matchedIDs).Error; err != nil {
+		return nil, err
+	}
+	matched := make(map[uint]bool, len(matchedIDs))
+	for _, id := range matchedIDs {
+		matched[id] = true
+	}
+
+	for i := range transactions {
+		transaction, line := &transactions[i], lines[i]
+		if transaction.Amount <= 0 {
+			continue
+		}
+
+		// Structured references are exact; otherwise look for the number in the text
+		var candidates []*models.Invoice
+		for j := range invoices {
+			for _, reference := range line.References {
+				if strings.EqualFold(reference, invoices[j].InvoiceNumber) {
+					candidates = append(candidates, &invoices[j])
+					break
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			text := line.Remittance + " " + line.Description
+			for j := range invoices {
+				if invoiceNumberIn(invoices[j].InvoiceNumber, text) {
+					candidates = append(candidates, &invoices[j])
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		result := gin.H{"bank_transaction_id": transaction.ID}
+		if len(candidates) > 1 {
+			numbers := make([]string, len(candidates))
+			for j, invoice := range candidates {
+				numbers[j] = invoice.InvoiceNumber
+			}
+			result["invoice_numbers"] = numbers
+			result["result"] = "ambiguous"
+			results = append(results, result)
+			continue
+		}
+
+		invoice := candidates[0]
+		result["invoice_id"] = invoice.ID
+		result["invoice_number"] = invoice.InvoiceNumber
+
+		switch {
+		case matched[invoice.ID]:
+			result["result"] = "already_matched"
+
+		case invoice.Status == "paid" && invoice.AmountPaid == 0:
+			if inCAD(invoice.Total, invoicePaidRate(invoice)) != transaction.Amount {
+				result["result"] = "amount_mismatch"
+				break
+			}
+			if err := markBankTransactionMatched(tx, transaction, sourceInvoice, invoice.ID, userID); err != nil {
+				return nil, err
+			}
+			matched[invoice.ID] = true
+			result["result"] = "matched"
+
+		case invoice.Status == "paid":
+			result["result"] = "already_paid"
+
+		case invoice.Currency != functionalCurrency:
+			result["result"] = "foreign_currency"
+
+		case invoiceBalance(invoice) != transaction.Amount:
+			result["result"] = "amount_mismatch"
+
+		default:
+			period, err := closedPeriodContaining(invoice.CompanyID, transaction.PostedDate)
+			if err != nil {
+				return nil, err
+			}
+			if period != nil {
+				result["result"] = "closed_period"
+				break
+			}
+
+			// Record the payment of the balance and match it
+			payment := models.Payment{
+				ClientID:          invoice.ClientID,
+				PaymentDate:       transaction.PostedDate,
+				Amount:            transaction.Amount,
+				Currency:          functionalCurrency,
+				ExchangeRate:      1,
+				Method:            "bank_transfer",
+				Reference:         transaction.Reference,
+				AccountCode:       bankAccount.AccountCode,
+				BankTransactionID: &transaction.ID,
+				CompanyID:         invoice.CompanyID,
+				Allocations: []models.PaymentAllocation{
+					{InvoiceID: invoice.ID, Amount: transaction.Amount, AppliedDate: transaction.PostedDate},
+				},
+			}
+			if err := recordPayment(tx, &payment, []uint{invoice.ID}); err != nil {
+				return nil, err
+			}
+			if err := markBankTransactionMatched(tx, transaction, sourcePayment, payment.ID, userID); err != nil {
+				return nil, err
+			}
+			invoice.Status, invoice.AmountPaid = "paid", invoice.AmountPaid+transaction.Amount
+			result["payment_id"] = payment.ID
+			result["result"] = "paid_and_matched"
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ImportCAMT053Statement imports the booked entries of an ISO 20022 camt.053 statement
+// into a bank account. Entries already imported, identified by the bank's entry
+// reference, are skipped. Deposits whose remittance information names an invoice number
+// are matched to the invoice; see matchStatementInvoices. Files with statements for
+// several accounts need the IBAN or account number in account_id to choose one.
+func ImportCAMT053Statement(c *gin.Context) {
+	bankAccount, src, ok := openStatementUpload(c)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	statements, err := parseCAMT053(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Choose the statement to import
+	accountIDs := make([]string, len(statements))
+	for i, statement := range statements {
+		accountIDs[i] = statement.AccountID()
+	}
+	selected, ok := selectStatements(c, accountIDs)
+	if !ok {
+		return
+	}
+
+	var lines []statementLine
+	for _, i := range selected {
+		for _, entry := range statements[i].Entries {
+			if !entry.Booked() {
+				continue
+			}
+			line, err := entry.statementLine()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	transactions, saved, duplicates, err := saveStatementLines(tx, bankAccount, lines)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bank transactions"})
+		return
+	}
+
+	// Match deposits to the invoices they pay
+	invoiceMatches, err := matchStatementInvoices(tx, bankAccount, transactions, saved, c.GetUint("user_id"))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match invoices"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Summarize the imported statement with its opening and closing balances
+	statement := statements[selected[0]]
+	summary := gin.H{
+		"statement_id": statement.ID,
+		"account_id":   statement.AccountID(),
+		"currency":     statement.Currency,
+	}
+	for _, balance := range statement.Balances {
+		var key string
+		switch balance.Type {
+		case "OPBD", "PRCD":
+			key = "opening_balance"
+		case "CLBD":
+			key = "closing_balance"
+		default:
+			continue
+		}
+		amount, err := models.ParseMoney(strings.TrimSpace(balance.Amount.Value))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(balance.Indicator) == "DBIT" {
+			amount = -amount.Abs()
+		}
+		summary[key] = amount
+		if date, err := balance.Date.Time(); err == nil {
+			summary[key+"_date"] = date.Format("2006-01-02")
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported":        len(transactions),
+		"duplicates":      duplicates,
+		"statement":       summary,
+		"transactions":    transactions,
+		"invoice_matches": invoiceMatches,
+	})
+}
@@ -0,0 +1,625 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BankMatchSuggestion is a book item that may account for a statement line
+type BankMatchSuggestion struct {
+	BookItem
+	DaysApart int `json:"days_apart"`
+}
+
+// markBankTransactionMatched records that a statement line is accounted for by a source record
+func markBankTransactionMatched(tx *gorm.DB, transaction *models.BankTransaction, sourceType string, sourceID uint, userID uint) error {
+	now := time.Now()
+	return tx.Model(transaction).Updates(map[string]interface{}{
+		"status":              "matched",
+		"matched_source_type": sourceType,
+		"matched_source_id":   sourceID,
+		"matched_at":          now,
+		"matched_by_id":       userID,
+	}).Error
+}
+
+// ListBankTransactions lists statement lines
+func ListBankTransactions(c *gin.Context) {
+	var transactions []models.BankTransaction
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.BankTransaction{})
+
+	// Apply filters
+	if bankAccountID := c.Query("bank_account_id"); bankAccountID != "" {
+		query = query.Where("bank_account_id = ?", bankAccountID)
+	}
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("posted_date >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("posted_date <= ?", endDate)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bank transactions"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("posted_date DESC, id DESC").Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bank transactions"})
+		return
+	}
+
+	response := gin.H{
+		"data":       transactions,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateBankTransactions adds statement lines to a bank account
+func CreateBankTransactions(c *gin.Context) {
+	var req models.CreateBankTransactionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify bank account exists
+	var bankAccount models.BankAccount
+	if err := database.DB.First(&bankAccount, req.BankAccountID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bank account not found"})
+		return
+	}
+
+	rules, err := newCategorizer(database.DB, bankAccount.CompanyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization rules"})
+		return
+	}
+
+	transactions := make([]models.BankTransaction, 0, len(req.Transactions))
+	for i, line := range req.Transactions {
+		postedDate, err := time.Parse("2006-01-02", line.PostedDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid posted date format on line %d. Use YYYY-MM-DD", i+1)})
+			return
+		}
+		transaction := models.BankTransaction{
+			BankAccountID: bankAccount.ID,
+			PostedDate:    postedDate,
+			Description:   line.Description,
+			Amount:        line.Amount,
+			Reference:     line.Reference,
+			Status:        "unmatched",
+			CompanyID:     bankAccount.CompanyID,
+		}
+		if rule := rules.match(transaction.Description, transaction.Amount, &bankAccount.ID, nil); rule != nil {
+			transaction.CategorizationRuleID = &rule.ID
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err := database.DB.Create(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bank transactions"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transactions)
+}
+
+// DeleteBankTransaction deletes an unmatched statement line
+func DeleteBankTransaction(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var transaction models.BankTransaction
+	if err := database.DB.First(&transaction, transactionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank transaction not found"})
+		return
+	}
+
+	if transaction.Status == "matched" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Unmatch the bank transaction before deleting it"})
+		return
+	}
+
+	if err := database.DB.Delete(&transaction).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bank transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bank transaction deleted successfully"})
+}
+
+// GetBankTransactionSuggestions suggests unmatched book items with the same amount as a
+// statement line, posted within a number of days of it (default 7), closest first
+func GetBankTransactionSuggestions(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var transaction models.BankTransaction
+	if err := database.DB.Preload("BankAccount").First(&transaction, transactionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank transaction not found"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+		return
+	}
+
+	items, err := bankBookItems(database.DB, transaction.BankAccount,
+		transaction.PostedDate.AddDate(0, 0, -days), transaction.PostedDate.AddDate(0, 0, days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ledger postings"})
+		return
+	}
+	matched, err := matchedBookItems(database.DB, transaction.BankAccountID, farFuture)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch matched transactions"})
+		return
+	}
+
+	suggestions := []BankMatchSuggestion{}
+	for _, item := range items {
+		if item.Amount != transaction.Amount || matched[bookItemKey{SourceType: item.SourceType, SourceID: item.SourceID}] {
+			continue
+		}
+		daysApart := int(item.Date.Sub(transaction.PostedDate).Hours() / 24)
+		if daysApart < 0 {
+			daysApart = -daysApart
+		}
+		suggestions = append(suggestions, BankMatchSuggestion{BookItem: item, DaysApart: daysApart})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].DaysApart < suggestions[j].DaysApart
+	})
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// MatchBankTransaction confirms that a statement line is accounted for by a source
+// record posted to the bank account's ledger account for the same amount
+func MatchBankTransaction(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var req models.MatchBankTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transaction models.BankTransaction
+	if err := database.DB.Preload("BankAccount").First(&transaction, transactionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank transaction not found"})
+		return
+	}
+
+	if transaction.Status == "matched" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bank transaction is already matched"})
+		return
+	}
+
+	// Find the record's postings to the bank account's ledger account
+	items, err := bankBookItems(database.DB, transaction.BankAccount, time.Time{}, farFuture)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ledger postings"})
+		return
+	}
+	var item *BookItem
+	for i := range items {
+		if items[i].SourceType == req.SourceType && items[i].SourceID == req.SourceID {
+			item = &items[i]
+			break
+		}
+	}
+	if item == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Record has no postings to the bank account's ledger account"})
+		return
+	}
+	if item.Amount != transaction.Amount {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Statement amount %s does not match the book amount %s", transaction.Amount, item.Amount)})
+		return
+	}
+
+	// Reject records already matched to another statement line
+	var matchedCount int64
+	if err := database.DB.Model(&models.BankTransaction{}).
+		Where("company_id = ? AND status = ? AND matched_source_type = ? AND matched_source_id = ?", transaction.CompanyID, "matched", req.SourceType, req.SourceID).
+		Count(&matchedCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check matched transactions"})
+		return
+	}
+	if matchedCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Record is already matched to another bank transaction"})
+		return
+	}
+
+	if err := markBankTransactionMatched(database.DB, &transaction, req.SourceType, req.SourceID, c.GetUint("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match bank transaction"})
+		return
+	}
+
+	// Load updated transaction
+	if err := database.DB.First(&transaction, transaction.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated bank transaction data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// UnmatchBankTransaction removes the match of a statement line
+func UnmatchBankTransaction(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var transaction models.BankTransaction
+	if err := database.DB.First(&transaction, transactionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank transaction not found"})
+		return
+	}
+
+	if err := database.DB.Model(&transaction).Updates(map[string]interface{}{
+		"status":              "unmatched",
+		"matched_source_type": nil,
+		"matched_source_id":   nil,
+		"matched_at":          nil,
+		"matched_by_id":       nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmatch bank transaction"})
+		return
+	}
+
+	// Load updated transaction
+	if err := database.DB.First(&transaction, transaction.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated bank transaction data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// bankRecordProblem returns why a statement line cannot be recorded as requested, or an
+// empty string when it can
+func bankRecordProblem(db *gorm.DB, transaction *models.BankTransaction, req *models.CreateRecordFromBankTransactionRequest) string {
+	if transaction.Status == "matched" {
+		return "Bank transaction is already matched"
+	}
+	if transaction.BankAccount.AccountCode != accountCash {
+		return "Records can only be created from bank accounts reconciled against the cash account"
+	}
+
+	// Withdrawals become expenses or owner payments, deposits become income entries
+	if (req.RecordType == "income_entry") != (transaction.Amount > 0) {
+		return "Deposits can only be recorded as income entries, withdrawals as expenses or owner payments"
+	}
+	if req.HSTAmount >= transaction.Amount.Abs() {
+		return "HST amount must be less than the transaction amount"
+	}
+
+	if req.RecordType == "expense" {
+		if req.CategoryID == nil {
+			return "category_id is required for expenses"
+		}
+		var category models.ExpenseCategory
+		if err := db.First(&category, *req.CategoryID).Error; err != nil {
+			return "Expense category not found"
+		}
+	}
+	if req.ClientID != nil {
+		var client models.Client
+		if err := db.First(&client, *req.ClientID).Error; err != nil {
+			return "Client not found"
+		}
+	}
+	return ""
+}
+
+// recordBankTransaction creates the expense, income entry or owner payment for a statement
+// line, posts it to the general ledger and matches the line to it. The request must have
+// been checked with bankRecordProblem.
+func recordBankTransaction(tx *gorm.DB, transaction *models.BankTransaction, req *models.CreateRecordFromBankTransactionRequest, userID uint) (interface{}, error) {
+	gross := transaction.Amount.Abs()
+	description := transaction.Description
+	if req.Description != nil {
+		description = *req.Description
+	}
+
+	var sourceType string
+	var sourceID uint
+	var record interface{}
+	var entries []models.JournalEntry
+
+	switch req.RecordType {
+	case "expense":
+		expenseAccount, err := expenseAccountCode(tx, *req.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		expense := models.Expense{
+			Description:  description,
+			CategoryID:   *req.CategoryID,
+			Amount:       gross - req.HSTAmount,
+			HSTPaid:      req.HSTAmount,
+			Currency:     functionalCurrency,
+			ExchangeRate: 1,
+			ExpenseDate:  transaction.PostedDate,
+			PaidBy:       "corp",
+			CompanyID:    transaction.CompanyID,
+		}
+		if err := tx.Create(&expense).Error; err != nil {
+			return nil, err
+		}
+		sourceType, sourceID, record = sourceExpense, expense.ID, &expense
+		entries = expenseJournalEntries(&expense, expenseAccount)
+
+	case "income_entry":
+		incomeType := "other"
+		if req.ClientID != nil {
+			incomeType = "client"
+		}
+		if req.IncomeType != nil {
+			incomeType = *req.IncomeType
+		}
+
+		incomeEntry := models.IncomeEntry{
+			Description:  description,
+			Amount:       gross - req.HSTAmount,
+			HSTAmount:    req.HSTAmount,
+			Total:        gross,
+			Currency:     functionalCurrency,
+			ExchangeRate: 1,
+			IncomeType:   incomeType,
+			ClientID:     req.ClientID,
+			IncomeDate:   transaction.PostedDate,
+			CompanyID:    transaction.CompanyID,
+		}
+		if err := tx.Create(&incomeEntry).Error; err != nil {
+			return nil, err
+		}
+		sourceType, sourceID, record = sourceIncomeEntry, incomeEntry.ID, &incomeEntry
+		entries = incomeEntryJournalEntries(&incomeEntry)
+
+	case "owner_payment":
+		paymentType := "other"
+		if req.PaymentType != nil {
+			paymentType = *req.PaymentType
+		}
+
+		payment := models.OwnerPayment{
+			Description: description,
+			Amount:      gross,
+			PaymentDate: transaction.PostedDate,
+			PaymentType: paymentType,
+			Reference:   transaction.Reference,
+			CompanyID:   transaction.CompanyID,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return nil, err
+		}
+		sourceType, sourceID, record = sourceOwnerPayment, payment.ID, &payment
+		entries = ownerPaymentJournalEntries(&payment)
+
+	default:
+		return nil, fmt.Errorf("unknown record type %q", req.RecordType)
+	}
+
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceType, sourceID, entries); err != nil {
+		return nil, err
+	}
+
+	// Match the statement line to the new record
+	if err := markBankTransactionMatched(tx, transaction, sourceType, sourceID, userID); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// CreateRecordFromBankTransaction records an unmatched statement line as a new expense,
+// income entry or owner payment, posts it to the general ledger and matches the line to
+// it. Amounts are taken from the statement; an HST amount splits out the tax portion.
+func CreateRecordFromBankTransaction(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var req models.CreateRecordFromBankTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transaction models.BankTransaction
+	if err := database.DB.Preload("BankAccount").First(&transaction, transactionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank transaction not found"})
+		return
+	}
+
+	// Fill in what was left out from the line's categorization rule
+	rules, err := newCategorizer(database.DB, transaction.CompanyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization rules"})
+		return
+	}
+	applyCategorizationRule(rules, rules.forBankTransaction(&transaction), &req, &transaction)
+
+	if problem := bankRecordProblem(database.DB, &transaction, &req); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, transaction.CompanyID, transaction.PostedDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	record, err := recordBankTransaction(tx, &transaction, &req, c.GetUint("user_id"))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record bank transaction"})
+		return
+	}
+
+	// Learn a rule from the manual categorization
+	var rule *models.CategorizationRule
+	if req.LearnRule && req.RecordType != "owner_payment" {
+		if rule, err = learnCategorizationRule(tx, record, transaction.Description, nil, nil); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to learn categorization rule"})
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load updated transaction
+	if err := database.DB.First(&transaction, transaction.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated bank transaction data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"bank_transaction":    transaction,
+		"record":              record,
+		"categorization_rule": rule,
+	})
+}
+
+// BulkCreateRecordsFromBankTransactions records unmatched statement lines in bulk:
+// withdrawals become expenses in one category and deposits become income entries. Lines
+// that cannot be recorded are skipped and reported with the reason.
+func BulkCreateRecordsFromBankTransactions(c *gin.Context) {
+	var req models.BulkCreateRecordsFromBankTransactionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transactions []models.BankTransaction
+	if err := database.DB.Preload("BankAccount").Where("id IN ?", req.BankTransactionIDs).
+		Order("posted_date, id").Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bank transactions"})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	companies := make(map[uint]models.Company)
+	categorizers := make(map[uint]*categorizer)
+	records := []interface{}{}
+	skipped := []gin.H{}
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	for i := range transactions {
+		transaction := &transactions[i]
+
+		recordReq := models.CreateRecordFromBankTransactionRequest{RecordType: "income_entry", ClientID: req.ClientID}
+		if transaction.Amount < 0 {
+			recordReq = models.CreateRecordFromBankTransactionRequest{RecordType: "expense"}
+		}
+
+		// The line's categorization rule comes before the defaults of the request
+		rules, ok := categorizers[transaction.CompanyID]
+		if !ok {
+			var err error
+			if rules, err = newCategorizer(tx, transaction.CompanyID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization rules"})
+				return
+			}
+			categorizers[transaction.CompanyID] = rules
+		}
+		ruleSetHST := applyCategorizationRule(rules, rules.forBankTransaction(transaction), &recordReq, transaction)
+		if recordReq.CategoryID == nil && recordReq.RecordType == "expense" {
+			recordReq.CategoryID = req.ExpenseCategoryID
+		}
+		if recordReq.IncomeType == nil && recordReq.RecordType == "income_entry" {
+			recordReq.IncomeType = req.IncomeType
+		}
+
+		// Split out HST at the company's rate
+		if req.IncludesHST && !ruleSetHST {
+			company, ok := companies[transaction.CompanyID]
+			if !ok {
+				if err := tx.First(&company, transaction.CompanyID).Error; err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load company data"})
+					return
+				}
+				companies[transaction.CompanyID] = company
+			}
+			recordReq.HSTAmount = transaction.Amount.Abs().MulRate(company.HSTRate / (1 + company.HSTRate))
+		}
+
+		if problem := bankRecordProblem(tx, transaction, &recordReq); problem != "" {
+			skipped = append(skipped, gin.H{"bank_transaction_id": transaction.ID, "reason": problem})
+			continue
+		}
+		period, err := closedPeriodContaining(transaction.CompanyID, transaction.PostedDate)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check accounting periods"})
+			return
+		}
+		if period != nil {
+			skipped = append(skipped, gin.H{"bank_transaction_id": transaction.ID, "reason": "Posted in a closed accounting period"})
+			continue
+		}
+
+		record, err := recordBankTransaction(tx, transaction, &recordReq, userID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to record bank transaction %d", transaction.ID)})
+			return
+		}
+		records = append(records, record)
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"created": len(records),
+		"records": records,
+		"skipped": skipped,
+	})
+}
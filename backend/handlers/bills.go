@@ -0,0 +1,998 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// billStatus returns the status of a bill from the amount paid on it
+func billStatus(bill *models.Bill) string {
+	switch {
+	case bill.Status == "void":
+		return "void"
+	case bill.AmountPaid == 0:
+		return "open"
+	case bill.AmountPaid < bill.Total:
+		return "partially_paid"
+	default:
+		return "paid"
+	}
+}
+
+// buildBillItems builds the lines of a bill, defaulting their category to the vendor's
+// and their HST to the company's rate when it is registered for HST. It responds with an error and returns false when
+// a line has no valid category.
+func buildBillItems(c *gin.Context, requests []models.CreateBillItemRequest, vendor *models.Vendor, company *models.Company) ([]models.BillItem, bool) {
+	items := make([]models.BillItem, 0, len(requests))
+	categories := make(map[uint]bool)
+	for _, req := range requests {
+		item := models.BillItem{
+			Description: req.Description,
+			CategoryID:  req.CategoryID,
+			Amount:      req.Amount,
+			ProjectID:   zeroToNil(req.ProjectID),
+		}
+		if req.HSTAmount != nil {
+			item.HSTAmount = *req.HSTAmount
+		} else if company.HSTRegistered {
+			item.HSTAmount = req.Amount.MulRate(company.HSTRate)
+		}
+		if item.CategoryID == 0 && vendor.DefaultCategoryID != nil {
+			item.CategoryID = *vendor.DefaultCategoryID
+		}
+		if item.CategoryID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required on every line unless the vendor has a default category"})
+			return nil, false
+		}
+
+		// Verify category exists
+		if !categories[item.CategoryID] {
+			var category models.ExpenseCategory
+			if err := database.DB.First(&category, item.CategoryID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+				return nil, false
+			}
+			categories[item.CategoryID] = true
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// verifyBillProjects checks that the projects of a bill and its lines belong to the
+// company. It responds with 400 and returns false when one does not.
+func verifyBillProjects(c *gin.Context, companyID uint, projectID *uint, items []models.CreateBillItemRequest) bool {
+	projectIDs := []*uint{projectID}
+	for _, item := range items {
+		projectIDs = append(projectIDs, item.ProjectID)
+	}
+	for _, id := range projectIDs {
+		if id != nil && *id != 0 {
+			if _, ok := findCompanyProject(c, *id, companyID); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// setBillTotals sets a bill's subtotal, HST and total from its lines
+func setBillTotals(bill *models.Bill) {
+	bill.Subtotal, bill.HSTAmount = 0, 0
+	for _, item := range bill.Items {
+		bill.Subtotal += item.Amount
+		bill.HSTAmount += item.HSTAmount
+	}
+	bill.Total = bill.Subtotal + bill.HSTAmount
+}
+
+// postBill posts a bill to the general ledger, each line to its category's expense account
+func postBill(tx *gorm.DB, bill *models.Bill) error {
+	itemAccounts := make(map[uint]string)
+	for _, item := range bill.Items {
+		if _, ok := itemAccounts[item.CategoryID]; ok {
+			continue
+		}
+		account, err := expenseAccountCode(tx, item.CategoryID)
+		if err != nil {
+			return err
+		}
+		itemAccounts[item.CategoryID] = account
+	}
+	return syncSourceJournal(tx, sourceBill, bill.ID, billJournalEntries(bill, itemAccounts))
+}
+
+// refreshBillPayments recalculates the amount paid and status of bills from the payments
+// allocated to them
+func refreshBillPayments(tx *gorm.DB, billIDs []uint) error {
+	for _, billID := range billIDs {
+		var bill models.Bill
+		if err := tx.First(&bill, billID).Error; err != nil {
+			return err
+		}
+
+		var amounts []models.Money
+		if err := tx.Model(&models.BillPaymentAllocation{}).Where("bill_id = ?", billID).Pluck("amount", &amounts).Error; err != nil {
+			return err
+		}
+		bill.AmountPaid = 0
+		for _, amount := range amounts {
+			bill.AmountPaid += amount
+		}
+
+		if err := tx.Model(&bill).Updates(map[string]interface{}{
+			"amount_paid": bill.AmountPaid,
+			"status":      billStatus(&bill),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejectBillWithPayments responds with 409 Conflict and returns true when a bill is void
+// or has payments, so its amounts can no longer change
+func rejectBillWithPayments(c *gin.Context, bill *models.Bill) bool {
+	if bill.Status == "void" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bill is void"})
+		return true
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.BillPaymentAllocation{}).Where("bill_id = ?", bill.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check bill payments"})
+		return true
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bill has payments; delete them first"})
+		return true
+	}
+	return false
+}
+
+// loadBill loads a bill with its vendor, lines and payments
+func loadBill(db *gorm.DB, bill *models.Bill, id interface{}) error {
+	return db.Preload("Vendor").Preload("Project").Preload("Items.Category").Preload("Payments.BillPayment").First(bill, id).Error
+}
+
+// ListBills lists bills, soonest due first
+func ListBills(c *gin.Context) {
+	var bills []models.Bill
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	// Get filter parameters
+	companyID := c.Query("company_id")
+	vendorID := c.Query("vendor_id")
+	projectID := c.Query("project_id")
+	status := c.Query("status")
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	query := database.DB.Preload("Vendor").Model(&models.Bill{})
+
+	// Apply filters
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if vendorID != "" {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if projectID != "" {
+		// Bills for the project or with a line for it
+		query = query.Where("project_id = ? OR id IN (?)", projectID,
+			database.DB.Model(&models.BillItem{}).Select("bill_id").Where("project_id = ?", projectID))
+	}
+	if status == "unpaid" {
+		query = query.Where("status IN ?", []string{"open", "partially_paid"})
+	} else if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if startDate != "" {
+		query = query.Where("bill_date >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where("bill_date <= ?", endDate)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bills"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("due_date ASC, id ASC").Find(&bills).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bills"})
+		return
+	}
+
+	response := gin.H{
+		"data":       bills,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateBill records a vendor's bill and posts it to accounts payable
+func CreateBill(c *gin.Context) {
+	var req models.CreateBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse bill and due dates
+	billDate, err := time.Parse("2006-01-02", req.BillDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bill date format. Use YYYY-MM-DD"})
+		return
+	}
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due date format. Use YYYY-MM-DD"})
+		return
+	}
+	if dueDate.Before(billDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Due date must be on or after the bill date"})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Verify vendor belongs to the company
+	vendor, ok := findCompanyVendor(c, req.VendorID, req.CompanyID)
+	if !ok {
+		return
+	}
+
+	// Verify projects belong to the company
+	if !verifyBillProjects(c, req.CompanyID, req.ProjectID, req.Items) {
+		return
+	}
+
+	// Check for the same bill recorded twice
+	var count int64
+	database.DB.Model(&models.Bill{}).Where("vendor_id = ? AND bill_number = ?", req.VendorID, req.BillNumber).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A bill with this number already exists for the vendor"})
+		return
+	}
+
+	items, ok := buildBillItems(c, req.Items, vendor, &company)
+	if !ok {
+		return
+	}
+
+	bill := models.Bill{
+		BillNumber: req.BillNumber,
+		VendorID:   req.VendorID,
+		BillDate:   billDate,
+		DueDate:    dueDate,
+		Status:     "open",
+		Notes:      req.Notes,
+		ProjectID:  zeroToNil(req.ProjectID),
+		CompanyID:  req.CompanyID,
+		Items:      items,
+	}
+	setBillTotals(&bill)
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, bill.CompanyID, bill.BillDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Create bill with its lines
+	if err := tx.Create(&bill).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bill"})
+		return
+	}
+
+	// Post to the general ledger
+	if err := postBill(tx, &bill); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post bill to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load bill with related data
+	if err := loadBill(database.DB, &bill, bill.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load bill data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bill)
+}
+
+// GetBill retrieves a bill by ID with its lines and payments
+func GetBill(c *gin.Context) {
+	billID := c.Param("id")
+
+	var bill models.Bill
+	if err := loadBill(database.DB, &bill, billID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bill)
+}
+
+// UpdateBill updates a bill that has no payments and reposts it
+func UpdateBill(c *gin.Context) {
+	billID := c.Param("id")
+
+	var req models.UpdateBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find bill
+	var bill models.Bill
+	if err := database.DB.Preload("Vendor").Preload("Company").Preload("Items").First(&bill, billID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+		return
+	}
+	if rejectBillWithPayments(c, &bill) {
+		return
+	}
+
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, bill.CompanyID, bill.BillDate) {
+		return
+	}
+
+	// Update fields if provided
+	if req.BillNumber != nil && *req.BillNumber != bill.BillNumber {
+		var count int64
+		database.DB.Model(&models.Bill{}).
+			Where("vendor_id = ? AND bill_number = ? AND id != ?", bill.VendorID, *req.BillNumber, bill.ID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A bill with this number already exists for the vendor"})
+			return
+		}
+		bill.BillNumber = *req.BillNumber
+	}
+	if req.BillDate != nil {
+		billDate, err := time.Parse("2006-01-02", *req.BillDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bill date format. Use YYYY-MM-DD"})
+			return
+		}
+		bill.BillDate = billDate
+	}
+	if req.DueDate != nil {
+		dueDate, err := time.Parse("2006-01-02", *req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due date format. Use YYYY-MM-DD"})
+			return
+		}
+		bill.DueDate = dueDate
+	}
+	if bill.DueDate.Before(bill.BillDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Due date must be on or after the bill date"})
+		return
+	}
+	if req.Notes != nil {
+		bill.Notes = req.Notes
+	}
+	if !verifyBillProjects(c, bill.CompanyID, req.ProjectID, req.Items) {
+		return
+	}
+	if req.ProjectID != nil {
+		bill.ProjectID = zeroToNil(req.ProjectID)
+	}
+
+	var items []models.BillItem
+	if len(req.Items) > 0 {
+		var ok bool
+		if items, ok = buildBillItems(c, req.Items, &bill.Vendor, &bill.Company); !ok {
+			return
+		}
+		bill.Items = items
+		setBillTotals(&bill)
+	}
+
+	// Reject changes that move the record into a closed accounting period
+	if rejectClosedPeriod(c, bill.CompanyID, bill.BillDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Model(&models.Bill{}).Where("id = ?", bill.ID).Updates(map[string]interface{}{
+		"bill_number": bill.BillNumber,
+		"bill_date":   bill.BillDate,
+		"due_date":    bill.DueDate,
+		"notes":       bill.Notes,
+		"project_id":  bill.ProjectID,
+		"subtotal":    bill.Subtotal,
+		"hst_amount":  bill.HSTAmount,
+		"total":       bill.Total,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bill"})
+		return
+	}
+
+	// Replace the lines if provided
+	if items != nil {
+		if err := tx.Where("bill_id = ?", bill.ID).Delete(&models.BillItem{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete existing bill items"})
+			return
+		}
+		for i := range items {
+			items[i].BillID = bill.ID
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bill items"})
+			return
+		}
+		bill.Items = items
+	}
+
+	// Repost to the general ledger
+	if err := postBill(tx, &bill); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post bill to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load updated bill with related data
+	var updated models.Bill
+	if err := loadBill(database.DB, &updated, bill.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated bill data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// VoidBill voids a bill that has no payments, reversing its ledger postings. The bill is
+// kept for the record.
+func VoidBill(c *gin.Context) {
+	billID := c.Param("id")
+
+	var bill models.Bill
+	if err := database.DB.First(&bill, billID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+		return
+	}
+	if rejectBillWithPayments(c, &bill) {
+		return
+	}
+
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, bill.CompanyID, bill.BillDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Model(&bill).Update("status", "void").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to void bill"})
+		return
+	}
+
+	// Reverse the bill's ledger postings
+	if err := reverseSourceJournal(tx, sourceBill, bill.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse bill in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load updated bill with related data
+	if err := loadBill(database.DB, &bill, bill.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated bill data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bill)
+}
+
+// DeleteBill deletes a bill that has no payments
+func DeleteBill(c *gin.Context) {
+	billID := c.Param("id")
+
+	// Find bill
+	var bill models.Bill
+	if err := database.DB.First(&bill, billID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.BillPaymentAllocation{}).Where("bill_id = ?", bill.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check bill payments"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete bill with payments"})
+		return
+	}
+
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, bill.CompanyID, bill.BillDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Soft delete bill
+	if err := tx.Delete(&bill).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bill"})
+		return
+	}
+
+	// Reverse the bill's ledger postings
+	if err := reverseSourceJournal(tx, sourceBill, bill.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse bill in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bill deleted successfully"})
+}
+
+// ListBillPayments lists bill payments, latest first
+func ListBillPayments(c *gin.Context) {
+	var payments []models.BillPayment
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	// Get filter parameters
+	companyID := c.Query("company_id")
+	vendorID := c.Query("vendor_id")
+
+	query := database.DB.Preload("Vendor").Preload("Allocations").Model(&models.BillPayment{})
+
+	// Apply filters
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if vendorID != "" {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bill payments"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("payment_date DESC, id DESC").Find(&payments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bill payments"})
+		return
+	}
+
+	response := gin.H{
+		"data":       payments,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateBillPayment records a payment to a vendor settling one or more of its bills. The
+// payment amount is the sum of the amounts applied to each bill, none of which may be
+// more than the bill's balance.
+func CreateBillPayment(c *gin.Context) {
+	var req models.CreateBillPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse payment date
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment_date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Verify vendor belongs to the company
+	if _, ok := findCompanyVendor(c, req.VendorID, req.CompanyID); !ok {
+		return
+	}
+
+	payment := models.BillPayment{
+		VendorID:    req.VendorID,
+		PaymentDate: paymentDate,
+		PaidBy:      req.PaidBy,
+		Reference:   req.Reference,
+		Notes:       req.Notes,
+		CompanyID:   req.CompanyID,
+	}
+	if payment.PaidBy == "" {
+		payment.PaidBy = "corp"
+	}
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, payment.CompanyID, payment.PaymentDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Verify each bill is an unpaid bill of the vendor with enough balance, locking the
+	// bills in ID order so concurrent payments cannot overpay them or deadlock
+	allocations := append([]models.CreateBillPaymentAllocationRequest{}, req.Allocations...)
+	sort.SliceStable(allocations, func(i, j int) bool { return allocations[i].BillID < allocations[j].BillID })
+	billIDs := make([]uint, 0, len(allocations))
+	for _, allocation := range allocations {
+		for _, billID := range billIDs {
+			if billID == allocation.BillID {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Bill %d is listed more than once", billID)})
+				return
+			}
+		}
+		billIDs = append(billIDs, allocation.BillID)
+
+		var bill models.Bill
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("company_id = ? AND vendor_id = ?", req.CompanyID, req.VendorID).
+			First(&bill, allocation.BillID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Bill %d not found for the vendor", allocation.BillID)})
+			return
+		}
+		if bill.Status != "open" && bill.Status != "partially_paid" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Bill %s is %s", bill.BillNumber, bill.Status)})
+			return
+		}
+		if balance := bill.Total - bill.AmountPaid; allocation.Amount > balance {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Payment of %s on bill %s is more than its balance of %s",
+				allocation.Amount, bill.BillNumber, balance)})
+			return
+		}
+
+		payment.Allocations = append(payment.Allocations, models.BillPaymentAllocation{
+			BillID: allocation.BillID,
+			Amount: allocation.Amount,
+		})
+		payment.Amount += allocation.Amount
+	}
+
+	// Create payment with its allocations
+	if err := tx.Create(&payment).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bill payment"})
+		return
+	}
+
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceBillPayment, payment.ID, billPaymentJournalEntries(&payment)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post bill payment to the general ledger"})
+		return
+	}
+
+	// Update the amount paid on each bill
+	if err := refreshBillPayments(tx, billIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bills"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load payment with related data
+	if err := database.DB.Preload("Vendor").Preload("Allocations.Bill").First(&payment, payment.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load bill payment data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// GetBillPayment retrieves a bill payment by ID with the bills it settles
+func GetBillPayment(c *gin.Context) {
+	paymentID := c.Param("id")
+
+	var payment models.BillPayment
+	if err := database.DB.Preload("Vendor").Preload("Allocations.Bill").First(&payment, paymentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill payment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// DeleteBillPayment deletes a bill payment, reopening the bills it settled
+func DeleteBillPayment(c *gin.Context) {
+	paymentID := c.Param("id")
+
+	// Find payment
+	var payment models.BillPayment
+	if err := database.DB.Preload("Allocations").First(&payment, paymentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill payment not found"})
+		return
+	}
+
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, payment.CompanyID, payment.PaymentDate) {
+		return
+	}
+
+	billIDs := make([]uint, len(payment.Allocations))
+	for i, allocation := range payment.Allocations {
+		billIDs[i] = allocation.BillID
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Remove the allocations and soft delete the payment
+	if err := tx.Where("bill_payment_id = ?", payment.ID).Delete(&models.BillPaymentAllocation{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bill payment allocations"})
+		return
+	}
+	if err := tx.Delete(&payment).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bill payment"})
+		return
+	}
+
+	// Reverse the payment's ledger postings
+	if err := reverseSourceJournal(tx, sourceBillPayment, payment.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse bill payment in the general ledger"})
+		return
+	}
+
+	// Update the amount paid on each bill
+	if err := refreshBillPayments(tx, billIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bills"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bill payment deleted successfully"})
+}
+
+// AgingBuckets are balances grouped by how many days past due they are
+type AgingBuckets struct {
+	Current    models.Money `json:"current"` // Not yet due
+	Days1To30  models.Money `json:"days_1_30"`
+	Days31To60 models.Money `json:"days_31_60"`
+	Days61To90 models.Money `json:"days_61_90"`
+	Over90     models.Money `json:"over_90"`
+	Total      models.Money `json:"total"`
+}
+
+// Add adds a balance to the bucket for the days it is past due
+func (b *AgingBuckets) Add(balance models.Money, daysPastDue int) {
+	switch {
+	case daysPastDue <= 0:
+		b.Current += balance
+	case daysPastDue <= 30:
+		b.Days1To30 += balance
+	case daysPastDue <= 60:
+		b.Days31To60 += balance
+	case daysPastDue <= 90:
+		b.Days61To90 += balance
+	default:
+		b.Over90 += balance
+	}
+	b.Total += balance
+}
+
+// AgedPayablesBill is an unpaid bill on the aged payables report
+type AgedPayablesBill struct {
+	BillID      uint         `json:"bill_id"`
+	BillNumber  string       `json:"bill_number"`
+	BillDate    string       `json:"bill_date"`
+	DueDate     string       `json:"due_date"`
+	DaysPastDue int          `json:"days_past_due"`
+	Total       models.Money `json:"total"`
+	Balance     models.Money `json:"balance"`
+}
+
+// AgedPayablesVendor is a vendor's unpaid bills on the aged payables report
+type AgedPayablesVendor struct {
+	VendorID uint   `json:"vendor_id"`
+	Name     string `json:"name"`
+	AgingBuckets
+	Bills []AgedPayablesBill `json:"bills"`
+}
+
+// GetAgedPayables reports the unpaid bills of a company as of a date (as_of_date, today
+// by default) by vendor, in current, 1-30, 31-60, 61-90 and over-90-days-past-due buckets.
+// Only bills dated and payments made on or before the date count.
+func GetAgedPayables(c *gin.Context) {
+	companyID, err := strconv.ParseUint(c.Query("company_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company_id"})
+		return
+	}
+
+	// Parse as-of date
+	asOfDate := currentDate()
+	if value := c.Query("as_of_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as of date format. Use YYYY-MM-DD"})
+			return
+		}
+		asOfDate = parsed
+	}
+
+	var bills []models.Bill
+	if err := database.DB.Preload("Vendor").
+		Where("company_id = ? AND status != ? AND bill_date <= ?", companyID, "void", asOfDate).
+		Order("due_date ASC, id ASC").Find(&bills).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bills"})
+		return
+	}
+
+	// Amounts paid on each bill by the as-of date
+	var allocations []struct {
+		BillID uint
+		Amount models.Money
+	}
+	if err := database.DB.Table("bill_payment_allocations").
+		Select("bill_payment_allocations.bill_id, bill_payment_allocations.amount").
+		Joins("JOIN bill_payments ON bill_payments.id = bill_payment_allocations.bill_payment_id").
+		Where("bill_payments.deleted_at IS NULL AND bill_payments.company_id = ? AND bill_payments.payment_date <= ?", companyID, asOfDate).
+		Scan(&allocations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bill payments"})
+		return
+	}
+	paid := make(map[uint]models.Money)
+	for _, allocation := range allocations {
+		paid[allocation.BillID] += allocation.Amount
+	}
+
+	vendors := make(map[uint]*AgedPayablesVendor)
+	var totals AgingBuckets
+	for _, bill := range bills {
+		balance := bill.Total - paid[bill.ID]
+		if balance <= 0 {
+			continue
+		}
+		daysPastDue := int(asOfDate.Sub(bill.DueDate).Hours() / 24)
+
+		vendor := vendors[bill.VendorID]
+		if vendor == nil {
+			vendor = &AgedPayablesVendor{VendorID: bill.VendorID, Name: bill.Vendor.Name, Bills: []AgedPayablesBill{}}
+			vendors[bill.VendorID] = vendor
+		}
+		vendor.Add(balance, daysPastDue)
+		vendor.Bills = append(vendor.Bills, AgedPayablesBill{
+			BillID:      bill.ID,
+			BillNumber:  bill.BillNumber,
+			BillDate:    bill.BillDate.Format("2006-01-02"),
+			DueDate:     bill.DueDate.Format("2006-01-02"),
+			DaysPastDue: daysPastDue,
+			Total:       bill.Total,
+			Balance:     balance,
+		})
+		totals.Add(balance, daysPastDue)
+	}
+
+	report := make([]AgedPayablesVendor, 0, len(vendors))
+	for _, vendor := range vendors {
+		report = append(report, *vendor)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Name < report[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"company_id": companyID,
+		"as_of_date": asOfDate.Format("2006-01-02"),
+		"vendors":    report,
+		"totals":     totals,
+	})
+}
@@ -0,0 +1,731 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jung-kurt/gofpdf"
+	"gorm.io/gorm"
+)
+
+// spreadBudget phases an annual amount evenly over twelve months, putting the cents that
+// do not divide evenly in the first months
+func spreadBudget(annual models.Money) []models.Money {
+	months := make([]models.Money, 12)
+	for i := range months {
+		months[i] = annual / 12
+		if models.Money(i) < annual%12 {
+			months[i]++
+		}
+	}
+	return months
+}
+
+// buildBudgetLines builds the lines of a budget with their monthly phasing. It responds
+// with an error and returns false when a line names no valid category or revenue account
+// or has no amounts.
+func buildBudgetLines(c *gin.Context, companyID uint, requests []models.BudgetLineRequest) ([]models.BudgetLine, bool) {
+	lines := make([]models.BudgetLine, 0, len(requests))
+	seen := make(map[string]bool)
+	for _, req := range requests {
+		line := models.BudgetLine{LineType: req.LineType}
+
+		var key string
+		switch req.LineType {
+		case "expense":
+			if req.CategoryID == nil || *req.CategoryID == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required on expense lines"})
+				return nil, false
+			}
+			var category models.ExpenseCategory
+			if err := database.DB.First(&category, *req.CategoryID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+				return nil, false
+			}
+			line.CategoryID = req.CategoryID
+			key = fmt.Sprintf("category:%d", *req.CategoryID)
+		case "revenue":
+			if req.AccountCode == nil || *req.AccountCode == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "account_code is required on revenue lines"})
+				return nil, false
+			}
+			var account models.Account
+			if err := database.DB.Where("company_id = ? AND code = ? AND type = ?", companyID, *req.AccountCode, "revenue").First(&account).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Revenue account not found"})
+				return nil, false
+			}
+			line.AccountCode = req.AccountCode
+			key = "account:" + *req.AccountCode
+		}
+		if seen[key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each expense category and revenue account can appear on only one budget line"})
+			return nil, false
+		}
+		seen[key] = true
+
+		// Phase the line over the months of the year
+		amounts := req.MonthlyAmounts
+		switch {
+		case amounts != nil:
+			for _, amount := range amounts {
+				line.AnnualAmount += amount
+			}
+			if req.AnnualAmount != nil && *req.AnnualAmount != line.AnnualAmount {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "annual_amount must equal the sum of monthly_amounts"})
+				return nil, false
+			}
+		case req.AnnualAmount != nil:
+			line.AnnualAmount = *req.AnnualAmount
+			amounts = spreadBudget(line.AnnualAmount)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "annual_amount or monthly_amounts is required on every budget line"})
+			return nil, false
+		}
+		for i, amount := range amounts {
+			line.Months = append(line.Months, models.BudgetMonth{Month: i + 1, Amount: amount})
+		}
+
+		lines = append(lines, line)
+	}
+	return lines, true
+}
+
+// deleteBudgetLines deletes the lines of a budget and their monthly amounts
+func deleteBudgetLines(tx *gorm.DB, budgetID uint) error {
+	lineIDs := tx.Model(&models.BudgetLine{}).Select("id").Where("budget_id = ?", budgetID)
+	if err := tx.Where("budget_line_id IN (?)", lineIDs).Delete(&models.BudgetMonth{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("budget_id = ?", budgetID).Delete(&models.BudgetLine{}).Error
+}
+
+// loadBudget loads a budget with its lines and their monthly amounts
+func loadBudget(db *gorm.DB, budget *models.Budget, id interface{}) error {
+	return db.Preload("Lines.Category").
+		Preload("Lines.Months", func(db *gorm.DB) *gorm.DB { return db.Order("month") }).
+		First(budget, id).Error
+}
+
+// ListBudgets lists budgets, latest fiscal year first
+func ListBudgets(c *gin.Context) {
+	var budgets []models.Budget
+
+	query := database.DB.Model(&models.Budget{})
+
+	// Apply company and fiscal year filters if provided
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if fiscalYear := c.Query("fiscal_year"); fiscalYear != "" {
+		query = query.Where("fiscal_year = ?", fiscalYear)
+	}
+
+	if err := query.Order("fiscal_year DESC").Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+// CreateBudget creates a company's budget for a fiscal year
+func CreateBudget(c *gin.Context) {
+	var req models.CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// A company has one budget per fiscal year
+	var count int64
+	database.DB.Model(&models.Budget{}).Where("company_id = ? AND fiscal_year = ?", req.CompanyID, req.FiscalYear).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A budget for this fiscal year already exists"})
+		return
+	}
+
+	lines, ok := buildBudgetLines(c, req.CompanyID, req.Lines)
+	if !ok {
+		return
+	}
+
+	budget := models.Budget{
+		Name:       req.Name,
+		FiscalYear: req.FiscalYear,
+		Notes:      req.Notes,
+		CompanyID:  req.CompanyID,
+		Lines:      lines,
+	}
+
+	// Create budget with its lines and monthly amounts
+	if err := database.DB.Create(&budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create budget"})
+		return
+	}
+
+	// Load budget with related data
+	if err := loadBudget(database.DB, &budget, budget.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load budget data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+// GetBudget retrieves a budget by ID with its lines and monthly amounts
+func GetBudget(c *gin.Context) {
+	budgetID := c.Param("id")
+
+	var budget models.Budget
+	if err := loadBudget(database.DB, &budget, budgetID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// UpdateBudget updates a budget, replacing its lines when given
+func UpdateBudget(c *gin.Context) {
+	budgetID := c.Param("id")
+
+	var req models.UpdateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find budget
+	var budget models.Budget
+	if err := database.DB.First(&budget, budgetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Notes != nil {
+		updates["notes"] = req.Notes
+	}
+
+	var lines []models.BudgetLine
+	if len(req.Lines) > 0 {
+		var ok bool
+		if lines, ok = buildBudgetLines(c, budget.CompanyID, req.Lines); !ok {
+			return
+		}
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&budget).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget"})
+			return
+		}
+	}
+
+	// Replace the lines if provided
+	if lines != nil {
+		if err := deleteBudgetLines(tx, budget.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete existing budget lines"})
+			return
+		}
+		for i := range lines {
+			lines[i].BudgetID = budget.ID
+		}
+		if err := tx.Create(&lines).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create budget lines"})
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load updated budget with related data
+	var updated models.Budget
+	if err := loadBudget(database.DB, &updated, budget.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated budget data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteBudget deletes a budget and its lines
+func DeleteBudget(c *gin.Context) {
+	budgetID := c.Param("id")
+
+	// Find budget
+	var budget models.Budget
+	if err := database.DB.First(&budget, budgetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := deleteBudgetLines(tx, budget.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget lines"})
+		return
+	}
+
+	// Soft delete budget
+	if err := tx.Delete(&budget).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted successfully"})
+}
+
+// BudgetVsActualAmounts compares the budget and actual amounts of a month or period
+type BudgetVsActualAmounts struct {
+	Budget          models.Money `json:"budget"`
+	Actual          models.Money `json:"actual"`
+	Variance        models.Money `json:"variance"`         // Actual less budget
+	VariancePercent *float64     `json:"variance_percent"` // Variance as a percent of budget; null without a budget
+}
+
+// BudgetVsActualMonth is the comparison for one month of the fiscal year
+type BudgetVsActualMonth struct {
+	Month     int       `json:"month"` // 1 is the first month of the fiscal year
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	BudgetVsActualAmounts
+}
+
+// BudgetVsActualLine is the comparison for one budget line, or a total of lines
+type BudgetVsActualLine struct {
+	LineType     string                `json:"line_type"` // expense, revenue, or net on the net income total
+	CategoryID   *uint                 `json:"category_id,omitempty"`
+	AccountCode  *string               `json:"account_code,omitempty"`
+	Name         string                `json:"name"`
+	AnnualBudget models.Money          `json:"annual_budget"`
+	Months       []BudgetVsActualMonth `json:"months"`
+	YearToDate   BudgetVsActualAmounts `json:"year_to_date"`
+}
+
+// BudgetVsActualReport compares a budget with actual results by month and year to date
+type BudgetVsActualReport struct {
+	BudgetID    uint                 `json:"budget_id"`
+	BudgetName  string               `json:"budget_name"`
+	CompanyName string               `json:"company_name"`
+	FiscalYear  int                  `json:"fiscal_year"`
+	StartDate   time.Time            `json:"start_date"`
+	EndDate     time.Time            `json:"end_date"`
+	AsOfDate    time.Time            `json:"as_of_date"`
+	Lines       []BudgetVsActualLine `json:"lines"`
+	Totals      []BudgetVsActualLine `json:"totals"` // Total revenue, total expenses and net income
+}
+
+// newBudgetVsActualAmounts compares a budget and actual amount
+func newBudgetVsActualAmounts(budget, actual models.Money) BudgetVsActualAmounts {
+	amounts := BudgetVsActualAmounts{Budget: budget, Actual: actual, Variance: actual - budget}
+	if budget != 0 {
+		percent := math.Round(float64(amounts.Variance)/math.Abs(float64(budget))*1000) / 10
+		amounts.VariancePercent = &percent
+	}
+	return amounts
+}
+
+// fiscalMonthIndex returns the index of the month containing a date, or -1
+func fiscalMonthIndex(months []FiscalPeriod, date time.Time) int {
+	for i, month := range months {
+		if month.Contains(date) {
+			return i
+		}
+	}
+	return -1
+}
+
+// budgetActuals returns the actual amounts of each month through a date: expenses and
+// bill lines in CAD by expense category, and net credits by revenue account
+func budgetActuals(db *gorm.DB, companyID uint, months []FiscalPeriod, through time.Time) (map[uint][]models.Money, map[string][]models.Money, error) {
+	byCategory := make(map[uint][]models.Money)
+	byAccount := make(map[string][]models.Money)
+	addCategory := func(categoryID uint, date time.Time, amount models.Money) {
+		i := fiscalMonthIndex(months, date)
+		if i < 0 {
+			return
+		}
+		if byCategory[categoryID] == nil {
+			byCategory[categoryID] = make([]models.Money, len(months))
+		}
+		byCategory[categoryID][i] += amount
+	}
+
+	var expenses []models.Expense
+	if err := db.Where("company_id = ? AND expense_date >= ? AND expense_date <= ?", companyID, months[0].StartDate, through).
+		Find(&expenses).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, expense := range expenses {
+		addCategory(expense.CategoryID, expense.ExpenseDate, inCAD(expense.Amount, expense.ExchangeRate))
+	}
+
+	var billItems []struct {
+		CategoryID uint
+		BillDate   time.Time
+		Amount     models.Money
+	}
+	if err := db.Table("bill_items").
+		Select("bill_items.category_id, bills.bill_date, bill_items.amount").
+		Joins("JOIN bills ON bills.id = bill_items.bill_id").
+		Where("bills.company_id = ? AND bills.status <> ? AND bills.deleted_at IS NULL", companyID, "void").
+		Where("bills.bill_date >= ? AND bills.bill_date <= ?", months[0].StartDate, through).
+		Scan(&billItems).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, item := range billItems {
+		addCategory(item.CategoryID, item.BillDate, item.Amount)
+	}
+
+	// Revenue comes from the ledger, so it includes invoices and income entries alike
+	for i, month := range months {
+		if month.StartDate.After(through) {
+			break
+		}
+		end := month.EndOfDay()
+		if end.After(through) {
+			end = through
+		}
+		activity, err := ledgerActivity(db, companyID, &month.StartDate, end)
+		if err != nil {
+			return nil, nil, err
+		}
+		for code, account := range activity {
+			if byAccount[code] == nil {
+				byAccount[code] = make([]models.Money, len(months))
+			}
+			byAccount[code][i] = account.Credit - account.Debit
+		}
+	}
+
+	return byCategory, byAccount, nil
+}
+
+// buildBudgetVsActualLine compares monthly budget and actual amounts. Year to date covers
+// the months that have started by the as-of date.
+func buildBudgetVsActualLine(line BudgetVsActualLine, months []FiscalPeriod, budget, actual []models.Money, asOfDate time.Time) BudgetVsActualLine {
+	var ytdBudget, ytdActual models.Money
+	line.Months = make([]BudgetVsActualMonth, len(months))
+	for i, month := range months {
+		var budgetAmount, actualAmount models.Money
+		if budget != nil {
+			budgetAmount = budget[i]
+		}
+		if actual != nil {
+			actualAmount = actual[i]
+		}
+		line.AnnualBudget += budgetAmount
+		if !month.StartDate.After(asOfDate) {
+			ytdBudget += budgetAmount
+			ytdActual += actualAmount
+		}
+		line.Months[i] = BudgetVsActualMonth{
+			Month:                 i + 1,
+			StartDate:             month.StartDate,
+			EndDate:               month.EndDate,
+			BudgetVsActualAmounts: newBudgetVsActualAmounts(budgetAmount, actualAmount),
+		}
+	}
+	line.YearToDate = newBudgetVsActualAmounts(ytdBudget, ytdActual)
+	return line
+}
+
+// GetBudgetVsActual compares a budget with actual results for each month of its fiscal
+// year and year to date as of a date (default today). Actual expenses are expenses and
+// bill lines by category; actual revenue is the net credits to each revenue account. A
+// positive variance is spending over budget on expense lines and revenue ahead of budget
+// on revenue lines. Use format=pdf for a PDF report.
+func GetBudgetVsActual(c *gin.Context) {
+	budgetID := c.Param("id")
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Use json or pdf"})
+		return
+	}
+
+	asOfDate := currentDate()
+	if value := c.Query("as_of_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as of date format. Use YYYY-MM-DD"})
+			return
+		}
+		asOfDate = parsed
+	}
+	// Include everything dated on the as-of date
+	asOfDate = time.Date(asOfDate.Year(), asOfDate.Month(), asOfDate.Day(), 23, 59, 59, 0, time.UTC)
+
+	var budget models.Budget
+	if err := database.DB.Preload("Company").Preload("Lines.Category").Preload("Lines.Months").First(&budget, budgetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	period := resolveFiscalPeriod(budget.Company, budget.FiscalYear)
+	months := fiscalMonths(budget.Company, budget.FiscalYear)
+	through := asOfDate
+	if through.After(period.EndOfDay()) {
+		through = period.EndOfDay()
+	}
+
+	byCategory, byAccount, err := budgetActuals(database.DB, budget.CompanyID, months, through)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate actual amounts"})
+		return
+	}
+
+	var accounts []models.Account
+	if err := database.DB.Where("company_id = ? AND type = ?", budget.CompanyID, "revenue").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+	accountNames := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		accountNames[account.Code] = account.Name
+	}
+
+	report := BudgetVsActualReport{
+		BudgetID:    budget.ID,
+		BudgetName:  budget.Name,
+		CompanyName: budget.Company.Name,
+		FiscalYear:  budget.FiscalYear,
+		StartDate:   period.StartDate,
+		EndDate:     period.EndDate,
+		AsOfDate:    time.Date(asOfDate.Year(), asOfDate.Month(), asOfDate.Day(), 0, 0, 0, 0, time.UTC),
+		Lines:       []BudgetVsActualLine{},
+	}
+
+	// Revenue lines come first, then expense lines
+	totalBudget := map[string][]models.Money{"revenue": make([]models.Money, len(months)), "expense": make([]models.Money, len(months))}
+	totalActual := map[string][]models.Money{"revenue": make([]models.Money, len(months)), "expense": make([]models.Money, len(months))}
+	for _, lineType := range []string{"revenue", "expense"} {
+		for _, budgetLine := range budget.Lines {
+			if budgetLine.LineType != lineType {
+				continue
+			}
+
+			budgetAmounts := make([]models.Money, len(months))
+			for _, month := range budgetLine.Months {
+				if month.Month >= 1 && month.Month <= len(months) {
+					budgetAmounts[month.Month-1] = month.Amount
+				}
+			}
+
+			line := BudgetVsActualLine{
+				LineType:    budgetLine.LineType,
+				CategoryID:  budgetLine.CategoryID,
+				AccountCode: budgetLine.AccountCode,
+			}
+			var actualAmounts []models.Money
+			if budgetLine.CategoryID != nil {
+				if budgetLine.Category != nil {
+					line.Name = budgetLine.Category.Name
+				}
+				actualAmounts = byCategory[*budgetLine.CategoryID]
+			} else if budgetLine.AccountCode != nil {
+				line.Name = accountNames[*budgetLine.AccountCode]
+				if line.Name == "" {
+					line.Name = *budgetLine.AccountCode
+				}
+				actualAmounts = byAccount[*budgetLine.AccountCode]
+			}
+
+			for i := range months {
+				totalBudget[lineType][i] += budgetAmounts[i]
+				if actualAmounts != nil {
+					totalActual[lineType][i] += actualAmounts[i]
+				}
+			}
+			report.Lines = append(report.Lines, buildBudgetVsActualLine(line, months, budgetAmounts, actualAmounts, asOfDate))
+		}
+	}
+
+	netBudget := make([]models.Money, len(months))
+	netActual := make([]models.Money, len(months))
+	for i := range months {
+		netBudget[i] = totalBudget["revenue"][i] - totalBudget["expense"][i]
+		netActual[i] = totalActual["revenue"][i] - totalActual["expense"][i]
+	}
+	report.Totals = []BudgetVsActualLine{
+		buildBudgetVsActualLine(BudgetVsActualLine{LineType: "revenue", Name: "Total Revenue"}, months, totalBudget["revenue"], totalActual["revenue"], asOfDate),
+		buildBudgetVsActualLine(BudgetVsActualLine{LineType: "expense", Name: "Total Expenses"}, months, totalBudget["expense"], totalActual["expense"], asOfDate),
+		buildBudgetVsActualLine(BudgetVsActualLine{LineType: "net", Name: "Net Income"}, months, netBudget, netActual, asOfDate),
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	pdfBytes, err := generateBudgetVsActualPDF(&report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set headers for PDF download
+	filename := fmt.Sprintf("Budget_vs_Actual_%d.pdf", budget.FiscalYear)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", strconv.Itoa(len(pdfBytes)))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// formatVariancePercent formats a variance percent for the PDF report
+func formatVariancePercent(percent *float64) string {
+	if percent == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", *percent)
+}
+
+// generateBudgetVsActualPDF creates a landscape PDF with a year-to-date summary of every
+// line followed by the monthly comparison of each line
+func generateBudgetVsActualPDF(report *BudgetVsActualReport) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(10, 15, 10)
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "BUDGET VS ACTUAL")
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, report.CompanyName)
+	pdf.Ln(8)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("%s - Fiscal Year %d (%s to %s)", report.BudgetName, report.FiscalYear,
+		report.StartDate.Format("January 2, 2006"), report.EndDate.Format("January 2, 2006")))
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Year to date as of %s", report.AsOfDate.Format("January 2, 2006")))
+	pdf.Ln(10)
+
+	lines := append(append([]BudgetVsActualLine{}, report.Lines...), report.Totals...)
+
+	// Year-to-date summary
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, "YEAR TO DATE")
+	pdf.Ln(8)
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(87, 7, "Line", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(38, 7, "Budget", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(38, 7, "Actual", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(38, 7, "Variance", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(38, 7, "Variance %", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(38, 7, "Annual Budget", "1", 1, "C", false, 0, "")
+
+	for i, line := range lines {
+		style := ""
+		if i >= len(report.Lines) {
+			style = "B"
+		}
+		pdf.SetFont("Arial", style, 9)
+		pdf.CellFormat(87, 6, fmt.Sprintf("%s (%s)", line.Name, line.LineType), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(38, 6, fmt.Sprintf("$%s", line.YearToDate.Budget), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(38, 6, fmt.Sprintf("$%s", line.YearToDate.Actual), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(38, 6, fmt.Sprintf("$%s", line.YearToDate.Variance), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(38, 6, formatVariancePercent(line.YearToDate.VariancePercent), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(38, 6, fmt.Sprintf("$%s", line.AnnualBudget), "1", 1, "R", false, 0, "")
+	}
+
+	// Monthly comparison of each line
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, "BY MONTH")
+	pdf.Ln(10)
+
+	for _, line := range lines {
+		if pdf.GetY() > 160 {
+			pdf.AddPage()
+		}
+
+		pdf.SetFont("Arial", "B", 9)
+		pdf.CellFormat(0, 6, fmt.Sprintf("%s (%s)", line.Name, line.LineType), "", 1, "L", false, 0, "")
+
+		pdf.SetFont("Arial", "B", 7)
+		pdf.CellFormat(25, 5, "", "1", 0, "L", false, 0, "")
+		for _, month := range line.Months {
+			pdf.CellFormat(19, 5, month.StartDate.Format("Jan 2006"), "1", 0, "C", false, 0, "")
+		}
+		pdf.CellFormat(24, 5, "YTD", "1", 1, "C", false, 0, "")
+
+		rows := []struct {
+			label string
+			value func(BudgetVsActualAmounts) string
+		}{
+			{"Budget", func(a BudgetVsActualAmounts) string { return a.Budget.String() }},
+			{"Actual", func(a BudgetVsActualAmounts) string { return a.Actual.String() }},
+			{"Variance", func(a BudgetVsActualAmounts) string { return a.Variance.String() }},
+			{"Variance %", func(a BudgetVsActualAmounts) string { return formatVariancePercent(a.VariancePercent) }},
+		}
+		for _, row := range rows {
+			pdf.SetFont("Arial", "B", 7)
+			pdf.CellFormat(25, 5, row.label, "1", 0, "L", false, 0, "")
+			pdf.SetFont("Arial", "", 7)
+			for _, month := range line.Months {
+				pdf.CellFormat(19, 5, row.value(month.BudgetVsActualAmounts), "1", 0, "R", false, 0, "")
+			}
+			pdf.CellFormat(24, 5, row.value(line.YearToDate), "1", 1, "R", false, 0, "")
+		}
+		pdf.Ln(4)
+	}
+
+	// Output to bytes buffer
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -40,13 +40,6 @@ func CreateCapitalAsset(c *gin.Context) {
 		return
 	}
 
-	// Verify category exists
-	var category models.ExpenseCategory
-	if err := database.DB.First(&category, req.CategoryID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
-		return
-	}
-
 	// Verify company exists
 	var company models.Company
 	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
@@ -54,6 +47,35 @@ func CreateCapitalAsset(c *gin.Context) {
 		return
 	}
 
+	// Verify vendor belongs to the company and default to its category
+	if req.VendorID != nil {
+		vendor, ok := findCompanyVendor(c, *req.VendorID, req.CompanyID)
+		if !ok {
+			return
+		}
+		if req.CategoryID == 0 && vendor.DefaultCategoryID != nil {
+			req.CategoryID = *vendor.DefaultCategoryID
+		}
+	}
+	if req.CategoryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required unless the vendor has a default category"})
+		return
+	}
+
+	// Verify project belongs to the company
+	if req.ProjectID != nil && *req.ProjectID != 0 {
+		if _, ok := findCompanyProject(c, *req.ProjectID, req.CompanyID); !ok {
+			return
+		}
+	}
+
+	// Verify category exists
+	var category models.ExpenseCategory
+	if err := database.DB.First(&category, req.CategoryID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+		return
+	}
+
 	// Parse purchase date
 	purchaseDate, err := time.Parse("2006-01-02", req.PurchaseDate)
 	if err != nil {
@@ -87,16 +109,44 @@ func CreateCapitalAsset(c *gin.Context) {
 		BookValue:               totalCost,
 		PaidBy:                  req.PaidBy,
 		ReceiptAttached:         req.ReceiptAttached,
+		VendorID:                req.VendorID,
+		ProjectID:               zeroToNil(req.ProjectID),
 		CompanyID:               req.CompanyID,
 	}
 
-	if err := database.DB.Create(&asset).Error; err != nil {
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, asset.CompanyID, asset.PurchaseDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Create(&asset).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create capital asset"})
 		return
 	}
 
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceCapitalAsset, asset.ID, capitalAssetJournalEntries(&asset)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post capital asset to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load asset with related data
-	if err := database.DB.Preload("Category").Preload("Company").First(&asset, asset.ID).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").First(&asset, asset.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load capital asset data"})
 		return
 	}
@@ -109,7 +159,7 @@ func GetCapitalAsset(c *gin.Context) {
 	assetID := c.Param("id")
 
 	var asset models.CapitalAsset
-	if err := database.DB.Preload("Category").Preload("Company").Preload("DepreciationEntries").First(&asset, assetID).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").Preload("DepreciationEntries").First(&asset, assetID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Capital asset not found"})
 		return
 	}
@@ -134,6 +184,11 @@ func UpdateCapitalAsset(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, asset.CompanyID, asset.PurchaseDate, optionalDate(asset.DisposalDate)) {
+		return
+	}
+
 	// Update fields if provided
 	updates := make(map[string]interface{})
 	if req.Description != nil {
@@ -197,14 +252,66 @@ func UpdateCapitalAsset(c *gin.Context) {
 	if req.ReceiptAttached != nil {
 		updates["receipt_attached"] = *req.ReceiptAttached
 	}
+	if req.VendorID != nil {
+		// Verify vendor belongs to the company
+		if *req.VendorID != 0 {
+			if _, ok := findCompanyVendor(c, *req.VendorID, asset.CompanyID); !ok {
+				return
+			}
+		}
+		updates["vendor_id"] = zeroToNil(req.VendorID)
+	}
+	if req.ProjectID != nil {
+		// Verify project belongs to the company
+		if *req.ProjectID != 0 {
+			if _, ok := findCompanyProject(c, *req.ProjectID, asset.CompanyID); !ok {
+				return
+			}
+		}
+		updates["project_id"] = zeroToNil(req.ProjectID)
+	}
 
-	if err := database.DB.Model(&asset).Updates(updates).Error; err != nil {
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Model(&asset).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update capital asset"})
 		return
 	}
 
+	// Reload the updated record
+	if err := tx.First(&asset, asset.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload capital asset"})
+		return
+	}
+
+	// Reject changes that move the record into a closed accounting period
+	if rejectClosedPeriod(c, asset.CompanyID, asset.PurchaseDate, optionalDate(asset.DisposalDate)) {
+		tx.Rollback()
+		return
+	}
+
+	// Repost to the general ledger
+	if err := syncSourceJournal(tx, sourceCapitalAsset, asset.ID, capitalAssetJournalEntries(&asset)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post capital asset to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load updated asset with related data
-	if err := database.DB.Preload("Category").Preload("Company").Preload("DepreciationEntries").First(&asset, asset.ID).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").Preload("DepreciationEntries").First(&asset, asset.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated capital asset data"})
 		return
 	}
@@ -223,6 +330,11 @@ func DeleteCapitalAsset(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, asset.CompanyID, asset.PurchaseDate, optionalDate(asset.DisposalDate)) {
+		return
+	}
+
 	// Check if asset has depreciation entries
 	var depCount int64
 	if err := database.DB.Model(&models.DepreciationEntry{}).Where("capital_asset_id = ?", assetID).Count(&depCount).Error; err != nil {
@@ -235,12 +347,33 @@ func DeleteCapitalAsset(c *gin.Context) {
 		return
 	}
 
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
 	// Soft delete asset
-	if err := database.DB.Delete(&asset).Error; err != nil {
+	if err := tx.Delete(&asset).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete capital asset"})
 		return
 	}
 
+	// Reverse the capital asset's ledger postings
+	if err := reverseSourceJournal(tx, sourceCapitalAsset, asset.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse capital asset in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Capital asset deleted successfully"})
 }
 
@@ -257,9 +390,11 @@ func ListCapitalAssets(c *gin.Context) {
 	search := c.Query("search")
 	companyID := c.Query("company_id")
 	categoryID := c.Query("category_id")
+	vendorID := c.Query("vendor_id")
+	projectID := c.Query("project_id")
 	ccaClass := c.Query("cca_class")
 
-	query := database.DB.Preload("Category").Preload("Company").Model(&models.CapitalAsset{})
+	query := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").Model(&models.CapitalAsset{})
 
 	// Apply filters
 	if search != "" {
@@ -271,6 +406,12 @@ func ListCapitalAssets(c *gin.Context) {
 	if categoryID != "" {
 		query = query.Where("category_id = ?", categoryID)
 	}
+	if vendorID != "" {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
 	if ccaClass != "" {
 		query = query.Where("cca_class = ?", ccaClass)
 	}
@@ -334,17 +475,21 @@ func CalculateDepreciation(c *gin.Context) {
 
 	// Find asset
 	var asset models.CapitalAsset
-	if err := database.DB.First(&asset, assetID).Error; err != nil {
+	if err := database.DB.Preload("Company").First(&asset, assetID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Capital asset not found"})
 		return
 	}
 
 	// Calculate depreciation
-	depreciation := calculateAssetDepreciation(asset, fiscalYear)
+	period := resolveFiscalPeriod(asset.Company, fiscalYear)
+	depreciation := calculateAssetDepreciation(asset, period)
 
 	c.JSON(http.StatusOK, gin.H{
 		"capital_asset_id":     asset.ID,
 		"fiscal_year":          fiscalYear,
+		"period_start":         period.StartDate.Format("2006-01-02"),
+		"period_end":           period.EndDate.Format("2006-01-02"),
+		"is_short_year":        period.IsShortYear,
 		"depreciation_amount":  depreciation.Amount,
 		"is_half_year_rule":    depreciation.IsHalfYearRule,
 		"remaining_book_value": depreciation.RemainingBookValue,
@@ -367,7 +512,7 @@ func CreateDepreciationEntry(c *gin.Context) {
 
 	// Find asset
 	var asset models.CapitalAsset
-	if err := database.DB.First(&asset, assetID).Error; err != nil {
+	if err := database.DB.Preload("Company").First(&asset, assetID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Capital asset not found"})
 		return
 	}
@@ -387,7 +532,7 @@ func CreateDepreciationEntry(c *gin.Context) {
 	}
 
 	// Calculate depreciation
-	depreciation := calculateAssetDepreciation(asset, req.FiscalYear)
+	depreciation := calculateAssetDepreciation(asset, resolveFiscalPeriod(asset.Company, req.FiscalYear))
 
 	// Create depreciation entry
 	entry := models.DepreciationEntry{
@@ -399,7 +544,20 @@ func CreateDepreciationEntry(c *gin.Context) {
 		CompanyID:          asset.CompanyID,
 	}
 
-	if err := database.DB.Create(&entry).Error; err != nil {
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, entry.CompanyID, entry.EntryDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create depreciation entry"})
 		return
 	}
@@ -408,14 +566,28 @@ func CreateDepreciationEntry(c *gin.Context) {
 	newAccumulatedDepreciation := asset.AccumulatedDepreciation + depreciation.Amount
 	newBookValue := asset.TotalCost - newAccumulatedDepreciation
 
-	if err := database.DB.Model(&asset).Updates(map[string]interface{}{
+	if err := tx.Model(&asset).Updates(map[string]interface{}{
 		"accumulated_depreciation": newAccumulatedDepreciation,
 		"book_value":               newBookValue,
 	}).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update asset depreciation"})
 		return
 	}
 
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceDepreciationEntry, entry.ID, depreciationJournalEntries(&entry)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post depreciation entry to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load entry with related data
 	if err := database.DB.Preload("CapitalAsset").Preload("Company").First(&entry, entry.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load depreciation entry data"})
@@ -427,28 +599,34 @@ func CreateDepreciationEntry(c *gin.Context) {
 
 // DepreciationCalculation represents the result of a depreciation calculation
 type DepreciationCalculation struct {
-	Amount             float64
+	Amount             models.Money
 	IsHalfYearRule     bool
-	RemainingBookValue float64
+	RemainingBookValue models.Money
 }
 
-// calculateAssetDepreciation calculates depreciation for a capital asset
-func calculateAssetDepreciation(asset models.CapitalAsset, fiscalYear int) DepreciationCalculation {
+// calculateAssetDepreciation calculates depreciation for a capital asset over a fiscal period.
+// The rate is applied once and rounded half-up to the cent.
+func calculateAssetDepreciation(asset models.CapitalAsset, period FiscalPeriod) DepreciationCalculation {
 	// Check if asset was purchased in the current fiscal year
-	purchaseYear := asset.PurchaseDate.Year()
-	isHalfYearRule := purchaseYear == fiscalYear
+	isHalfYearRule := period.Contains(asset.PurchaseDate)
 
-	// Calculate depreciation amount
-	var depreciationAmount float64
+	// Determine the depreciation base and rate
+	base := asset.BookValue
+	rate := asset.CCARate
 
 	if isHalfYearRule {
 		// Half-year rule: only 50% of the normal rate in the first year
-		depreciationAmount = asset.DepreciableAmount * asset.CCARate * 0.5
-	} else {
-		// Normal depreciation: rate * remaining book value
-		depreciationAmount = asset.BookValue * asset.CCARate
+		base = asset.DepreciableAmount
+		rate = rate * 0.5
 	}
 
+	// CCA is prorated by days in a short fiscal year
+	if period.IsShortYear {
+		rate = rate * float64(period.Days()) / 365
+	}
+
+	depreciationAmount := base.MulRate(rate)
+
 	// Ensure we don't depreciate more than the remaining book value
 	if depreciationAmount > asset.BookValue {
 		depreciationAmount = asset.BookValue
@@ -0,0 +1,599 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// categorizer matches bank and card activity against a company's categorization rules
+type categorizer struct {
+	rules    []models.CategorizationRule
+	patterns map[uint]*regexp.Regexp
+}
+
+// newCategorizer loads the active categorization rules of a company in priority order
+func newCategorizer(db *gorm.DB, companyID uint) (*categorizer, error) {
+	var rules []models.CategorizationRule
+	if err := db.Where("company_id = ? AND is_active = ?", companyID, true).
+		Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return compileCategorizer(rules), nil
+}
+
+// compileCategorizer prepares rules for matching. Regex patterns are checked when rules
+// are saved; a pattern that no longer compiles never matches.
+func compileCategorizer(rules []models.CategorizationRule) *categorizer {
+	c := &categorizer{rules: rules, patterns: make(map[uint]*regexp.Regexp)}
+	for _, rule := range rules {
+		if rule.MatchType == "regex" {
+			if pattern, err := regexp.Compile(rule.Pattern); err == nil {
+				c.patterns[rule.ID] = pattern
+			}
+		}
+	}
+	return c
+}
+
+// match returns the first rule that matches a description and amount (positive for money
+// in) from a bank account or CSV import profile, or nil
+func (c *categorizer) match(description string, amount models.Money, bankAccountID, csvImportProfileID *uint) *models.CategorizationRule {
+	for i := range c.rules {
+		rule := &c.rules[i]
+
+		if rule.BankAccountID != nil && (bankAccountID == nil || *rule.BankAccountID != *bankAccountID) {
+			continue
+		}
+		if rule.CSVImportProfileID != nil && (csvImportProfileID == nil || *rule.CSVImportProfileID != *csvImportProfileID) {
+			continue
+		}
+		if rule.Direction != nil && (*rule.Direction == "out") != (amount < 0) {
+			continue
+		}
+		if rule.MinAmount != nil && amount.Abs() < *rule.MinAmount {
+			continue
+		}
+		if rule.MaxAmount != nil && amount.Abs() > *rule.MaxAmount {
+			continue
+		}
+
+		if rule.MatchType == "regex" {
+			pattern, ok := c.patterns[rule.ID]
+			if !ok || !pattern.MatchString(description) {
+				continue
+			}
+		} else if !strings.Contains(strings.ToUpper(description), strings.ToUpper(rule.Pattern)) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// forBankTransaction returns the rule for a statement line: the rule it matched when it
+// was imported while that rule is active, or else the first rule that matches it now
+func (c *categorizer) forBankTransaction(transaction *models.BankTransaction) *models.CategorizationRule {
+	if transaction.CategorizationRuleID != nil {
+		for i := range c.rules {
+			if c.rules[i].ID == *transaction.CategorizationRuleID {
+				return &c.rules[i]
+			}
+		}
+	}
+	return c.match(transaction.Description, transaction.Amount, &transaction.BankAccountID, nil)
+}
+
+// cleanDescription returns the description a rule gives to matched activity, expanding
+// the groups of regex rules
+func (c *categorizer) cleanDescription(rule *models.CategorizationRule, description string) string {
+	if rule.Description == nil || *rule.Description == "" {
+		return description
+	}
+	if pattern, ok := c.patterns[rule.ID]; ok && rule.MatchType == "regex" {
+		if match := pattern.FindStringSubmatchIndex(description); match != nil {
+			return strings.TrimSpace(string(pattern.ExpandString(nil, *rule.Description, description, match)))
+		}
+	}
+	return *rule.Description
+}
+
+// ruleHSTAmount returns the HST included in a gross amount under a rule's HST treatment,
+// and false when the rule leaves HST unspecified
+func ruleHSTAmount(rule *models.CategorizationRule, gross models.Money) (models.Money, bool) {
+	if rule == nil || rule.HSTRate == nil {
+		return 0, false
+	}
+	return gross.Abs().MulRate(*rule.HSTRate / (1 + *rule.HSTRate)), true
+}
+
+// applyCategorizationRule fills the parts of a request to record a statement line that
+// were left out with the actions of a rule. It reports whether the rule set the HST amount.
+func applyCategorizationRule(c *categorizer, rule *models.CategorizationRule, req *models.CreateRecordFromBankTransactionRequest, transaction *models.BankTransaction) bool {
+	if rule == nil {
+		return false
+	}
+
+	if req.Description == nil {
+		if description := c.cleanDescription(rule, transaction.Description); description != transaction.Description {
+			req.Description = &description
+		}
+	}
+	if req.RecordType == "expense" && req.CategoryID == nil {
+		req.CategoryID = rule.CategoryID
+	}
+	if req.RecordType == "income_entry" && req.IncomeType == nil {
+		req.IncomeType = rule.IncomeType
+	}
+	if req.HSTAmount == 0 {
+		if hstAmount, ok := ruleHSTAmount(rule, transaction.Amount); ok {
+			req.HSTAmount = hstAmount
+			return true
+		}
+	}
+	return false
+}
+
+// categorizationRuleProblem checks a rule before it is saved
+func categorizationRuleProblem(db *gorm.DB, rule *models.CategorizationRule) string {
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return "Pattern is required"
+	}
+	if rule.MatchType == "regex" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return "Invalid regex pattern: " + err.Error()
+		}
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return "min_amount must not exceed max_amount"
+	}
+	if rule.CategoryID != nil {
+		var category models.ExpenseCategory
+		if err := db.First(&category, *rule.CategoryID).Error; err != nil {
+			return "Expense category not found"
+		}
+	}
+	if rule.BankAccountID != nil {
+		var bankAccount models.BankAccount
+		if err := db.Where("company_id = ?", rule.CompanyID).First(&bankAccount, *rule.BankAccountID).Error; err != nil {
+			return "Bank account not found"
+		}
+	}
+	if rule.CSVImportProfileID != nil {
+		var profile models.CSVImportProfile
+		if err := db.Where("company_id = ?", rule.CompanyID).First(&profile, *rule.CSVImportProfileID).Error; err != nil {
+			return "CSV import profile not found"
+		}
+	}
+	return ""
+}
+
+// newCategorizationRule builds a rule from a create request
+func newCategorizationRule(req *models.CreateCategorizationRuleRequest) models.CategorizationRule {
+	rule := models.CategorizationRule{
+		Name:               req.Name,
+		Priority:           100,
+		IsActive:           true,
+		MatchType:          req.MatchType,
+		Pattern:            req.Pattern,
+		Direction:          req.Direction,
+		MinAmount:          req.MinAmount,
+		MaxAmount:          req.MaxAmount,
+		BankAccountID:      req.BankAccountID,
+		CSVImportProfileID: req.CSVImportProfileID,
+		CategoryID:         req.CategoryID,
+		PaidBy:             req.PaidBy,
+		HSTRate:            req.HSTRate,
+		Description:        emptyToNil(req.Description),
+		IncomeType:         req.IncomeType,
+		CompanyID:          req.CompanyID,
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if rule.MatchType == "" {
+		rule.MatchType = "contains"
+	}
+	return rule
+}
+
+// merchantPattern guesses the merchant part of a bank description, dropping the store
+// numbers, phone numbers and locations that card processors append, e.g.
+// "ADOBE *CREATIVE CLD 800-833-6687 ON" becomes "ADOBE *CREATIVE CLD"
+func merchantPattern(description string) string {
+	words := strings.Fields(description)
+	var merchant []string
+	for i, word := range words {
+		hasDigit := strings.IndexFunc(word, unicode.IsDigit) >= 0
+		if i > 0 && (hasDigit || len(merchant) == 3) {
+			break
+		}
+		merchant = append(merchant, word)
+	}
+	return strings.Join(merchant, " ")
+}
+
+// learnedHSTRate infers the HST rate of a record from its pre-tax amount and HST
+func learnedHSTRate(amount, hst models.Money) float64 {
+	if hst == 0 || amount == 0 {
+		return 0
+	}
+	return math.Round(hst.Float64()/amount.Float64()*1000) / 1000
+}
+
+// learnCategorizationRule saves a rule that categorizes activity like a record categorized
+// by hand. The bank description the record came from, if any, is matched; when a rule
+// with the same contains pattern exists its actions are replaced instead.
+func learnCategorizationRule(db *gorm.DB, record interface{}, bankDescription string, pattern, name *string) (*models.CategorizationRule, error) {
+	var rule models.CategorizationRule
+	var description string
+	switch source := record.(type) {
+	case *models.Expense:
+		direction := "out"
+		hstRate := learnedHSTRate(source.Amount, source.HSTPaid)
+		rule = models.CategorizationRule{
+			Direction:  &direction,
+			CategoryID: &source.CategoryID,
+			PaidBy:     &source.PaidBy,
+			HSTRate:    &hstRate,
+			CompanyID:  source.CompanyID,
+		}
+		description = source.Description
+	case *models.IncomeEntry:
+		direction := "in"
+		hstRate := learnedHSTRate(source.Amount, source.HSTAmount)
+		rule = models.CategorizationRule{
+			Direction:  &direction,
+			HSTRate:    &hstRate,
+			IncomeType: &source.IncomeType,
+			CompanyID:  source.CompanyID,
+		}
+		description = source.Description
+	default:
+		return nil, errors.New("rules can only be learned from expenses and income entries")
+	}
+
+	// Match the bank's description and clean it up to the record's
+	text := description
+	if bankDescription != "" {
+		text = bankDescription
+	}
+	if description != text {
+		rule.Description = &description
+	}
+	rule.Pattern = merchantPattern(text)
+	if pattern != nil && strings.TrimSpace(*pattern) != "" {
+		rule.Pattern = strings.TrimSpace(*pattern)
+	}
+	if rule.Pattern == "" {
+		return nil, errors.New("no pattern could be learned from an empty description")
+	}
+	rule.Name = rule.Pattern
+	if name != nil && *name != "" {
+		rule.Name = *name
+	}
+
+	var existing models.CategorizationRule
+	err := db.Where("company_id = ? AND match_type = ? AND UPPER(pattern) = UPPER(?)", rule.CompanyID, "contains", rule.Pattern).
+		First(&existing).Error
+	if err == nil {
+		updates := map[string]interface{}{
+			"direction":   rule.Direction,
+			"category_id": rule.CategoryID,
+			"paid_by":     rule.PaidBy,
+			"hst_rate":    rule.HSTRate,
+			"description": rule.Description,
+			"income_type": rule.IncomeType,
+			"is_active":   true,
+		}
+		if err := db.Model(&existing).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		if err := db.First(&existing, existing.ID).Error; err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	rule.Priority = 100
+	rule.IsActive = true
+	rule.MatchType = "contains"
+	rule.Learned = true
+	if err := db.Create(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListCategorizationRules lists the categorization rules of a company in priority order
+func ListCategorizationRules(c *gin.Context) {
+	var rules []models.CategorizationRule
+
+	query := database.DB.Model(&models.CategorizationRule{}).Preload("Category")
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if c.Query("active") == "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Order("priority, id").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateCategorizationRule creates a new categorization rule
+func CreateCategorizationRule(c *gin.Context) {
+	var req models.CreateCategorizationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	rule := newCategorizationRule(&req)
+	if problem := categorizationRuleProblem(database.DB, &rule); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create categorization rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetCategorizationRule retrieves a categorization rule by ID
+func GetCategorizationRule(c *gin.Context) {
+	ruleID := c.Param("id")
+
+	var rule models.CategorizationRule
+	if err := database.DB.Preload("Category").First(&rule, ruleID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categorization rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateCategorizationRule updates a categorization rule
+func UpdateCategorizationRule(c *gin.Context) {
+	ruleID := c.Param("id")
+
+	var req models.UpdateCategorizationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find rule
+	var rule models.CategorizationRule
+	if err := database.DB.First(&rule, ruleID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categorization rule not found"})
+		return
+	}
+
+	// Update fields if provided; empty strings and zero IDs clear optional fields
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Priority != nil {
+		updates["priority"] = *req.Priority
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if req.MatchType != nil {
+		rule.MatchType = *req.MatchType
+		updates["match_type"] = rule.MatchType
+	}
+	if req.Pattern != nil {
+		rule.Pattern = *req.Pattern
+		updates["pattern"] = rule.Pattern
+	}
+	if req.Direction != nil {
+		rule.Direction = emptyToNil(req.Direction)
+		updates["direction"] = rule.Direction
+	}
+	if req.MinAmount != nil {
+		rule.MinAmount = req.MinAmount
+		updates["min_amount"] = rule.MinAmount
+	}
+	if req.MaxAmount != nil {
+		rule.MaxAmount = req.MaxAmount
+		updates["max_amount"] = rule.MaxAmount
+	}
+	if req.BankAccountID != nil {
+		rule.BankAccountID = zeroToNil(req.BankAccountID)
+		updates["bank_account_id"] = rule.BankAccountID
+	}
+	if req.CSVImportProfileID != nil {
+		rule.CSVImportProfileID = zeroToNil(req.CSVImportProfileID)
+		updates["csv_import_profile_id"] = rule.CSVImportProfileID
+	}
+	if req.CategoryID != nil {
+		rule.CategoryID = zeroToNil(req.CategoryID)
+		updates["category_id"] = rule.CategoryID
+	}
+	if req.PaidBy != nil {
+		updates["paid_by"] = emptyToNil(req.PaidBy)
+	}
+	if req.HSTRate != nil {
+		updates["hst_rate"] = *req.HSTRate
+	}
+	if req.Description != nil {
+		updates["description"] = emptyToNil(req.Description)
+	}
+	if req.IncomeType != nil {
+		updates["income_type"] = emptyToNil(req.IncomeType)
+	}
+
+	if problem := categorizationRuleProblem(database.DB, &rule); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	if err := database.DB.Model(&rule).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update categorization rule"})
+		return
+	}
+
+	// Load updated rule
+	if err := database.DB.Preload("Category").First(&rule, rule.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated categorization rule data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// zeroToNil returns nil for a nil or zero ID
+func zeroToNil(id *uint) *uint {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
+
+// DeleteCategorizationRule deletes a categorization rule
+func DeleteCategorizationRule(c *gin.Context) {
+	ruleID := c.Param("id")
+
+	var rule models.CategorizationRule
+	if err := database.DB.First(&rule, ruleID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categorization rule not found"})
+		return
+	}
+
+	// Statement lines keep no reference to a deleted rule
+	if err := database.DB.Model(&models.BankTransaction{}).Where("categorization_rule_id = ?", rule.ID).
+		Update("categorization_rule_id", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bank transactions"})
+		return
+	}
+
+	if err := database.DB.Delete(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete categorization rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Categorization rule deleted successfully"})
+}
+
+// TestCategorizationRules shows how sample activity would be categorized by a company's
+// active rules, or by a draft rule given in the request
+func TestCategorizationRules(c *gin.Context) {
+	var req models.TestCategorizationRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rules *categorizer
+	if req.Rule != nil {
+		req.Rule.CompanyID = req.CompanyID
+		draft := newCategorizationRule(req.Rule)
+		if problem := categorizationRuleProblem(database.DB, &draft); problem != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
+		}
+		rules = compileCategorizer([]models.CategorizationRule{draft})
+	} else {
+		var err error
+		if rules, err = newCategorizer(database.DB, req.CompanyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization rules"})
+			return
+		}
+	}
+
+	rule := rules.match(req.Description, req.Amount, req.BankAccountID, req.CSVImportProfileID)
+	if rule == nil {
+		c.JSON(http.StatusOK, gin.H{"matched": false})
+		return
+	}
+
+	result := gin.H{
+		"description": rules.cleanDescription(rule, req.Description),
+		"category_id": rule.CategoryID,
+		"paid_by":     rule.PaidBy,
+		"income_type": rule.IncomeType,
+	}
+	if hstAmount, ok := ruleHSTAmount(rule, req.Amount); ok {
+		result["hst_amount"] = hstAmount
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matched": true,
+		"rule":    rule,
+		"result":  result,
+	})
+}
+
+// LearnCategorizationRule learns a rule from an expense or income entry that was
+// categorized by hand, matching the description of the statement line it was matched to
+func LearnCategorizationRule(c *gin.Context) {
+	var req models.LearnCategorizationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var record interface{}
+	var sourceType string
+	switch req.SourceType {
+	case "expense":
+		var expense models.Expense
+		if err := database.DB.First(&expense, req.SourceID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
+			return
+		}
+		record, sourceType = &expense, sourceExpense
+	case "income_entry":
+		var incomeEntry models.IncomeEntry
+		if err := database.DB.First(&incomeEntry, req.SourceID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Income entry not found"})
+			return
+		}
+		record, sourceType = &incomeEntry, sourceIncomeEntry
+	}
+
+	// Use the bank's description of the record when it came from a statement line
+	var bankDescription string
+	var transaction models.BankTransaction
+	if err := database.DB.Where("matched_source_type = ? AND matched_source_id = ?", sourceType, req.SourceID).
+		First(&transaction).Error; err == nil {
+		bankDescription = transaction.Description
+	}
+
+	rule, err := learnCategorizationRule(database.DB, record, bankDescription, req.Pattern, req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
@@ -82,6 +82,14 @@ func GetClient(c *gin.Context) {
 		return
 	}
 
+	// Add what the client owes today
+	outstanding, err := clientOutstandingBalance(database.DB, &client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate outstanding balance"})
+		return
+	}
+	client.OutstandingBalance = &outstanding
+
 	c.JSON(http.StatusOK, client)
 }
 
@@ -29,9 +29,11 @@ func CreateCompany(c *gin.Context) {
 	company := models.Company{
 		Name:              req.Name,
 		BusinessNumber:    req.BusinessNumber,
+		Address:           req.Address,
 		HSTNumber:         req.HSTNumber,
 		HSTRegistered:     req.HSTRegistered,
 		FiscalYearEnd:     req.FiscalYearEnd,
+		IncorporationDate: req.IncorporationDate,
 		SmallBusinessRate: req.SmallBusinessRate,
 		HSTRate:           req.HSTRate,
 	}
@@ -41,6 +43,12 @@ func CreateCompany(c *gin.Context) {
 		return
 	}
 
+	// Seed the company's chart of accounts
+	if err := SeedChartOfAccounts(database.DB, company.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chart of accounts"})
+		return
+	}
+
 	c.JSON(http.StatusCreated, company)
 }
 
@@ -88,6 +96,9 @@ func UpdateCompany(c *gin.Context) {
 		}
 		updates["business_number"] = *req.BusinessNumber
 	}
+	if req.Address != nil {
+		updates["address"] = emptyToNil(req.Address)
+	}
 	if req.HSTNumber != nil {
 		updates["hst_number"] = *req.HSTNumber
 	}
@@ -97,6 +108,9 @@ func UpdateCompany(c *gin.Context) {
 	if req.FiscalYearEnd != nil {
 		updates["fiscal_year_end"] = *req.FiscalYearEnd
 	}
+	if req.IncorporationDate != nil {
+		updates["incorporation_date"] = *req.IncorporationDate
+	}
 	if req.SmallBusinessRate != nil {
 		updates["small_business_rate"] = *req.SmallBusinessRate
 	}
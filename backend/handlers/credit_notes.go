@@ -0,0 +1,649 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// creditNoteStatus returns the status of a credit note from the amount applied or refunded
+func creditNoteStatus(creditNote *models.CreditNote) string {
+	switch {
+	case creditNote.AmountApplied == 0:
+		return "open"
+	case creditNote.AmountApplied < creditNote.Total:
+		return "partially_applied"
+	default:
+		return "applied"
+	}
+}
+
+// loadCreditNote loads a credit note with its client, invoice, lines and applications
+func loadCreditNote(db *gorm.DB, creditNote *models.CreditNote, id interface{}) error {
+	return db.Preload("Client").Preload("Invoice").Preload("Items").Preload("Applications.Invoice").First(creditNote, id).Error
+}
+
+// generateCreditNoteNumber generates a credit note number unique within a company: CN-YYYY-XXXX
+func generateCreditNoteNumber(db *gorm.DB, companyID uint) (string, error) {
+	year := time.Now().Year()
+
+	// Count deleted credit notes too, so numbers are never reused
+	var count int64
+	if err := db.Unscoped().Model(&models.CreditNote{}).
+		Where("company_id = ? AND EXTRACT(YEAR FROM created_at) = ?", companyID, year).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("CN-%d-%04d", year, count+1), nil
+}
+
+// buildCreditNoteItems builds the lines of a credit note against an invoice, whose Items
+// must be loaded. Lines crediting an invoice line default to its description, unit and
+// unit price. It responds with 400 and returns false when a line is invalid.
+func buildCreditNoteItems(c *gin.Context, invoice *models.Invoice, requests []models.CreateCreditNoteItemRequest) ([]models.CreditNoteItem, bool) {
+	items := make([]models.CreditNoteItem, 0, len(requests))
+	for _, req := range requests {
+		item := models.CreditNoteItem{
+			InvoiceItemID: req.InvoiceItemID,
+			Description:   req.Description,
+			Quantity:      req.Quantity,
+		}
+		if req.UnitPrice != nil {
+			item.UnitPrice = *req.UnitPrice
+		}
+
+		if req.InvoiceItemID != nil {
+			var invoiceItem *models.InvoiceItem
+			for i := range invoice.Items {
+				if invoice.Items[i].ID == *req.InvoiceItemID {
+					invoiceItem = &invoice.Items[i]
+					break
+				}
+			}
+			if invoiceItem == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invoice item %d is not on invoice %s", *req.InvoiceItemID, invoice.InvoiceNumber)})
+				return nil, false
+			}
+			if item.Description == "" {
+				item.Description = invoiceItem.Description
+			}
+			if req.UnitPrice == nil {
+				item.UnitPrice = invoiceItem.UnitPrice
+			}
+			item.Unit = invoiceItem.Unit
+		} else if item.Description == "" || req.UnitPrice == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "description and unit_price are required on lines that do not credit an invoice line"})
+			return nil, false
+		}
+
+		item.Total = item.UnitPrice.MulRate(item.Quantity)
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// ListCreditNotes lists credit notes, latest first
+func ListCreditNotes(c *gin.Context) {
+	var creditNotes []models.CreditNote
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	// Get filter parameters
+	companyID := c.Query("company_id")
+	clientID := c.Query("client_id")
+	invoiceID := c.Query("invoice_id")
+	status := c.Query("status")
+
+	query := database.DB.Preload("Client").Preload("Invoice").Model(&models.CreditNote{})
+
+	// Apply filters
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+	if invoiceID != "" {
+		query = query.Where("invoice_id = ?", invoiceID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count credit notes"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("issue_date DESC, id DESC").Find(&creditNotes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credit notes"})
+		return
+	}
+
+	response := gin.H{
+		"data":       creditNotes,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateCreditNote issues a credit note against an issued invoice. The credit notes of an
+// invoice may not credit more than its subtotal; HST is credited at the rate the invoice
+// charged. With apply_to_invoice the credit is applied to the invoice's open balance on
+// the issue date.
+func CreateCreditNote(c *gin.Context) {
+	var req models.CreateCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse issue date
+	issueDate, err := time.Parse("2006-01-02", req.IssueDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid issue date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	// Verify the invoice has been issued
+	var invoice models.Invoice
+	if err := database.DB.Preload("Items").First(&invoice, req.InvoiceID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invoice not found"})
+		return
+	}
+	if invoice.Status == "draft" || invoice.Status == "cancelled" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invoice %s is %s; only issued invoices can be credited", invoice.InvoiceNumber, invoice.Status)})
+		return
+	}
+	if issueDate.Before(invoice.IssueDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "issue_date cannot be before the invoice's issue date"})
+		return
+	}
+
+	items, ok := buildCreditNoteItems(c, &invoice, req.Items)
+	if !ok {
+		return
+	}
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, invoice.CompanyID, issueDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Lock the invoice so concurrent credit notes cannot credit more than its subtotal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, invoice.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock invoice"})
+		return
+	}
+
+	// Verify the invoice has enough left to credit
+	var credited struct {
+		Subtotal  models.Money
+		HSTAmount models.Money
+	}
+	if err := tx.Model(&models.CreditNote{}).Select("COALESCE(SUM(subtotal), 0) AS subtotal, COALESCE(SUM(hst_amount), 0) AS hst_amount").
+		Where("invoice_id = ?", invoice.ID).Scan(&credited).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check invoice credit notes"})
+		return
+	}
+	var subtotal models.Money
+	for _, item := range items {
+		subtotal += item.Total
+	}
+	remaining := invoice.Subtotal - credited.Subtotal
+	if subtotal > remaining {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Credit of %s is more than the %s left to credit on invoice %s",
+			subtotal, remaining, invoice.InvoiceNumber)})
+		return
+	}
+
+	// Credit HST at the invoice's rate, crediting what is left when the rest of the
+	// subtotal is credited so rounding never leaves HST behind
+	var hstAmount models.Money
+	switch {
+	case subtotal == remaining:
+		hstAmount = invoice.HSTAmount - credited.HSTAmount
+	case invoice.Subtotal != 0:
+		hstAmount = subtotal.MulRate(float64(invoice.HSTAmount) / float64(invoice.Subtotal))
+	}
+
+	creditNoteNumber, err := generateCreditNoteNumber(tx, invoice.CompanyID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate credit note number"})
+		return
+	}
+
+	creditNote := models.CreditNote{
+		CreditNoteNumber: creditNoteNumber,
+		InvoiceID:        invoice.ID,
+		ClientID:         invoice.ClientID,
+		IssueDate:        issueDate,
+		Reason:           emptyToNil(req.Reason),
+		Subtotal:         subtotal,
+		HSTAmount:        hstAmount,
+		Total:            subtotal + hstAmount,
+		Currency:         invoice.Currency,
+		ExchangeRate:     invoice.ExchangeRate,
+		CompanyID:        invoice.CompanyID,
+		Items:            items,
+	}
+
+	// Apply the credit to the invoice's open balance
+	var invoiceIDs []uint
+	if req.ApplyToInvoice {
+		amount := invoiceBalance(&invoice)
+		if creditNote.Total < amount {
+			amount = creditNote.Total
+		}
+		if amount > 0 {
+			if _, ok := findOpenInvoice(c, tx, invoice.CompanyID, invoice.ClientID, invoice.Currency, invoice.ID, amount); !ok {
+				tx.Rollback()
+				return
+			}
+			creditNote.Applications = []models.CreditNoteApplication{
+				{InvoiceID: &invoice.ID, Amount: amount, AppliedDate: issueDate},
+			}
+			creditNote.AmountApplied = amount
+			invoiceIDs = append(invoiceIDs, invoice.ID)
+		}
+	}
+	creditNote.Status = creditNoteStatus(&creditNote)
+
+	// Create credit note with its lines and application
+	if err := tx.Create(&creditNote).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create credit note"})
+		return
+	}
+
+	// Post to the general ledger
+	if err := loadCreditNote(tx, &creditNote, creditNote.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload credit note"})
+		return
+	}
+	if err := syncSourceJournal(tx, sourceCreditNote, creditNote.ID, creditNoteJournalEntries(&creditNote)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post credit note to the general ledger"})
+		return
+	}
+
+	// Update the invoice's balance
+	if err := refreshInvoiceBalances(tx, invoiceIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load credit note with related data
+	if err := loadCreditNote(database.DB, &creditNote, creditNote.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load credit note data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, creditNote)
+}
+
+// GetCreditNote retrieves a credit note by ID with its lines and applications
+func GetCreditNote(c *gin.Context) {
+	creditNoteID := c.Param("id")
+
+	var creditNote models.CreditNote
+	if err := loadCreditNote(database.DB, &creditNote, creditNoteID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, creditNote)
+}
+
+// useCreditNote applies part of a credit note to an invoice, or refunds it when invoiceID
+// is nil, and responds with the updated credit note
+func useCreditNote(c *gin.Context, creditNote *models.CreditNote, application models.CreditNoteApplication) {
+	if application.AppliedDate.Before(creditNote.IssueDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date cannot be before the credit note's issue date"})
+		return
+	}
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, creditNote.CompanyID, application.AppliedDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Lock the credit note so concurrent uses cannot apply or refund more than is left
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(creditNote, creditNote.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock credit note"})
+		return
+	}
+	if left := creditNote.Total - creditNote.AmountApplied; application.Amount > left {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Amount of %s is more than the %s left on credit note %s",
+			application.Amount, left, creditNote.CreditNoteNumber)})
+		return
+	}
+
+	// Verify the invoice can take the credit
+	var invoiceIDs []uint
+	if application.InvoiceID != nil {
+		if _, ok := findOpenInvoice(c, tx, creditNote.CompanyID, creditNote.ClientID, creditNote.Currency, *application.InvoiceID, application.Amount); !ok {
+			tx.Rollback()
+			return
+		}
+		invoiceIDs = append(invoiceIDs, *application.InvoiceID)
+	}
+
+	// Create the application and update the credit note from all of its applications
+	application.CreditNoteID = creditNote.ID
+	if err := tx.Create(&application).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply credit note"})
+		return
+	}
+	if err := tx.Model(&models.CreditNoteApplication{}).Select("COALESCE(SUM(amount), 0)").
+		Where("credit_note_id = ?", creditNote.ID).Scan(&creditNote.AmountApplied).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update credit note"})
+		return
+	}
+	if err := tx.Model(&models.CreditNote{}).Where("id = ?", creditNote.ID).Updates(map[string]interface{}{
+		"amount_applied": creditNote.AmountApplied,
+		"status":         creditNoteStatus(creditNote),
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update credit note"})
+		return
+	}
+
+	// Repost to the general ledger
+	if err := loadCreditNote(tx, creditNote, creditNote.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload credit note"})
+		return
+	}
+	if err := syncSourceJournal(tx, sourceCreditNote, creditNote.ID, creditNoteJournalEntries(creditNote)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post credit note to the general ledger"})
+		return
+	}
+
+	// Update the invoice's balance
+	if err := refreshInvoiceBalances(tx, invoiceIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load credit note with related data
+	if err := loadCreditNote(database.DB, creditNote, creditNote.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load credit note data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, creditNote)
+}
+
+// ApplyCreditNote applies part of a credit note to an open invoice of its client on a date
+// (applied_date, today by default)
+func ApplyCreditNote(c *gin.Context) {
+	creditNoteID := c.Param("id")
+
+	var req models.ApplyCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var creditNote models.CreditNote
+	if err := database.DB.First(&creditNote, creditNoteID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
+		return
+	}
+
+	// Parse applied date
+	appliedDate := currentDate()
+	if req.AppliedDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.AppliedDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid applied_date format. Use YYYY-MM-DD"})
+			return
+		}
+		appliedDate = parsed
+	}
+
+	useCreditNote(c, &creditNote, models.CreditNoteApplication{
+		InvoiceID:   &req.InvoiceID,
+		Amount:      req.Amount,
+		AppliedDate: appliedDate,
+	})
+}
+
+// RefundCreditNote records a refund of part of a credit note to its client on a date
+// (refund_date, today by default), paid from cash
+func RefundCreditNote(c *gin.Context) {
+	creditNoteID := c.Param("id")
+
+	var req models.RefundCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var creditNote models.CreditNote
+	if err := database.DB.First(&creditNote, creditNoteID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
+		return
+	}
+
+	// Parse refund date
+	refundDate := currentDate()
+	if req.RefundDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.RefundDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund_date format. Use YYYY-MM-DD"})
+			return
+		}
+		refundDate = parsed
+	}
+
+	useCreditNote(c, &creditNote, models.CreditNoteApplication{
+		Amount:      req.Amount,
+		AppliedDate: refundDate,
+		Reference:   emptyToNil(req.Reference),
+	})
+}
+
+// DeleteCreditNote deletes a credit note with its applications and refunds, reopening the
+// invoices it was applied to
+func DeleteCreditNote(c *gin.Context) {
+	creditNoteID := c.Param("id")
+
+	// Find credit note
+	var creditNote models.CreditNote
+	if err := database.DB.Preload("Applications").First(&creditNote, creditNoteID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
+		return
+	}
+
+	// Reject changes to records dated in a closed accounting period
+	dates := []time.Time{creditNote.IssueDate}
+	var invoiceIDs []uint
+	for _, application := range creditNote.Applications {
+		dates = append(dates, application.AppliedDate)
+		if application.InvoiceID != nil {
+			invoiceIDs = append(invoiceIDs, *application.InvoiceID)
+		}
+	}
+	if rejectClosedPeriod(c, creditNote.CompanyID, dates...) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Remove the applications and soft delete the credit note
+	if err := tx.Where("credit_note_id = ?", creditNote.ID).Delete(&models.CreditNoteApplication{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete credit note applications"})
+		return
+	}
+	if err := tx.Delete(&creditNote).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete credit note"})
+		return
+	}
+
+	// Reverse the credit note's ledger postings
+	if err := reverseSourceJournal(tx, sourceCreditNote, creditNote.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse credit note in the general ledger"})
+		return
+	}
+
+	// Update the balance of each invoice
+	if err := refreshInvoiceBalances(tx, invoiceIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoices"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Credit note deleted successfully"})
+}
+
+// GetCreditNotePDF renders a credit note as a PDF with the company's invoice template
+func GetCreditNotePDF(c *gin.Context) {
+	creditNoteID := c.Param("id")
+
+	var creditNote models.CreditNote
+	if err := database.DB.Preload("Company").Preload("Client").Preload("Invoice").Preload("Items").Preload("Applications.Invoice").
+		First(&creditNote, creditNoteID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
+		return
+	}
+
+	template, err := loadInvoiceTemplate(database.DB, creditNote.CompanyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice template"})
+		return
+	}
+
+	pdfBytes, err := generateCreditNotePDF(&creditNote, &template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set headers for PDF download
+	filename := fmt.Sprintf("CreditNote_%s.pdf", creditNote.CreditNoteNumber)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", strconv.Itoa(len(pdfBytes)))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// generateCreditNotePDF creates the PDF of a credit note. The credit note's Company,
+// Client, Invoice, Items and Applications.Invoice must be loaded.
+func generateCreditNotePDF(creditNote *models.CreditNote, template *models.InvoiceTemplate) ([]byte, error) {
+	doc := brandedDocument{
+		Title:     "CREDIT NOTE",
+		Reference: "Credit note " + creditNote.CreditNoteNumber,
+		Details: [][2]string{
+			{"Credit Note Number", creditNote.CreditNoteNumber},
+			{"Issue Date", creditNote.IssueDate.Format("January 2, 2006")},
+			{"Original Invoice", creditNote.Invoice.InvoiceNumber},
+			{"Invoice Date", creditNote.Invoice.IssueDate.Format("January 2, 2006")},
+		},
+		PartyLabel:  "CREDIT TO",
+		Description: creditNote.Reason,
+		Totals: [][2]string{
+			{"Subtotal", fmt.Sprintf("$%s", creditNote.Subtotal)},
+			{hstLabel(creditNote.Subtotal, creditNote.HSTAmount, creditNote.Client.HSTExempt), fmt.Sprintf("$%s", creditNote.HSTAmount)},
+		},
+		TotalLabel: fmt.Sprintf("Total Credit (%s)", creditNote.Currency),
+		Total:      creditNote.Total,
+	}
+	if creditNote.Currency != functionalCurrency {
+		doc.Details = append(doc.Details, [2]string{"Currency", creditNote.Currency})
+	}
+	for _, item := range creditNote.Items {
+		doc.Lines = append(doc.Lines, brandedLine{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+			UnitPrice:   item.UnitPrice,
+			Amount:      item.Total,
+		})
+	}
+
+	if len(creditNote.Applications) > 0 {
+		for _, application := range creditNote.Applications {
+			label := "Refunded " + application.AppliedDate.Format("January 2, 2006")
+			if application.Invoice != nil {
+				label = "Applied to invoice " + application.Invoice.InvoiceNumber
+			}
+			doc.Balance = append(doc.Balance, [2]string{label, fmt.Sprintf("$%s", application.Amount)})
+		}
+		doc.Balance = append(doc.Balance, [2]string{"Credit Remaining", fmt.Sprintf("$%s", creditNote.Total-creditNote.AmountApplied)})
+	}
+
+	return renderBrandedPDF(&doc, &creditNote.Company, &creditNote.Client, template)
+}
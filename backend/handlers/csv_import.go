@@ -0,0 +1,802 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CSVImportRow is a statement row parsed with an import profile. Rows with errors are
+// not imported.
+type CSVImportRow struct {
+	Line         int          `json:"line"` // Line number in the file
+	Date         string       `json:"date,omitempty"`
+	Description  string       `json:"description"`
+	Reference    *string      `json:"reference,omitempty"`
+	Amount       models.Money `json:"amount"`                // Positive for money in, negative for money out
+	RecordType   string       `json:"record_type,omitempty"` // expense or income_entry
+	CategoryID   *uint        `json:"category_id,omitempty"` // Expense category
+	PaidBy       string       `json:"paid_by,omitempty"`     // For expenses
+	IncomeType   string       `json:"income_type,omitempty"` // For income entries
+	RuleID       *uint        `json:"categorization_rule_id,omitempty"`
+	HSTAmount    models.Money `json:"hst_amount"`
+	ExchangeRate float64      `json:"exchange_rate,omitempty"`
+	Errors       []string     `json:"errors,omitempty"`
+
+	postedDate time.Time
+}
+
+// csvDateTokens maps the date format tokens of import profiles to Go layout elements,
+// longest first so that MMM is not read as MM
+var csvDateTokens = strings.NewReplacer(
+	"YYYY", "2006",
+	"YY", "06",
+	"MMM", "Jan",
+	"MM", "01",
+	"DD", "02",
+	"M", "1",
+	"D", "2",
+)
+
+// csvDateLayout converts a date format such as MM/DD/YYYY to a Go time layout
+func csvDateLayout(format string) (string, error) {
+	layout := csvDateTokens.Replace(format)
+
+	// A layout that cannot read back a formatted date is missing a year, month or day
+	sample := time.Date(2024, 11, 23, 0, 0, 0, 0, time.UTC)
+	parsed, err := time.Parse(layout, sample.Format(layout))
+	if err != nil || !parsed.Equal(sample) {
+		return "", fmt.Errorf("invalid date format %q. Use tokens YYYY, MM, MMM and DD, e.g. MM/DD/YYYY", format)
+	}
+	return layout, nil
+}
+
+// csvColumnIndex finds a profile column by header name, ignoring case, or by 1-based position
+func csvColumnIndex(header []string, column string) (int, bool) {
+	column = strings.TrimSpace(column)
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, true
+		}
+	}
+	if position, err := strconv.Atoi(column); err == nil && position > 0 {
+		return position - 1, true
+	}
+	return 0, false
+}
+
+// parseCSVAmount parses a statement amount, allowing currency symbols, thousands
+// separators and accounting-style parentheses for negatives
+func parseCSVAmount(value string) (models.Money, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.Trim(value, "()")
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+
+	amount, err := models.ParseMoney(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = -amount.Abs()
+	}
+	return amount, nil
+}
+
+// csvImportProfileProblem checks that a profile describes a readable statement
+func csvImportProfileProblem(profile *models.CSVImportProfile) string {
+	if _, err := csvDateLayout(profile.DateFormat); err != nil {
+		return err.Error()
+	}
+	if len([]rune(profile.Delimiter)) != 1 {
+		return "Delimiter must be a single character"
+	}
+	hasAmount := profile.AmountColumn != nil
+	hasDebitCredit := profile.DebitColumn != nil || profile.CreditColumn != nil
+	if hasAmount == hasDebitCredit {
+		return "Map either amount_column, or debit_column and credit_column"
+	}
+	if profile.DebitColumn != nil && profile.CreditColumn == nil || profile.DebitColumn == nil && profile.CreditColumn != nil {
+		return "debit_column and credit_column must be mapped together"
+	}
+	if !profile.HasHeader {
+		for _, column := range []*string{&profile.DateColumn, &profile.DescriptionColumn, profile.AmountColumn, profile.DebitColumn, profile.CreditColumn, profile.ReferenceColumn} {
+			if column == nil {
+				continue
+			}
+			if position, err := strconv.Atoi(*column); err != nil || position < 1 {
+				return "Columns must be numbered from 1 when the file has no header"
+			}
+		}
+	}
+	return ""
+}
+
+// emptyToNil returns nil for an empty or blank string
+func emptyToNil(value *string) *string {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	return value
+}
+
+// parseCSVStatement reads the rows of a CSV statement with an import profile, categorizes
+// them with the company's rules, falling back to the profile's defaults, and checks that
+// each can be recorded as an expense or income entry. It fails only when the file itself
+// cannot be read; problems with a row are reported in its errors.
+func parseCSVStatement(profile *models.CSVImportProfile, company *models.Company, expenseCategoryID *uint, rules *categorizer, r io.Reader) ([]CSVImportRow, error) {
+	layout, err := csvDateLayout(profile.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = []rune(profile.Delimiter)[0]
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	// Read the records with their line numbers; blank lines are not records
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) <= profile.SkipRows {
+		return nil, fmt.Errorf("no rows found in the file")
+	}
+	records, lines = records[profile.SkipRows:], lines[profile.SkipRows:]
+
+	// Resolve the mapped columns
+	var header []string
+	if profile.HasHeader {
+		header = records[0]
+		records, lines = records[1:], lines[1:]
+	}
+	column := func(name *string) (int, error) {
+		if name == nil {
+			return -1, nil
+		}
+		index, ok := csvColumnIndex(header, *name)
+		if !ok {
+			return 0, fmt.Errorf("column %q not found in the file", *name)
+		}
+		return index, nil
+	}
+	var dateIndex, descriptionIndex, amountIndex, debitIndex, creditIndex, referenceIndex int
+	for _, mapping := range []struct {
+		name  *string
+		index *int
+	}{
+		{&profile.DateColumn, &dateIndex},
+		{&profile.DescriptionColumn, &descriptionIndex},
+		{profile.AmountColumn, &amountIndex},
+		{profile.DebitColumn, &debitIndex},
+		{profile.CreditColumn, &creditIndex},
+		{profile.ReferenceColumn, &referenceIndex},
+	} {
+		if *mapping.index, err = column(mapping.name); err != nil {
+			return nil, err
+		}
+	}
+	field := func(record []string, index int) string {
+		if index < 0 || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	// Cache lookups shared by rows with the same date
+	closedDates := make(map[time.Time]bool)
+	exchangeRates := make(map[time.Time]*float64)
+	categoriesFound := make(map[uint]bool)
+
+	rows := []CSVImportRow{}
+	for i, record := range records {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := CSVImportRow{
+			Line:        lines[i],
+			Description: field(record, descriptionIndex),
+		}
+		if reference := field(record, referenceIndex); reference != "" {
+			row.Reference = &reference
+		}
+		if row.Description == "" {
+			row.Errors = append(row.Errors, "Description is empty")
+		}
+
+		// Parse the date
+		if date, err := time.Parse(layout, field(record, dateIndex)); err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("Invalid date %q, expected %s", field(record, dateIndex), profile.DateFormat))
+		} else {
+			row.postedDate = date
+			row.Date = date.Format("2006-01-02")
+		}
+
+		// Parse the amount as positive for money in
+		if profile.AmountColumn != nil {
+			amount, err := parseCSVAmount(field(record, amountIndex))
+			if err != nil {
+				row.Errors = append(row.Errors, err.Error())
+			} else if profile.DebitsNegative {
+				row.Amount = amount
+			} else {
+				row.Amount = -amount
+			}
+		} else {
+			for _, part := range []struct {
+				index int
+				sign  models.Money
+			}{{creditIndex, 1}, {debitIndex, -1}} {
+				value := field(record, part.index)
+				if value == "" {
+					continue
+				}
+				amount, err := parseCSVAmount(value)
+				if err != nil {
+					row.Errors = append(row.Errors, err.Error())
+					continue
+				}
+				row.Amount += part.sign * amount.Abs()
+			}
+		}
+		if row.Amount == 0 && len(row.Errors) == 0 {
+			row.Errors = append(row.Errors, "Amount is zero")
+		}
+
+		// Categorize the row with the first matching rule, or the profile's defaults
+		rule := rules.match(row.Description, row.Amount, nil, &profile.ID)
+		row.CategoryID, row.PaidBy, row.IncomeType = expenseCategoryID, profile.PaidBy, profile.IncomeType
+		if rule != nil {
+			row.RuleID = &rule.ID
+			row.Description = rules.cleanDescription(rule, row.Description)
+			if rule.CategoryID != nil {
+				row.CategoryID = rule.CategoryID
+			}
+			if rule.PaidBy != nil {
+				row.PaidBy = *rule.PaidBy
+			}
+			if rule.IncomeType != nil {
+				row.IncomeType = *rule.IncomeType
+			}
+		}
+		if hstAmount, ok := ruleHSTAmount(rule, row.Amount); ok {
+			row.HSTAmount = hstAmount
+		} else if profile.IncludesHST {
+			row.HSTAmount = row.Amount.Abs().MulRate(company.HSTRate / (1 + company.HSTRate))
+		}
+
+		if row.Amount < 0 {
+			row.RecordType = "expense"
+			if row.CategoryID == nil {
+				row.Errors = append(row.Errors, "No expense category is set for money out")
+			} else {
+				found, ok := categoriesFound[*row.CategoryID]
+				if !ok {
+					var category models.ExpenseCategory
+					found = database.DB.First(&category, *row.CategoryID).Error == nil
+					categoriesFound[*row.CategoryID] = found
+				}
+				if !found {
+					row.Errors = append(row.Errors, "Expense category not found")
+				}
+			}
+		} else if row.Amount > 0 {
+			row.RecordType = "income_entry"
+			row.CategoryID, row.PaidBy = nil, ""
+		}
+		if row.RecordType == "expense" {
+			row.IncomeType = ""
+		}
+
+		if !row.postedDate.IsZero() {
+			// Reject rows in a closed accounting period
+			closed, ok := closedDates[row.postedDate]
+			if !ok {
+				period, err := closedPeriodContaining(company.ID, row.postedDate)
+				if err != nil {
+					return nil, err
+				}
+				closed = period != nil
+				closedDates[row.postedDate] = closed
+			}
+			if closed {
+				row.Errors = append(row.Errors, "Date is in a closed accounting period")
+			}
+
+			// Look up the exchange rate of foreign currency statements
+			row.ExchangeRate = 1
+			if profile.Currency != functionalCurrency {
+				rate, ok := exchangeRates[row.postedDate]
+				if !ok {
+					if recorded, err := exchangeRateOn(database.DB, profile.Currency, row.postedDate); err == nil {
+						rate = &recorded.Rate
+					}
+					exchangeRates[row.postedDate] = rate
+				}
+				if rate == nil {
+					row.Errors = append(row.Errors, fmt.Sprintf("No %s exchange rate recorded for %s", profile.Currency, row.Date))
+				} else {
+					row.ExchangeRate = *rate
+				}
+			}
+		}
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// recordCSVImportRow creates the expense or income entry for a statement row and posts
+// it to the general ledger. Expenses post to the account of the row's category.
+func recordCSVImportRow(tx *gorm.DB, profile *models.CSVImportProfile, expenseAccount string, row *CSVImportRow) (interface{}, error) {
+	gross := row.Amount.Abs()
+
+	if row.RecordType == "expense" {
+		expense := models.Expense{
+			Description:  row.Description,
+			CategoryID:   *row.CategoryID,
+			Amount:       gross - row.HSTAmount,
+			HSTPaid:      row.HSTAmount,
+			Currency:     profile.Currency,
+			ExchangeRate: row.ExchangeRate,
+			ExpenseDate:  row.postedDate,
+			PaidBy:       row.PaidBy,
+			CompanyID:    profile.CompanyID,
+		}
+		if err := tx.Create(&expense).Error; err != nil {
+			return nil, err
+		}
+		if err := syncSourceJournal(tx, sourceExpense, expense.ID, expenseJournalEntries(&expense, expenseAccount)); err != nil {
+			return nil, err
+		}
+		return &expense, nil
+	}
+
+	incomeEntry := models.IncomeEntry{
+		Description:  row.Description,
+		Amount:       gross - row.HSTAmount,
+		HSTAmount:    row.HSTAmount,
+		Total:        gross,
+		Currency:     profile.Currency,
+		ExchangeRate: row.ExchangeRate,
+		IncomeType:   row.IncomeType,
+		IncomeDate:   row.postedDate,
+		CompanyID:    profile.CompanyID,
+	}
+	if err := tx.Create(&incomeEntry).Error; err != nil {
+		return nil, err
+	}
+	if err := syncSourceJournal(tx, sourceIncomeEntry, incomeEntry.ID, incomeEntryJournalEntries(&incomeEntry)); err != nil {
+		return nil, err
+	}
+	return &incomeEntry, nil
+}
+
+// ListCSVImportProfiles lists the CSV import profiles of a company
+func ListCSVImportProfiles(c *gin.Context) {
+	var profiles []models.CSVImportProfile
+
+	query := database.DB.Model(&models.CSVImportProfile{})
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	if err := query.Order("name").Find(&profiles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch CSV import profiles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+// CreateCSVImportProfile creates a new CSV import profile
+func CreateCSVImportProfile(c *gin.Context) {
+	var req models.CreateCSVImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Verify expense category exists if provided
+	if req.ExpenseCategoryID != nil {
+		var category models.ExpenseCategory
+		if err := database.DB.First(&category, *req.ExpenseCategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+			return
+		}
+	}
+
+	// Check for a profile with the same name
+	var count int64
+	database.DB.Model(&models.CSVImportProfile{}).Where("company_id = ? AND name = ?", req.CompanyID, req.Name).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A CSV import profile with this name already exists"})
+		return
+	}
+
+	profile := models.CSVImportProfile{
+		Name:              req.Name,
+		HasHeader:         req.HasHeader == nil || *req.HasHeader,
+		SkipRows:          req.SkipRows,
+		Delimiter:         req.Delimiter,
+		DateColumn:        req.DateColumn,
+		DateFormat:        req.DateFormat,
+		DescriptionColumn: req.DescriptionColumn,
+		AmountColumn:      emptyToNil(req.AmountColumn),
+		DebitColumn:       emptyToNil(req.DebitColumn),
+		CreditColumn:      emptyToNil(req.CreditColumn),
+		ReferenceColumn:   emptyToNil(req.ReferenceColumn),
+		DebitsNegative:    req.DebitsNegative == nil || *req.DebitsNegative,
+		Currency:          normalizeCurrency(req.Currency),
+		PaidBy:            req.PaidBy,
+		ExpenseCategoryID: req.ExpenseCategoryID,
+		IncomeType:        req.IncomeType,
+		IncludesHST:       req.IncludesHST,
+		CompanyID:         req.CompanyID,
+	}
+	if profile.Delimiter == "" {
+		profile.Delimiter = ","
+	}
+	if profile.PaidBy == "" {
+		profile.PaidBy = "corp"
+	}
+	if profile.IncomeType == "" {
+		profile.IncomeType = "other"
+	}
+
+	if problem := csvImportProfileProblem(&profile); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	if err := database.DB.Create(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create CSV import profile"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile)
+}
+
+// GetCSVImportProfile retrieves a CSV import profile by ID
+func GetCSVImportProfile(c *gin.Context) {
+	profileID := c.Param("id")
+
+	var profile models.CSVImportProfile
+	if err := database.DB.Preload("ExpenseCategory").First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CSV import profile not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateCSVImportProfile updates a CSV import profile
+func UpdateCSVImportProfile(c *gin.Context) {
+	profileID := c.Param("id")
+
+	var req models.UpdateCSVImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find profile
+	var profile models.CSVImportProfile
+	if err := database.DB.First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CSV import profile not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		var count int64
+		database.DB.Model(&models.CSVImportProfile{}).
+			Where("company_id = ? AND name = ? AND id != ?", profile.CompanyID, *req.Name, profile.ID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A CSV import profile with this name already exists"})
+			return
+		}
+		updates["name"] = *req.Name
+		profile.Name = *req.Name
+	}
+	if req.HasHeader != nil {
+		updates["has_header"] = *req.HasHeader
+		profile.HasHeader = *req.HasHeader
+	}
+	if req.SkipRows != nil {
+		updates["skip_rows"] = *req.SkipRows
+		profile.SkipRows = *req.SkipRows
+	}
+	if req.Delimiter != nil {
+		updates["delimiter"] = *req.Delimiter
+		profile.Delimiter = *req.Delimiter
+	}
+	if req.DateColumn != nil {
+		updates["date_column"] = *req.DateColumn
+		profile.DateColumn = *req.DateColumn
+	}
+	if req.DateFormat != nil {
+		updates["date_format"] = *req.DateFormat
+		profile.DateFormat = *req.DateFormat
+	}
+	if req.DescriptionColumn != nil {
+		updates["description_column"] = *req.DescriptionColumn
+		profile.DescriptionColumn = *req.DescriptionColumn
+	}
+	if req.AmountColumn != nil {
+		profile.AmountColumn = emptyToNil(req.AmountColumn)
+		updates["amount_column"] = profile.AmountColumn
+	}
+	if req.DebitColumn != nil {
+		profile.DebitColumn = emptyToNil(req.DebitColumn)
+		updates["debit_column"] = profile.DebitColumn
+	}
+	if req.CreditColumn != nil {
+		profile.CreditColumn = emptyToNil(req.CreditColumn)
+		updates["credit_column"] = profile.CreditColumn
+	}
+	if req.ReferenceColumn != nil {
+		profile.ReferenceColumn = emptyToNil(req.ReferenceColumn)
+		updates["reference_column"] = profile.ReferenceColumn
+	}
+	if req.DebitsNegative != nil {
+		updates["debits_negative"] = *req.DebitsNegative
+		profile.DebitsNegative = *req.DebitsNegative
+	}
+	if req.Currency != nil {
+		updates["currency"] = normalizeCurrency(*req.Currency)
+		profile.Currency = normalizeCurrency(*req.Currency)
+	}
+	if req.PaidBy != nil {
+		updates["paid_by"] = *req.PaidBy
+	}
+	if req.ExpenseCategoryID != nil {
+		var category models.ExpenseCategory
+		if err := database.DB.First(&category, *req.ExpenseCategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+			return
+		}
+		updates["expense_category_id"] = *req.ExpenseCategoryID
+	}
+	if req.IncomeType != nil {
+		updates["income_type"] = *req.IncomeType
+	}
+	if req.IncludesHST != nil {
+		updates["includes_hst"] = *req.IncludesHST
+	}
+
+	if problem := csvImportProfileProblem(&profile); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	if err := database.DB.Model(&profile).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update CSV import profile"})
+		return
+	}
+
+	// Load updated profile
+	if err := database.DB.Preload("ExpenseCategory").First(&profile, profile.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated CSV import profile data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteCSVImportProfile deletes a CSV import profile
+func DeleteCSVImportProfile(c *gin.Context) {
+	profileID := c.Param("id")
+
+	var profile models.CSVImportProfile
+	if err := database.DB.First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CSV import profile not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete CSV import profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "CSV import profile deleted successfully"})
+}
+
+// loadCSVImport reads the profile and uploaded statement of a preview or import request.
+// The expense_category_id form field overrides the profile's category for rows no rule
+// categorizes. It responds with an error and returns false when the request cannot be
+// processed.
+func loadCSVImport(c *gin.Context) (*models.CSVImportProfile, []CSVImportRow, bool) {
+	profileID := c.Param("id")
+
+	var profile models.CSVImportProfile
+	if err := database.DB.Preload("Company").First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CSV import profile not found"})
+		return nil, nil, false
+	}
+
+	expenseCategoryID := profile.ExpenseCategoryID
+	if value := c.PostForm("expense_category_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense_category_id"})
+			return nil, nil, false
+		}
+		categoryID := uint(id)
+		expenseCategoryID = &categoryID
+	}
+
+	// Get the uploaded file
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return nil, nil, false
+	}
+	if file.Size > maxStatementFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds 10MB limit"})
+		return nil, nil, false
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return nil, nil, false
+	}
+	defer src.Close()
+
+	rules, err := newCategorizer(database.DB, profile.CompanyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization rules"})
+		return nil, nil, false
+	}
+
+	rows, err := parseCSVStatement(&profile, &profile.Company, expenseCategoryID, rules, src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	return &profile, rows, true
+}
+
+// csvImportSummary counts the valid and invalid rows of a parsed statement
+func csvImportSummary(rows []CSVImportRow) (valid, invalid int) {
+	for _, row := range rows {
+		if len(row.Errors) == 0 {
+			valid++
+		} else {
+			invalid++
+		}
+	}
+	return valid, invalid
+}
+
+// PreviewCSVImport parses an uploaded CSV statement with a profile without saving
+// anything, returning each row as it would be recorded along with its validation errors
+func PreviewCSVImport(c *gin.Context) {
+	_, rows, ok := loadCSVImport(c)
+	if !ok {
+		return
+	}
+
+	valid, invalid := csvImportSummary(rows)
+	c.JSON(http.StatusOK, gin.H{
+		"rows":    rows,
+		"valid":   valid,
+		"invalid": invalid,
+	})
+}
+
+// ImportCSVStatement records the rows of an uploaded CSV statement as expenses (money
+// out) and income entries (money in) in one transaction. The file is rejected when any
+// row has errors, unless skip_invalid is true.
+func ImportCSVStatement(c *gin.Context) {
+	profile, rows, ok := loadCSVImport(c)
+	if !ok {
+		return
+	}
+
+	valid, invalid := csvImportSummary(rows)
+	if invalid > 0 && c.PostForm("skip_invalid") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   fmt.Sprintf("%d rows have errors. Fix the file or profile, or set skip_invalid to import the valid rows", invalid),
+			"rows":    rows,
+			"valid":   valid,
+			"invalid": invalid,
+		})
+		return
+	}
+	if valid == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid rows to import"})
+		return
+	}
+
+	// Resolve the expense accounts of the categories used
+	expenseAccounts := make(map[uint]string)
+	for _, row := range rows {
+		if row.RecordType != "expense" || len(row.Errors) > 0 {
+			continue
+		}
+		if _, ok := expenseAccounts[*row.CategoryID]; ok {
+			continue
+		}
+		account, err := expenseAccountCode(database.DB, *row.CategoryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve expense account"})
+			return
+		}
+		expenseAccounts[*row.CategoryID] = account
+	}
+
+	records := []interface{}{}
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	for i := range rows {
+		if len(rows[i].Errors) > 0 {
+			continue
+		}
+		var expenseAccount string
+		if rows[i].CategoryID != nil {
+			expenseAccount = expenseAccounts[*rows[i].CategoryID]
+		}
+		record, err := recordCSVImportRow(tx, profile, expenseAccount, &rows[i])
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to record row on line %d", rows[i].Line)})
+			return
+		}
+		records = append(records, record)
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	skipped := []CSVImportRow{}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			skipped = append(skipped, row)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"created": len(records),
+		"records": records,
+		"skipped": skipped,
+	})
+}
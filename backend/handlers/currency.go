@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// functionalCurrency is the currency the books are kept in. Documents in another
+// currency are converted at their exchange rate when posted and reported.
+const functionalCurrency = "CAD"
+
+// exchangeRateLookback is how far back a rate is looked up when none is published on
+// a date, covering weekends and bank holidays
+const exchangeRateLookback = 7 * 24 * time.Hour
+
+// normalizeCurrency returns an upper-case currency code, defaulting to the functional currency
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return functionalCurrency
+	}
+	return code
+}
+
+// inCAD converts an amount in a document's currency to Canadian dollars
+func inCAD(amount models.Money, rate float64) models.Money {
+	if rate == 0 || rate == 1 {
+		return amount
+	}
+	return amount.MulRate(rate)
+}
+
+// exchangeRateOn returns the most recent rate for a currency published on or up to a
+// week before a date
+func exchangeRateOn(db *gorm.DB, currency string, date time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := db.Where("currency = ? AND rate_date <= ? AND rate_date > ?", currency, date, date.Add(-exchangeRateLookback)).
+		Order("rate_date DESC").First(&rate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// resolveExchangeRate returns the exchange rate of a document dated in a currency. The
+// functional currency always has a rate of 1; otherwise the given rate is used, or the
+// recorded rate for the date when none is given. It responds with an error and returns
+// false when no rate can be found.
+func resolveExchangeRate(c *gin.Context, db *gorm.DB, currency string, date time.Time, rate *float64) (float64, bool) {
+	if currency == functionalCurrency {
+		return 1, true
+	}
+	if rate != nil {
+		return *rate, true
+	}
+
+	recorded, err := exchangeRateOn(db, currency, date)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("No %s exchange rate recorded for %s. Provide exchange_rate or import Bank of Canada rates",
+			currency, date.Format("2006-01-02"))})
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up exchange rate"})
+		return 0, false
+	}
+	return recorded.Rate, true
+}
+
+// ListExchangeRates lists recorded exchange rates
+func ListExchangeRates(c *gin.Context) {
+	var rates []models.ExchangeRate
+
+	query := database.DB.Model(&models.ExchangeRate{})
+	if currency := c.Query("currency"); currency != "" {
+		query = query.Where("currency = ?", normalizeCurrency(currency))
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("rate_date >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("rate_date <= ?", endDate)
+	}
+
+	if err := query.Order("rate_date DESC, currency").Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// LookupExchangeRate returns the rate that applies to a currency on a date
+func LookupExchangeRate(c *gin.Context) {
+	currency := normalizeCurrency(c.Query("currency"))
+	date, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	if currency == functionalCurrency {
+		c.JSON(http.StatusOK, models.ExchangeRate{Currency: currency, RateDate: date, Rate: 1})
+		return
+	}
+
+	rate, err := exchangeRateOn(database.DB, currency, date)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// CreateExchangeRate records an exchange rate, replacing any rate for the same currency and date
+func CreateExchangeRate(c *gin.Context) {
+	var req models.CreateExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rateDate, err := time.Parse("2006-01-02", req.RateDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rate date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	rate := models.ExchangeRate{
+		Currency: normalizeCurrency(req.Currency),
+		RateDate: rateDate,
+		Rate:     req.Rate,
+		Source:   "manual",
+	}
+	if rate.Currency == functionalCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exchange rates are recorded for foreign currencies only"})
+		return
+	}
+
+	rates := []models.ExchangeRate{rate}
+	if err := upsertExchangeRates(database.DB, rates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rates[0])
+}
+
+// ImportExchangeRates loads daily exchange rates from a Bank of Canada CSV file, such as
+// the FX_RATES_DAILY group export of the Valet API. Existing rates for the same currency
+// and date are replaced.
+func ImportExchangeRates(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer src.Close()
+
+	rates, err := parseBankOfCanadaRates(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := upsertExchangeRates(database.DB, rates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rates"})
+		return
+	}
+
+	// Summarize the imported currencies and date range
+	currencySet := make(map[string]bool)
+	var startDate, endDate time.Time
+	for _, rate := range rates {
+		currencySet[rate.Currency] = true
+		if startDate.IsZero() || rate.RateDate.Before(startDate) {
+			startDate = rate.RateDate
+		}
+		if rate.RateDate.After(endDate) {
+			endDate = rate.RateDate
+		}
+	}
+	currencies := make([]string, 0, len(currencySet))
+	for currency := range currencySet {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported":   len(rates),
+		"currencies": currencies,
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+	})
+}
+
+// parseBankOfCanadaRates reads the observations of a Bank of Canada CSV export. The file
+// starts with terms and series descriptions; the observations follow a header row whose
+// first column is "date" and whose series columns are named like "FXUSDCAD".
+func parseBankOfCanadaRates(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rates []models.ExchangeRate
+	var columns map[int]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %v", err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+
+		// Find the observations header
+		if strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			columns = make(map[int]string)
+			for i, name := range record[1:] {
+				name = strings.ToUpper(strings.TrimSpace(name))
+				if len(name) == 8 && strings.HasPrefix(name, "FX") && strings.HasSuffix(name, functionalCurrency) {
+					columns[i+1] = name[2:5]
+				}
+			}
+			continue
+		}
+		if columns == nil {
+			continue
+		}
+
+		rateDate, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			continue
+		}
+		for i, currency := range columns {
+			if i >= len(record) || strings.TrimSpace(record[i]) == "" {
+				continue
+			}
+			rate, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil || rate <= 0 {
+				return nil, fmt.Errorf("invalid %s rate %q on %s", currency, record[i], record[0])
+			}
+			rates = append(rates, models.ExchangeRate{
+				Currency: currency,
+				RateDate: rateDate,
+				Rate:     rate,
+				Source:   "bank_of_canada",
+			})
+		}
+	}
+
+	if columns == nil {
+		return nil, fmt.Errorf("no observations found; expected a Bank of Canada CSV with a \"date\" header row")
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no exchange rate series to CAD found in the file")
+	}
+	return rates, nil
+}
+
+// upsertExchangeRates saves exchange rates, replacing rates for the same currency and date
+func upsertExchangeRates(db *gorm.DB, rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "rate_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).CreateInBatches(&rates, 500).Error
+}
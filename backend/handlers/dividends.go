@@ -13,21 +13,21 @@ import (
 
 // CreateDividendRequest represents a request to create a dividend
 type CreateDividendRequest struct {
-	Amount          float64 `json:"amount" binding:"required,min=0"`
-	DeclarationDate string  `json:"declaration_date" binding:"required"`
-	PaymentDate     *string `json:"payment_date,omitempty"`
-	Status          string  `json:"status" binding:"required,oneof=declared paid"`
-	Notes           *string `json:"notes,omitempty"`
-	CompanyID       uint    `json:"company_id" binding:"required"`
+	Amount          models.Money `json:"amount" binding:"required,min=0"`
+	DeclarationDate string       `json:"declaration_date" binding:"required"`
+	PaymentDate     *string      `json:"payment_date,omitempty"`
+	Status          string       `json:"status" binding:"required,oneof=declared paid"`
+	Notes           *string      `json:"notes,omitempty"`
+	CompanyID       uint         `json:"company_id" binding:"required"`
 }
 
 // UpdateDividendRequest represents a request to update a dividend
 type UpdateDividendRequest struct {
-	Amount          *float64 `json:"amount,omitempty" binding:"omitempty,min=0"`
-	DeclarationDate *string  `json:"declaration_date,omitempty"`
-	PaymentDate     *string  `json:"payment_date,omitempty"`
-	Status          *string  `json:"status,omitempty" binding:"omitempty,oneof=declared paid"`
-	Notes           *string  `json:"notes,omitempty"`
+	Amount          *models.Money `json:"amount,omitempty" binding:"omitempty,min=0"`
+	DeclarationDate *string       `json:"declaration_date,omitempty"`
+	PaymentDate     *string       `json:"payment_date,omitempty"`
+	Status          *string       `json:"status,omitempty" binding:"omitempty,oneof=declared paid"`
+	Notes           *string       `json:"notes,omitempty"`
 }
 
 // CreateDividend creates a new dividend
@@ -73,11 +73,37 @@ func CreateDividend(c *gin.Context) {
 		CompanyID:       req.CompanyID,
 	}
 
-	if err := database.DB.Create(&dividend).Error; err != nil {
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, dividend.CompanyID, dividend.DeclarationDate, optionalDate(dividend.PaymentDate)) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Create(&dividend).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create dividend"})
 		return
 	}
 
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceDividend, dividend.ID, dividendJournalEntries(&dividend)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post dividend to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load dividend with company
 	if err := database.DB.Preload("Company").First(&dividend, dividend.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dividend data"})
@@ -117,6 +143,11 @@ func UpdateDividend(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, dividend.CompanyID, dividend.DeclarationDate, optionalDate(dividend.PaymentDate)) {
+		return
+	}
+
 	// Update fields if provided
 	updates := make(map[string]interface{})
 	if req.Amount != nil {
@@ -149,11 +180,45 @@ func UpdateDividend(c *gin.Context) {
 		updates["notes"] = *req.Notes
 	}
 
-	if err := database.DB.Model(&dividend).Updates(updates).Error; err != nil {
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Model(&dividend).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dividend"})
 		return
 	}
 
+	// Reload the updated record
+	if err := tx.First(&dividend, dividend.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload dividend"})
+		return
+	}
+
+	// Reject changes that move the record into a closed accounting period
+	if rejectClosedPeriod(c, dividend.CompanyID, dividend.DeclarationDate, optionalDate(dividend.PaymentDate)) {
+		tx.Rollback()
+		return
+	}
+
+	// Repost to the general ledger
+	if err := syncSourceJournal(tx, sourceDividend, dividend.ID, dividendJournalEntries(&dividend)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post dividend to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load updated dividend with company
 	if err := database.DB.Preload("Company").First(&dividend, dividend.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated dividend data"})
@@ -174,12 +239,38 @@ func DeleteDividend(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, dividend.CompanyID, dividend.DeclarationDate, optionalDate(dividend.PaymentDate)) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
 	// Soft delete dividend
-	if err := database.DB.Delete(&dividend).Error; err != nil {
+	if err := tx.Delete(&dividend).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete dividend"})
 		return
 	}
 
+	// Reverse the dividend's ledger postings
+	if err := reverseSourceJournal(tx, sourceDividend, dividend.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse dividend in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Dividend deleted successfully"})
 }
 
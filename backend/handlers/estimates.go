@@ -0,0 +1,677 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// estimatePaymentDays is the number of days to the due date of an invoice converted from
+// an estimate when no due date is given
+const estimatePaymentDays = 30
+
+// CreateEstimateRequest represents a request to create an estimate
+type CreateEstimateRequest struct {
+	ClientID    uint                       `json:"client_id" binding:"required"`
+	IssueDate   string                     `json:"issue_date" binding:"required"`
+	ExpiryDate  string                     `json:"expiry_date" binding:"required"`
+	Description *string                    `json:"description,omitempty"`
+	Notes       *string                    `json:"notes,omitempty"`
+	Currency    string                     `json:"currency,omitempty" binding:"omitempty,len=3,alpha"` // Defaults to CAD
+	ProjectID   *uint                      `json:"project_id,omitempty"`
+	CompanyID   uint                       `json:"company_id" binding:"required"`
+	Items       []CreateInvoiceItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// UpdateEstimateRequest represents a request to update an estimate that has not been
+// converted into an invoice
+type UpdateEstimateRequest struct {
+	ClientID    *uint                      `json:"client_id,omitempty"`
+	IssueDate   *string                    `json:"issue_date,omitempty"`
+	ExpiryDate  *string                    `json:"expiry_date,omitempty"`
+	Status      *string                    `json:"status,omitempty" binding:"omitempty,oneof=draft sent accepted declined expired"`
+	Description *string                    `json:"description,omitempty"`
+	Notes       *string                    `json:"notes,omitempty"`
+	Currency    *string                    `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	ProjectID   *uint                      `json:"project_id,omitempty"` // 0 to clear
+	Items       []CreateInvoiceItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+}
+
+// ConvertEstimateRequest represents a request to convert an estimate into an invoice
+type ConvertEstimateRequest struct {
+	IssueDate    string   `json:"issue_date,omitempty"`                             // Defaults to today
+	DueDate      string   `json:"due_date,omitempty"`                               // Defaults to 30 days after the issue date
+	ExchangeRate *float64 `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"` // Defaults to the recorded rate on the issue date
+}
+
+// EstimateWinRate summarizes how the estimates of a company were received
+type EstimateWinRate struct {
+	Total     int64        `json:"total"`
+	Draft     int64        `json:"draft"`
+	Sent      int64        `json:"sent"` // Awaiting an answer
+	Accepted  int64        `json:"accepted"`
+	Declined  int64        `json:"declined"`
+	Expired   int64        `json:"expired"`
+	Converted int64        `json:"converted"` // Accepted estimates invoiced
+	WinRate   *float64     `json:"win_rate"`  // Percent of decided estimates accepted; null when none are decided
+	Invoiced  models.Money `json:"invoiced"`  // Subtotal in CAD of the invoices converted from the estimates
+}
+
+// loadEstimate loads an estimate with its client, project, lines and invoice
+func loadEstimate(db *gorm.DB, estimate *models.Estimate, id interface{}) error {
+	return db.Preload("Client").Preload("Project").Preload("Items").Preload("Invoice").First(estimate, id).Error
+}
+
+// generateEstimateNumber generates an estimate number unique within a company: EST-YYYY-XXXX
+func generateEstimateNumber(db *gorm.DB, companyID uint) (string, error) {
+	year := time.Now().Year()
+
+	// Count deleted estimates too, so numbers are never reused
+	var count int64
+	if err := db.Unscoped().Model(&models.Estimate{}).
+		Where("company_id = ? AND EXTRACT(YEAR FROM created_at) = ?", companyID, year).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("EST-%d-%04d", year, count+1), nil
+}
+
+// buildEstimateItems builds the lines of an estimate
+func buildEstimateItems(requests []CreateInvoiceItemRequest) []models.EstimateItem {
+	items := make([]models.EstimateItem, len(requests))
+	for i, itemReq := range requests {
+		items[i] = models.EstimateItem{
+			Description: itemReq.Description,
+			Quantity:    itemReq.Quantity,
+			Unit:        emptyToNil(itemReq.Unit),
+			UnitPrice:   itemReq.UnitPrice,
+			Total:       itemReq.UnitPrice.MulRate(itemReq.Quantity),
+			ProjectID:   zeroToNil(itemReq.ProjectID),
+		}
+	}
+	return items
+}
+
+// estimateTotals calculates the subtotal and HST of estimate lines for a client
+func estimateTotals(items []models.EstimateItem, client *models.Client, company *models.Company) (models.Money, models.Money) {
+	var subtotal models.Money
+	for _, item := range items {
+		subtotal += item.Total
+	}
+
+	return subtotal, invoiceHSTAmount(subtotal, client, company)
+}
+
+// rejectConvertedEstimate responds with 409 Conflict and returns true when an estimate
+// has been converted into an invoice. The estimate's Invoice must be loaded.
+func rejectConvertedEstimate(c *gin.Context, estimate *models.Estimate) bool {
+	if estimate.Invoice == nil {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":      fmt.Sprintf("Estimate has been converted into invoice %s", estimate.Invoice.InvoiceNumber),
+		"invoice_id": estimate.Invoice.ID,
+	})
+	return true
+}
+
+// ListEstimates lists estimates, latest first
+func ListEstimates(c *gin.Context) {
+	var estimates []models.Estimate
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	// Get filter parameters
+	search := c.Query("search")
+	companyID := c.Query("company_id")
+	clientID := c.Query("client_id")
+	status := c.Query("status")
+	projectID := c.Query("project_id")
+
+	query := database.DB.Preload("Client").Preload("Project").Preload("Invoice").Model(&models.Estimate{})
+
+	// Apply filters
+	if search != "" {
+		query = query.Where("estimate_number ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count estimates"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("issue_date DESC, id DESC").Find(&estimates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch estimates"})
+		return
+	}
+
+	response := gin.H{
+		"data":       estimates,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateEstimate creates a draft estimate
+func CreateEstimate(c *gin.Context) {
+	var req CreateEstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse issue and expiry dates
+	issueDate, err := time.Parse("2006-01-02", req.IssueDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid issue date format. Use YYYY-MM-DD"})
+		return
+	}
+	expiryDate, err := time.Parse("2006-01-02", req.ExpiryDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expiry date format. Use YYYY-MM-DD"})
+		return
+	}
+	if expiryDate.Before(issueDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry date must be on or after the issue date"})
+		return
+	}
+
+	// Verify client belongs to the company
+	var client models.Client
+	if err := database.DB.Where("company_id = ?", req.CompanyID).First(&client, req.ClientID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Verify projects belong to the company
+	if !verifyInvoiceProjects(c, req.CompanyID, req.ProjectID, req.Items) {
+		return
+	}
+
+	estimateNumber, err := generateEstimateNumber(database.DB, req.CompanyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate estimate number"})
+		return
+	}
+
+	items := buildEstimateItems(req.Items)
+	subtotal, hstAmount := estimateTotals(items, &client, &company)
+
+	estimate := models.Estimate{
+		EstimateNumber: estimateNumber,
+		ClientID:       req.ClientID,
+		IssueDate:      issueDate,
+		ExpiryDate:     expiryDate,
+		Subtotal:       subtotal,
+		HSTAmount:      hstAmount,
+		Total:          subtotal + hstAmount,
+		Currency:       normalizeCurrency(req.Currency),
+		Status:         "draft",
+		Description:    req.Description,
+		Notes:          emptyToNil(req.Notes),
+		ProjectID:      zeroToNil(req.ProjectID),
+		CompanyID:      req.CompanyID,
+		Items:          items,
+	}
+
+	// Create estimate with its lines
+	if err := database.DB.Create(&estimate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create estimate"})
+		return
+	}
+
+	// Load estimate with related data
+	if err := loadEstimate(database.DB, &estimate, estimate.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load estimate data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, estimate)
+}
+
+// GetEstimate retrieves an estimate by ID with its lines and invoice
+func GetEstimate(c *gin.Context) {
+	estimateID := c.Param("id")
+
+	var estimate models.Estimate
+	if err := loadEstimate(database.DB, &estimate, estimateID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Estimate not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
+
+// UpdateEstimate updates an estimate that has not been converted into an invoice. Totals
+// are recalculated when the lines or client change.
+func UpdateEstimate(c *gin.Context) {
+	estimateID := c.Param("id")
+
+	var req UpdateEstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find estimate
+	var estimate models.Estimate
+	if err := loadEstimate(database.DB, &estimate, estimateID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Estimate not found"})
+		return
+	}
+
+	// Reject changes to converted estimates
+	if rejectConvertedEstimate(c, &estimate) {
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	if req.IssueDate != nil {
+		parsed, err := time.Parse("2006-01-02", *req.IssueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid issue date format. Use YYYY-MM-DD"})
+			return
+		}
+		estimate.IssueDate = parsed
+		updates["issue_date"] = parsed
+	}
+	if req.ExpiryDate != nil {
+		parsed, err := time.Parse("2006-01-02", *req.ExpiryDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expiry date format. Use YYYY-MM-DD"})
+			return
+		}
+		estimate.ExpiryDate = parsed
+		updates["expiry_date"] = parsed
+	}
+	if estimate.ExpiryDate.Before(estimate.IssueDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry date must be on or after the issue date"})
+		return
+	}
+	if req.ClientID != nil {
+		// Verify client belongs to the company
+		var client models.Client
+		if err := database.DB.Where("company_id = ?", estimate.CompanyID).First(&client, *req.ClientID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
+			return
+		}
+		estimate.Client = client
+		updates["client_id"] = *req.ClientID
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Notes != nil {
+		updates["notes"] = emptyToNil(req.Notes)
+	}
+	if req.Currency != nil {
+		updates["currency"] = normalizeCurrency(*req.Currency)
+	}
+	if req.ProjectID != nil {
+		updates["project_id"] = zeroToNil(req.ProjectID)
+	}
+
+	// Verify projects belong to the company
+	if !verifyInvoiceProjects(c, estimate.CompanyID, req.ProjectID, req.Items) {
+		return
+	}
+
+	// Recalculate totals for new lines or a new client
+	items := estimate.Items
+	if len(req.Items) > 0 {
+		items = buildEstimateItems(req.Items)
+	}
+	if len(req.Items) > 0 || req.ClientID != nil {
+		var company models.Company
+		if err := database.DB.First(&company, estimate.CompanyID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load company data"})
+			return
+		}
+		subtotal, hstAmount := estimateTotals(items, &estimate.Client, &company)
+		updates["subtotal"] = subtotal
+		updates["hst_amount"] = hstAmount
+		updates["total"] = subtotal + hstAmount
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&models.Estimate{}).Where("id = ?", estimate.ID).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update estimate"})
+			return
+		}
+	}
+
+	// Replace the lines if provided
+	if len(req.Items) > 0 {
+		if err := tx.Where("estimate_id = ?", estimate.ID).Delete(&models.EstimateItem{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete existing estimate items"})
+			return
+		}
+		for i := range items {
+			items[i].EstimateID = estimate.ID
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create estimate items"})
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load updated estimate with related data
+	var updated models.Estimate
+	if err := loadEstimate(database.DB, &updated, estimate.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated estimate data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteEstimate deletes an estimate that has not been converted into an invoice
+func DeleteEstimate(c *gin.Context) {
+	estimateID := c.Param("id")
+
+	// Find estimate
+	var estimate models.Estimate
+	if err := database.DB.Preload("Invoice").First(&estimate, estimateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Estimate not found"})
+		return
+	}
+
+	// Reject converted estimates
+	if rejectConvertedEstimate(c, &estimate) {
+		return
+	}
+
+	// Soft delete estimate
+	if err := database.DB.Delete(&estimate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete estimate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Estimate deleted successfully"})
+}
+
+// ConvertEstimate creates a draft invoice from an estimate's lines through the same
+// checks as a new invoice, links it to the estimate and marks the estimate accepted
+func ConvertEstimate(c *gin.Context) {
+	estimateID := c.Param("id")
+
+	// The body is optional
+	var req ConvertEstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var estimate models.Estimate
+	if err := loadEstimate(database.DB, &estimate, estimateID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Estimate not found"})
+		return
+	}
+
+	// Reject estimates already converted, declined or expired
+	if rejectConvertedEstimate(c, &estimate) {
+		return
+	}
+	if estimate.Status == "declined" || estimate.Status == "expired" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Estimate %s is %s; only draft, sent or accepted estimates can be converted",
+			estimate.EstimateNumber, estimate.Status)})
+		return
+	}
+
+	// Default the invoice dates
+	issueDate := currentDate()
+	if req.IssueDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.IssueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid issue date format. Use YYYY-MM-DD"})
+			return
+		}
+		issueDate = parsed
+	}
+	dueDate := req.DueDate
+	if dueDate == "" {
+		dueDate = issueDate.AddDate(0, 0, estimatePaymentDays).Format("2006-01-02")
+	}
+
+	invoiceReq := CreateInvoiceRequest{
+		ClientID:     estimate.ClientID,
+		IssueDate:    issueDate.Format("2006-01-02"),
+		DueDate:      dueDate,
+		Description:  estimate.Description,
+		Notes:        estimate.Notes,
+		Currency:     estimate.Currency,
+		ExchangeRate: req.ExchangeRate,
+		ProjectID:    estimate.ProjectID,
+		CompanyID:    estimate.CompanyID,
+	}
+	for _, item := range estimate.Items {
+		invoiceReq.Items = append(invoiceReq.Items, CreateInvoiceItemRequest{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+			UnitPrice:   item.UnitPrice,
+			ProjectID:   item.ProjectID,
+		})
+	}
+
+	invoice, ok := createInvoice(c, &invoiceReq, func(tx *gorm.DB, invoice *models.Invoice) error {
+		// Lock the estimate and check again that it has not been converted meanwhile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Estimate{}, estimate.ID).Error; err != nil {
+			return err
+		}
+		var existing models.Invoice
+		err := tx.Where("estimate_id = ?", estimate.ID).First(&existing).Error
+		if err == nil {
+			return &invoiceLinkError{Status: http.StatusConflict, Body: gin.H{
+				"error":      fmt.Sprintf("Estimate has been converted into invoice %s", existing.InvoiceNumber),
+				"invoice_id": existing.ID,
+			}}
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		invoice.EstimateID = &estimate.ID
+		if err := tx.Model(invoice).Update("estimate_id", estimate.ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Estimate{}).Where("id = ?", estimate.ID).Update("status", "accepted").Error
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// GetEstimateWinRate reports how many of a company's estimates were accepted, declined or
+// expired, by issue date between optional start_date and end_date
+func GetEstimateWinRate(c *gin.Context) {
+	companyID, err := strconv.ParseUint(c.Query("company_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company_id"})
+		return
+	}
+
+	startDate, endDate, ok := parseProjectReportDates(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Preload("Invoice").Where("company_id = ?", companyID)
+	if startDate != nil {
+		query = query.Where("issue_date >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("issue_date <= ?", *endDate)
+	}
+	var estimates []models.Estimate
+	if err := query.Find(&estimates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch estimates"})
+		return
+	}
+
+	var result EstimateWinRate
+	for _, estimate := range estimates {
+		result.Total++
+		switch estimate.Status {
+		case "draft":
+			result.Draft++
+		case "sent":
+			result.Sent++
+		case "accepted":
+			result.Accepted++
+		case "declined":
+			result.Declined++
+		case "expired":
+			result.Expired++
+		}
+		if estimate.Invoice != nil {
+			result.Converted++
+			result.Invoiced += inCAD(estimate.Invoice.Subtotal, estimate.Invoice.ExchangeRate)
+		}
+	}
+	if decided := result.Accepted + result.Declined + result.Expired; decided > 0 {
+		rate := math.Round(float64(result.Accepted)/float64(decided)*1000) / 10
+		result.WinRate = &rate
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// expireEstimates marks sent estimates past their expiry date as expired
+func expireEstimates(db *gorm.DB, today time.Time) error {
+	return db.Model(&models.Estimate{}).
+		Where("status = ? AND expiry_date < ?", "sent", today).
+		Update("status", "expired").Error
+}
+
+// GetEstimatePDF renders an estimate as a PDF with the company's invoice template
+func GetEstimatePDF(c *gin.Context) {
+	estimateID := c.Param("id")
+
+	var estimate models.Estimate
+	if err := database.DB.Preload("Client").Preload("Company").Preload("Items").First(&estimate, estimateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Estimate not found"})
+		return
+	}
+
+	template, err := loadInvoiceTemplate(database.DB, estimate.CompanyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice template"})
+		return
+	}
+
+	pdfBytes, err := generateEstimatePDF(&estimate, &template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set headers for PDF download
+	filename := fmt.Sprintf("Estimate_%s.pdf", estimate.EstimateNumber)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", strconv.Itoa(len(pdfBytes)))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// generateEstimatePDF creates the PDF of an estimate. The estimate's Client, Company and
+// Items must be loaded.
+func generateEstimatePDF(estimate *models.Estimate, template *models.InvoiceTemplate) ([]byte, error) {
+	doc := brandedDocument{
+		Title:     "ESTIMATE",
+		Reference: "Estimate " + estimate.EstimateNumber,
+		Details: [][2]string{
+			{"Estimate Number", estimate.EstimateNumber},
+			{"Date", estimate.IssueDate.Format("January 2, 2006")},
+			{"Valid Until", estimate.ExpiryDate.Format("January 2, 2006")},
+		},
+		PartyLabel:  "PREPARED FOR",
+		Description: estimate.Description,
+		Totals: [][2]string{
+			{"Subtotal", fmt.Sprintf("$%s", estimate.Subtotal)},
+			{hstLabel(estimate.Subtotal, estimate.HSTAmount, estimate.Client.HSTExempt), fmt.Sprintf("$%s", estimate.HSTAmount)},
+		},
+		TotalLabel: fmt.Sprintf("Estimated Total (%s)", estimate.Currency),
+		Total:      estimate.Total,
+	}
+	if estimate.Currency != functionalCurrency {
+		doc.Details = append(doc.Details, [2]string{"Currency", estimate.Currency})
+	}
+	for _, item := range estimate.Items {
+		doc.Lines = append(doc.Lines, brandedLine{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+			UnitPrice:   item.UnitPrice,
+			Amount:      item.Total,
+		})
+	}
+	if estimate.Notes != nil && *estimate.Notes != "" {
+		doc.Sections = append(doc.Sections, [2]string{"NOTES", *estimate.Notes})
+	}
+
+	return renderBrandedPDF(&doc, &estimate.Company, &estimate.Client, template)
+}
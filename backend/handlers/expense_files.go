@@ -71,11 +71,11 @@ func UploadExpenseFile(c *gin.Context) {
 	totalAmount := expense.Amount + expense.HSTPaid
 
 	// Debug logging
-	fmt.Printf("DEBUG: Expense upload - Amount: %.2f, HST: %.2f, Total: %.2f\n",
+	fmt.Printf("DEBUG: Expense upload - Amount: %s, HST: %s, Total: %s\n",
 		expense.Amount, expense.HSTPaid, totalAmount)
 
 	// Get the expense folder path
-	expenseFolderPath := fileStorage.GetExpenseFolderPath(expense.ExpenseDate, expense.Description, totalAmount)
+	expenseFolderPath := fileStorage.GetExpenseFolderPath(expense.ExpenseDate, expense.Description, totalAmount.Float64())
 
 	// Save the file
 	fileName, filePath, fileSize, err := fileStorage.SaveFile(expenseFolderPath, file)
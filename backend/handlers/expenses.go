@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,35 +16,47 @@ import (
 type CreateExpenseCategoryRequest struct {
 	Name        string  `json:"name" binding:"required"`
 	Description *string `json:"description,omitempty"`
+	AccountCode *string `json:"account_code,omitempty"`
+	CompanyID   uint    `json:"company_id,omitempty" binding:"required_with=AccountCode"` // Company whose chart of accounts has the account code
 }
 
 // UpdateExpenseCategoryRequest represents a request to update an expense category
 type UpdateExpenseCategoryRequest struct {
 	Name        *string `json:"name,omitempty"`
 	Description *string `json:"description,omitempty"`
+	AccountCode *string `json:"account_code,omitempty"`
+	CompanyID   uint    `json:"company_id,omitempty" binding:"required_with=AccountCode"` // Company whose chart of accounts has the account code
 }
 
 // CreateExpenseRequest represents a request to create an expense
 type CreateExpenseRequest struct {
-	Description     string  `json:"description" binding:"required"`
-	CategoryID      uint    `json:"category_id" binding:"required"`
-	Amount          float64 `json:"amount" binding:"required,min=0"`
-	HSTPaid         float64 `json:"hst_paid" binding:"min=0"`
-	ExpenseDate     string  `json:"expense_date" binding:"required"`
-	ReceiptAttached bool    `json:"receipt_attached"`
-	PaidBy          string  `json:"paid_by" binding:"required,oneof=corp owner"`
-	CompanyID       uint    `json:"company_id" binding:"required"`
+	Description     string       `json:"description" binding:"required"`
+	CategoryID      uint         `json:"category_id"` // Defaults to the vendor's default category
+	Amount          models.Money `json:"amount" binding:"required,min=0"`
+	HSTPaid         models.Money `json:"hst_paid" binding:"min=0"`
+	Currency        string       `json:"currency,omitempty" binding:"omitempty,len=3,alpha"` // Defaults to CAD
+	ExchangeRate    *float64     `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"`   // Defaults to the recorded rate on the expense date
+	ExpenseDate     string       `json:"expense_date" binding:"required"`
+	ReceiptAttached bool         `json:"receipt_attached"`
+	PaidBy          string       `json:"paid_by" binding:"required,oneof=corp owner"`
+	VendorID        *uint        `json:"vendor_id,omitempty"`
+	ProjectID       *uint        `json:"project_id,omitempty"`
+	CompanyID       uint         `json:"company_id" binding:"required"`
 }
 
 // UpdateExpenseRequest represents a request to update an expense
 type UpdateExpenseRequest struct {
-	Description     *string  `json:"description,omitempty"`
-	CategoryID      *uint    `json:"category_id,omitempty"`
-	Amount          *float64 `json:"amount,omitempty" binding:"omitempty,min=0"`
-	HSTPaid         *float64 `json:"hst_paid,omitempty" binding:"omitempty,min=0"`
-	ExpenseDate     *string  `json:"expense_date,omitempty"`
-	ReceiptAttached *bool    `json:"receipt_attached,omitempty"`
-	PaidBy          *string  `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	Description     *string       `json:"description,omitempty"`
+	CategoryID      *uint         `json:"category_id,omitempty"`
+	Amount          *models.Money `json:"amount,omitempty" binding:"omitempty,min=0"`
+	HSTPaid         *models.Money `json:"hst_paid,omitempty" binding:"omitempty,min=0"`
+	Currency        *string       `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	ExchangeRate    *float64      `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"`
+	ExpenseDate     *string       `json:"expense_date,omitempty"`
+	ReceiptAttached *bool         `json:"receipt_attached,omitempty"`
+	PaidBy          *string       `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	VendorID        *uint         `json:"vendor_id,omitempty"`  // 0 to clear
+	ProjectID       *uint         `json:"project_id,omitempty"` // 0 to clear
 }
 
 // CreateExpenseCategory creates a new expense category
@@ -54,10 +67,17 @@ func CreateExpenseCategory(c *gin.Context) {
 		return
 	}
 
+	// Verify the mapped account is an expense account
+	if req.AccountCode != nil && !isExpenseAccountCode(req.CompanyID, *req.AccountCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Account code %s is not an expense account in the company's chart of accounts", *req.AccountCode)})
+		return
+	}
+
 	// Create expense category
 	category := models.ExpenseCategory{
 		Name:        req.Name,
 		Description: req.Description,
+		AccountCode: req.AccountCode,
 	}
 
 	if err := database.DB.Create(&category).Error; err != nil {
@@ -106,6 +126,14 @@ func UpdateExpenseCategory(c *gin.Context) {
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
+	if req.AccountCode != nil {
+		// Verify the mapped account is an expense account
+		if !isExpenseAccountCode(req.CompanyID, *req.AccountCode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Account code %s is not an expense account in the company's chart of accounts", *req.AccountCode)})
+			return
+		}
+		updates["account_code"] = *req.AccountCode
+	}
 
 	if err := database.DB.Model(&category).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update expense category"})
@@ -196,6 +224,15 @@ func ListExpenseCategories(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// isExpenseAccountCode reports whether a code is an expense account in a company's chart of
+// accounts. Expense categories are shared between companies, so they map to account codes
+// rather than accounts.
+func isExpenseAccountCode(companyID uint, code string) bool {
+	var count int64
+	database.DB.Model(&models.Account{}).Where("company_id = ? AND code = ? AND type = ?", companyID, code, "expense").Count(&count)
+	return count > 0
+}
+
 // CreateExpense creates a new expense
 func CreateExpense(c *gin.Context) {
 	var req CreateExpenseRequest
@@ -204,13 +241,6 @@ func CreateExpense(c *gin.Context) {
 		return
 	}
 
-	// Verify category exists
-	var category models.ExpenseCategory
-	if err := database.DB.First(&category, req.CategoryID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
-		return
-	}
-
 	// Verify company exists
 	var company models.Company
 	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
@@ -218,6 +248,35 @@ func CreateExpense(c *gin.Context) {
 		return
 	}
 
+	// Verify vendor belongs to the company and default to its category
+	if req.VendorID != nil {
+		vendor, ok := findCompanyVendor(c, *req.VendorID, req.CompanyID)
+		if !ok {
+			return
+		}
+		if req.CategoryID == 0 && vendor.DefaultCategoryID != nil {
+			req.CategoryID = *vendor.DefaultCategoryID
+		}
+	}
+	if req.CategoryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required unless the vendor has a default category"})
+		return
+	}
+
+	// Verify project belongs to the company
+	if req.ProjectID != nil && *req.ProjectID != 0 {
+		if _, ok := findCompanyProject(c, *req.ProjectID, req.CompanyID); !ok {
+			return
+		}
+	}
+
+	// Verify category exists
+	var category models.ExpenseCategory
+	if err := database.DB.First(&category, req.CategoryID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+		return
+	}
+
 	// Parse expense date
 	expenseDate, err := time.Parse("2006-01-02", req.ExpenseDate)
 	if err != nil {
@@ -225,25 +284,69 @@ func CreateExpense(c *gin.Context) {
 		return
 	}
 
+	// Resolve the exchange rate on the expense date
+	currency := normalizeCurrency(req.Currency)
+	exchangeRate, ok := resolveExchangeRate(c, database.DB, currency, expenseDate, req.ExchangeRate)
+	if !ok {
+		return
+	}
+
 	// Create expense
 	expense := models.Expense{
 		Description:     req.Description,
 		CategoryID:      req.CategoryID,
 		Amount:          req.Amount,
 		HSTPaid:         req.HSTPaid,
+		Currency:        currency,
+		ExchangeRate:    exchangeRate,
 		ExpenseDate:     expenseDate,
 		ReceiptAttached: req.ReceiptAttached,
 		PaidBy:          req.PaidBy,
+		VendorID:        req.VendorID,
+		ProjectID:       zeroToNil(req.ProjectID),
 		CompanyID:       req.CompanyID,
 	}
 
-	if err := database.DB.Create(&expense).Error; err != nil {
+	// Resolve the expense account for the category
+	expenseAccount, err := expenseAccountCode(database.DB, req.CategoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve expense account"})
+		return
+	}
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, expense.CompanyID, expense.ExpenseDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Create(&expense).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create expense"})
 		return
 	}
 
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceExpense, expense.ID, expenseJournalEntries(&expense, expenseAccount)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post expense to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load expense with related data
-	if err := database.DB.Preload("Category").Preload("Company").Preload("Files").First(&expense, expense.ID).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").Preload("Files").First(&expense, expense.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load expense data"})
 		return
 	}
@@ -256,7 +359,7 @@ func GetExpense(c *gin.Context) {
 	expenseID := c.Param("id")
 
 	var expense models.Expense
-	if err := database.DB.Preload("Category").Preload("Company").Preload("Files").First(&expense, expenseID).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").Preload("Files").First(&expense, expenseID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
 		return
 	}
@@ -281,6 +384,11 @@ func UpdateExpense(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, expense.CompanyID, expense.ExpenseDate) {
+		return
+	}
+
 	// Update fields if provided
 	updates := make(map[string]interface{})
 	if req.Description != nil {
@@ -301,6 +409,9 @@ func UpdateExpense(c *gin.Context) {
 	if req.HSTPaid != nil {
 		updates["hst_paid"] = *req.HSTPaid
 	}
+	if req.Currency != nil {
+		updates["currency"] = normalizeCurrency(*req.Currency)
+	}
 	if req.ExpenseDate != nil {
 		expenseDate, err := time.Parse("2006-01-02", *req.ExpenseDate)
 		if err != nil {
@@ -315,14 +426,87 @@ func UpdateExpense(c *gin.Context) {
 	if req.PaidBy != nil {
 		updates["paid_by"] = *req.PaidBy
 	}
+	if req.VendorID != nil {
+		// Verify vendor belongs to the company
+		if *req.VendorID != 0 {
+			if _, ok := findCompanyVendor(c, *req.VendorID, expense.CompanyID); !ok {
+				return
+			}
+		}
+		updates["vendor_id"] = zeroToNil(req.VendorID)
+	}
+	if req.ProjectID != nil {
+		// Verify project belongs to the company
+		if *req.ProjectID != 0 {
+			if _, ok := findCompanyProject(c, *req.ProjectID, expense.CompanyID); !ok {
+				return
+			}
+		}
+		updates["project_id"] = zeroToNil(req.ProjectID)
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
 
-	if err := database.DB.Model(&expense).Updates(updates).Error; err != nil {
+	if err := tx.Model(&expense).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update expense"})
 		return
 	}
 
+	// Reload the updated record
+	if err := tx.First(&expense, expense.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload expense"})
+		return
+	}
+
+	// Reject changes that move the record into a closed accounting period
+	if rejectClosedPeriod(c, expense.CompanyID, expense.ExpenseDate) {
+		tx.Rollback()
+		return
+	}
+
+	// Re-resolve the exchange rate when the currency or expense date changes
+	if req.Currency != nil || req.ExchangeRate != nil || req.ExpenseDate != nil {
+		exchangeRate, ok := resolveExchangeRate(c, tx, expense.Currency, expense.ExpenseDate, req.ExchangeRate)
+		if !ok {
+			tx.Rollback()
+			return
+		}
+		if err := tx.Model(&expense).Update("exchange_rate", exchangeRate).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update expense exchange rate"})
+			return
+		}
+		expense.ExchangeRate = exchangeRate
+	}
+
+	// Repost to the general ledger
+	expenseAccount, err := expenseAccountCode(tx, expense.CategoryID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve expense account"})
+		return
+	}
+	if err := syncSourceJournal(tx, sourceExpense, expense.ID, expenseJournalEntries(&expense, expenseAccount)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post expense to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load updated expense with related data
-	if err := database.DB.Preload("Category").Preload("Company").Preload("Files").First(&expense, expense.ID).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").Preload("Files").First(&expense, expense.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated expense data"})
 		return
 	}
@@ -341,12 +525,38 @@ func DeleteExpense(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, expense.CompanyID, expense.ExpenseDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
 	// Soft delete expense
-	if err := database.DB.Delete(&expense).Error; err != nil {
+	if err := tx.Delete(&expense).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete expense"})
 		return
 	}
 
+	// Reverse the expense's ledger postings
+	if err := reverseSourceJournal(tx, sourceExpense, expense.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse expense in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Expense deleted successfully"})
 }
 
@@ -363,10 +573,12 @@ func ListExpenses(c *gin.Context) {
 	search := c.Query("search")
 	companyID := c.Query("company_id")
 	categoryID := c.Query("category_id")
+	vendorID := c.Query("vendor_id")
+	projectID := c.Query("project_id")
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 
-	query := database.DB.Preload("Category").Preload("Company").Preload("Files").Model(&models.Expense{})
+	query := database.DB.Preload("Category").Preload("Vendor").Preload("Project").Preload("Company").Preload("Files").Model(&models.Expense{})
 
 	// Apply filters
 	if search != "" {
@@ -378,6 +590,12 @@ func ListExpenses(c *gin.Context) {
 	if categoryID != "" {
 		query = query.Where("category_id = ?", categoryID)
 	}
+	if vendorID != "" {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
 	if startDate != "" {
 		query = query.Where("expense_date >= ?", startDate)
 	}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"time"
+
+	"accounting-backend/models"
+)
+
+// FiscalPeriod is the date range of one fiscal year of a company
+type FiscalPeriod struct {
+	FiscalYear  int       `json:"fiscal_year"` // Calendar year in which the fiscal year ends
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"` // Last day of the fiscal year
+	IsShortYear bool      `json:"is_short_year"`
+}
+
+// Days returns the number of days in the fiscal period
+func (p FiscalPeriod) Days() int {
+	return int(p.EndDate.Sub(p.StartDate).Hours()/24) + 1
+}
+
+// EndOfDay returns the last instant of the fiscal period, for inclusive date range queries
+func (p FiscalPeriod) EndOfDay() time.Time {
+	return time.Date(p.EndDate.Year(), p.EndDate.Month(), p.EndDate.Day(), 23, 59, 59, 0, time.UTC)
+}
+
+// Contains reports whether a date falls within the fiscal period
+func (p FiscalPeriod) Contains(date time.Time) bool {
+	return !date.Before(p.StartDate) && !date.After(p.EndOfDay())
+}
+
+// fiscalYearEndIn returns the company's fiscal year end date in a calendar year. A
+// February 29 year end falls on February 28 in non-leap years.
+func fiscalYearEndIn(company models.Company, year int) time.Time {
+	month, day := company.FiscalYearEnd.Month(), company.FiscalYearEnd.Day()
+	if company.FiscalYearEnd.IsZero() {
+		month, day = time.December, 31
+	}
+
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// resolveFiscalPeriod returns the start and end dates of a company's fiscal year. Fiscal
+// years are labelled by the calendar year in which they end, so with a June 30 year end
+// fiscal year 2025 runs from July 1, 2024 to June 30, 2025. The first fiscal year starts
+// on the incorporation date and is a short year when that falls after the usual start.
+func resolveFiscalPeriod(company models.Company, fiscalYear int) FiscalPeriod {
+	endDate := fiscalYearEndIn(company, fiscalYear)
+	startDate := fiscalYearEndIn(company, fiscalYear-1).AddDate(0, 0, 1)
+
+	period := FiscalPeriod{
+		FiscalYear: fiscalYear,
+		StartDate:  startDate,
+		EndDate:    endDate,
+	}
+
+	if company.IncorporationDate != nil {
+		incorporated := time.Date(company.IncorporationDate.Year(), company.IncorporationDate.Month(), company.IncorporationDate.Day(), 0, 0, 0, 0, time.UTC)
+		if incorporated.After(startDate) && !incorporated.After(endDate) {
+			period.StartDate = incorporated
+			period.IsShortYear = true
+		}
+	}
+
+	return period
+}
+
+// fiscalPeriodForDate returns the fiscal year of a company that contains a date
+func fiscalPeriodForDate(company models.Company, date time.Time) FiscalPeriod {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if day.After(fiscalYearEndIn(company, day.Year())) {
+		return resolveFiscalPeriod(company, day.Year()+1)
+	}
+	return resolveFiscalPeriod(company, day.Year())
+}
+
+// fiscalMonths splits a company's fiscal year into twelve months. Months end on the year
+// end's day of the month, or on the last day of each month when the year ends on a month
+// end. The first months of a short year start no earlier than the incorporation date.
+func fiscalMonths(company models.Company, fiscalYear int) []FiscalPeriod {
+	period := resolveFiscalPeriod(company, fiscalYear)
+	monthEnds := company.FiscalYearEnd.IsZero() || company.FiscalYearEnd.AddDate(0, 0, 1).Day() == 1
+
+	months := make([]FiscalPeriod, 0, 12)
+	start := fiscalYearEndIn(company, fiscalYear-1).AddDate(0, 0, 1)
+	for i := 0; i < 12; i++ {
+		end := period.EndDate
+		if i < 11 {
+			year, month := period.EndDate.Year(), period.EndDate.Month()-time.Month(11-i)
+			lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			day := period.EndDate.Day()
+			if monthEnds || day > lastDay {
+				day = lastDay
+			}
+			end = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		}
+
+		month := FiscalPeriod{FiscalYear: fiscalYear, StartDate: start, EndDate: end}
+		if start.Before(period.StartDate) && !end.Before(period.StartDate) {
+			month.StartDate = period.StartDate
+			month.IsShortYear = true
+		}
+		months = append(months, month)
+		start = end.AddDate(0, 0, 1)
+	}
+	return months
+}
@@ -86,11 +86,37 @@ func CreateHSTPayment(c *gin.Context) {
 		CompanyID:   req.CompanyID,
 	}
 
-	if err := database.DB.Create(&hstPayment).Error; err != nil {
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, hstPayment.CompanyID, hstPayment.PaymentDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Create(&hstPayment).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create HST payment"})
 		return
 	}
 
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceHSTPayment, hstPayment.ID, hstPaymentJournalEntries(&hstPayment)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post HST payment to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load HST payment with company
 	if err := database.DB.Preload("Company").First(&hstPayment, hstPayment.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load HST payment data"})
@@ -130,6 +156,11 @@ func UpdateHSTPayment(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, hstPayment.CompanyID, hstPayment.PaymentDate) {
+		return
+	}
+
 	// Update fields if provided
 	updates := make(map[string]interface{})
 	if req.Amount != nil {
@@ -151,11 +182,45 @@ func UpdateHSTPayment(c *gin.Context) {
 		updates["notes"] = *req.Notes
 	}
 
-	if err := database.DB.Model(&hstPayment).Updates(updates).Error; err != nil {
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Model(&hstPayment).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update HST payment"})
 		return
 	}
 
+	// Reload the updated record
+	if err := tx.First(&hstPayment, hstPayment.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload HST payment"})
+		return
+	}
+
+	// Reject changes that move the record into a closed accounting period
+	if rejectClosedPeriod(c, hstPayment.CompanyID, hstPayment.PaymentDate) {
+		tx.Rollback()
+		return
+	}
+
+	// Repost to the general ledger
+	if err := syncSourceJournal(tx, sourceHSTPayment, hstPayment.ID, hstPaymentJournalEntries(&hstPayment)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post HST payment to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load updated HST payment with company
 	if err := database.DB.Preload("Company").First(&hstPayment, hstPayment.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated HST payment data"})
@@ -176,11 +241,37 @@ func DeleteHSTPayment(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, hstPayment.CompanyID, hstPayment.PaymentDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
 	// Soft delete HST payment
-	if err := database.DB.Delete(&hstPayment).Error; err != nil {
+	if err := tx.Delete(&hstPayment).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete HST payment"})
 		return
 	}
 
+	// Reverse the HST payment's ledger postings
+	if err := reverseSourceJournal(tx, sourceHSTPayment, hstPayment.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse HST payment in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "HST payment deleted successfully"})
 }
@@ -18,6 +18,7 @@ func ListIncomeEntries(c *gin.Context) {
 	// Get query parameters
 	companyID := c.Query("company_id")
 	incomeType := c.Query("income_type")
+	projectID := c.Query("project_id")
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 	page := c.DefaultQuery("page", "1")
@@ -29,7 +30,7 @@ func ListIncomeEntries(c *gin.Context) {
 	offset := (pageInt - 1) * limitInt
 
 	// Build query
-	query := database.DB.Preload("Client").Preload("Company")
+	query := database.DB.Preload("Client").Preload("Project").Preload("Company")
 
 	if companyID != "" {
 		query = query.Where("company_id = ?", companyID)
@@ -37,6 +38,9 @@ func ListIncomeEntries(c *gin.Context) {
 	if incomeType != "" {
 		query = query.Where("income_type = ?", incomeType)
 	}
+	if projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
 	if startDate != "" {
 		query = query.Where("income_date >= ?", startDate)
 	}
@@ -91,6 +95,13 @@ func CreateIncomeEntry(c *gin.Context) {
 		client = &clientRecord
 	}
 
+	// Verify project belongs to the company
+	if req.ProjectID != nil && *req.ProjectID != 0 {
+		if _, ok := findCompanyProject(c, *req.ProjectID, req.CompanyID); !ok {
+			return
+		}
+	}
+
 	// Parse income date
 	incomeDate, err := time.Parse("2006-01-02", req.IncomeDate)
 	if err != nil {
@@ -100,31 +111,67 @@ func CreateIncomeEntry(c *gin.Context) {
 
 	// Calculate HST and total
 	// Only apply HST if it's not client income or if the client is not HST exempt
-	var hstAmount float64
+	var hstAmount models.Money
 	if req.IncomeType != "client" || client == nil || !client.HSTExempt {
-		hstAmount = req.Amount * company.HSTRate
+		hstAmount = req.Amount.MulRate(company.HSTRate)
 	}
 	total := req.Amount + hstAmount
 
+	// Resolve the exchange rate on the income date
+	currency := normalizeCurrency(req.Currency)
+	exchangeRate, ok := resolveExchangeRate(c, database.DB, currency, incomeDate, req.ExchangeRate)
+	if !ok {
+		return
+	}
+
 	// Create income entry
 	incomeEntry := models.IncomeEntry{
-		Description: req.Description,
-		Amount:      req.Amount,
-		HSTAmount:   hstAmount,
-		Total:       total,
-		IncomeType:  req.IncomeType,
-		ClientID:    req.ClientID,
-		IncomeDate:  incomeDate,
-		CompanyID:   req.CompanyID,
+		Description:  req.Description,
+		Amount:       req.Amount,
+		HSTAmount:    hstAmount,
+		Total:        total,
+		Currency:     currency,
+		ExchangeRate: exchangeRate,
+		IncomeType:   req.IncomeType,
+		ClientID:     req.ClientID,
+		IncomeDate:   incomeDate,
+		ProjectID:    zeroToNil(req.ProjectID),
+		CompanyID:    req.CompanyID,
 	}
 
-	if err := database.DB.Create(&incomeEntry).Error; err != nil {
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, incomeEntry.CompanyID, incomeEntry.IncomeDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Create(&incomeEntry).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create income entry"})
 		return
 	}
 
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceIncomeEntry, incomeEntry.ID, incomeEntryJournalEntries(&incomeEntry)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post income entry to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load income entry with relations
-	if err := database.DB.Preload("Client").Preload("Company").First(&incomeEntry, incomeEntry.ID).Error; err != nil {
+	if err := database.DB.Preload("Client").Preload("Project").Preload("Company").First(&incomeEntry, incomeEntry.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load income entry data"})
 		return
 	}
@@ -137,7 +184,7 @@ func GetIncomeEntry(c *gin.Context) {
 	incomeEntryID := c.Param("id")
 
 	var incomeEntry models.IncomeEntry
-	if err := database.DB.Preload("Client").Preload("Company").First(&incomeEntry, incomeEntryID).Error; err != nil {
+	if err := database.DB.Preload("Client").Preload("Project").Preload("Company").First(&incomeEntry, incomeEntryID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Income entry not found"})
 		return
 	}
@@ -157,11 +204,16 @@ func UpdateIncomeEntry(c *gin.Context) {
 
 	// Find income entry with client relationship
 	var incomeEntry models.IncomeEntry
-	if err := database.DB.Preload("Client").Preload("Company").First(&incomeEntry, incomeEntryID).Error; err != nil {
+	if err := database.DB.Preload("Client").Preload("Project").Preload("Company").First(&incomeEntry, incomeEntryID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Income entry not found"})
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, incomeEntry.CompanyID, incomeEntry.IncomeDate) {
+		return
+	}
+
 	// Update fields if provided
 	updates := make(map[string]interface{})
 	if req.Description != nil {
@@ -170,34 +222,49 @@ func UpdateIncomeEntry(c *gin.Context) {
 	if req.Amount != nil {
 		updates["amount"] = *req.Amount
 		// Recalculate HST and total based on client HST exemption
-		var hstAmount float64
+		var hstAmount models.Money
 		if incomeEntry.IncomeType != "client" || incomeEntry.Client == nil || !incomeEntry.Client.HSTExempt {
-			hstAmount = *req.Amount * incomeEntry.Company.HSTRate
+			hstAmount = req.Amount.MulRate(incomeEntry.Company.HSTRate)
 		}
 		updates["hst_amount"] = hstAmount
 		updates["total"] = *req.Amount + hstAmount
 	}
+	if req.Currency != nil {
+		updates["currency"] = normalizeCurrency(*req.Currency)
+	}
+	if req.ExchangeRate != nil {
+		updates["exchange_rate"] = *req.ExchangeRate
+	}
 	if req.IncomeType != nil {
 		updates["income_type"] = *req.IncomeType
 	}
 	if req.ClientID != nil {
 		updates["client_id"] = *req.ClientID
 		// Recalculate HST when client changes
-		var hstAmount float64
+		var hstAmount models.Money
 		if incomeEntry.IncomeType == "client" && *req.ClientID != 0 {
 			// Get the new client to check HST exemption
 			var newClient models.Client
 			if err := database.DB.First(&newClient, *req.ClientID).Error; err == nil {
 				if !newClient.HSTExempt {
-					hstAmount = incomeEntry.Amount * incomeEntry.Company.HSTRate
+					hstAmount = incomeEntry.Amount.MulRate(incomeEntry.Company.HSTRate)
 				}
 			}
 		} else if incomeEntry.IncomeType != "client" {
-			hstAmount = incomeEntry.Amount * incomeEntry.Company.HSTRate
+			hstAmount = incomeEntry.Amount.MulRate(incomeEntry.Company.HSTRate)
 		}
 		updates["hst_amount"] = hstAmount
 		updates["total"] = incomeEntry.Amount + hstAmount
 	}
+	if req.ProjectID != nil {
+		// Verify project belongs to the company
+		if *req.ProjectID != 0 {
+			if _, ok := findCompanyProject(c, *req.ProjectID, incomeEntry.CompanyID); !ok {
+				return
+			}
+		}
+		updates["project_id"] = zeroToNil(req.ProjectID)
+	}
 	if req.IncomeDate != nil {
 		// Parse income date
 		incomeDate, err := time.Parse("2006-01-02", *req.IncomeDate)
@@ -208,7 +275,15 @@ func UpdateIncomeEntry(c *gin.Context) {
 		updates["income_date"] = incomeDate
 	}
 
-	if err := database.DB.Model(&incomeEntry).Updates(updates).Error; err != nil {
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Model(&incomeEntry).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update income entry"})
 		return
 	}
@@ -216,32 +291,73 @@ func UpdateIncomeEntry(c *gin.Context) {
 	// If any field was updated, ensure HST is recalculated based on current client status
 	if len(updates) > 0 {
 		// Reload the income entry with fresh client data
-		if err := database.DB.Preload("Client").Preload("Company").First(&incomeEntry, incomeEntry.ID).Error; err != nil {
+		if err := tx.Preload("Client").Preload("Project").Preload("Company").First(&incomeEntry, incomeEntry.ID).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload income entry data"})
 			return
 		}
 
+		// Reject changes that move the record into a closed accounting period
+		if rejectClosedPeriod(c, incomeEntry.CompanyID, incomeEntry.IncomeDate) {
+			tx.Rollback()
+			return
+		}
+
+		// Re-resolve the exchange rate when the currency or income date changes
+		if req.Currency != nil || req.ExchangeRate != nil || req.IncomeDate != nil {
+			exchangeRate, ok := resolveExchangeRate(c, tx, incomeEntry.Currency, incomeEntry.IncomeDate, req.ExchangeRate)
+			if !ok {
+				tx.Rollback()
+				return
+			}
+			if err := tx.Model(&incomeEntry).Update("exchange_rate", exchangeRate).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update income entry exchange rate"})
+				return
+			}
+			incomeEntry.ExchangeRate = exchangeRate
+		}
+
 		// Recalculate HST based on current client exemption status
-		var hstAmount float64
+		var hstAmount models.Money
 		if incomeEntry.IncomeType == "client" && incomeEntry.Client != nil {
 			if !incomeEntry.Client.HSTExempt {
-				hstAmount = incomeEntry.Amount * incomeEntry.Company.HSTRate
+				hstAmount = incomeEntry.Amount.MulRate(incomeEntry.Company.HSTRate)
 			}
 		} else if incomeEntry.IncomeType != "client" {
-			hstAmount = incomeEntry.Amount * incomeEntry.Company.HSTRate
+			hstAmount = incomeEntry.Amount.MulRate(incomeEntry.Company.HSTRate)
 		}
 
 		// Update HST and total if they changed
 		if hstAmount != incomeEntry.HSTAmount {
-			database.DB.Model(&incomeEntry).Updates(map[string]interface{}{
+			if err := tx.Model(&incomeEntry).Updates(map[string]interface{}{
 				"hst_amount": hstAmount,
 				"total":      incomeEntry.Amount + hstAmount,
-			})
+			}).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update income entry HST"})
+				return
+			}
+			incomeEntry.HSTAmount = hstAmount
+			incomeEntry.Total = incomeEntry.Amount + hstAmount
 		}
+
+		// Repost to the general ledger
+		if err := syncSourceJournal(tx, sourceIncomeEntry, incomeEntry.ID, incomeEntryJournalEntries(&incomeEntry)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post income entry to the general ledger"})
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
 	}
 
 	// Load updated income entry with relations
-	if err := database.DB.Preload("Client").Preload("Company").First(&incomeEntry, incomeEntry.ID).Error; err != nil {
+	if err := database.DB.Preload("Client").Preload("Project").Preload("Company").First(&incomeEntry, incomeEntry.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated income entry data"})
 		return
 	}
@@ -260,11 +376,37 @@ func DeleteIncomeEntry(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, incomeEntry.CompanyID, incomeEntry.IncomeDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
 	// Soft delete income entry
-	if err := database.DB.Delete(&incomeEntry).Error; err != nil {
+	if err := tx.Delete(&incomeEntry).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete income entry"})
 		return
 	}
 
+	// Reverse the income entry's ledger postings
+	if err := reverseSourceJournal(tx, sourceIncomeEntry, incomeEntry.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse income entry in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Income entry deleted successfully"})
 }
@@ -0,0 +1,580 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG logos with image.DecodeConfig
+	_ "image/png"  // Register PNG logos with image.DecodeConfig
+	"math"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jung-kurt/gofpdf"
+	"gorm.io/gorm"
+)
+
+// hexColorPattern matches a #RRGGBB colour
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// parseHexColor returns the red, green and blue components of a #RRGGBB colour
+func parseHexColor(color string) (int, int, int) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil || !hexColorPattern.MatchString(color) {
+		return 0, 0, 0
+	}
+	return int(value >> 16 & 0xFF), int(value >> 8 & 0xFF), int(value & 0xFF)
+}
+
+// loadInvoiceTemplate returns a company's invoice template, or the default template when
+// the company has not configured one
+func loadInvoiceTemplate(db *gorm.DB, companyID uint) (models.InvoiceTemplate, error) {
+	template := models.InvoiceTemplate{
+		CompanyID:    companyID,
+		PrimaryColor: "#1F4E79",
+		AccentColor:  "#EAF1F8",
+	}
+	err := db.Where("company_id = ?", companyID).First(&template).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return template, err
+	}
+	template.HasLogo = template.LogoPath != nil && fileStorage.FileExists(*template.LogoPath)
+	return template, nil
+}
+
+// saveInvoiceTemplate creates or updates a company's invoice template
+func saveInvoiceTemplate(db *gorm.DB, template *models.InvoiceTemplate) error {
+	if template.ID == 0 {
+		return db.Create(template).Error
+	}
+	return db.Model(&models.InvoiceTemplate{}).Where("id = ?", template.ID).Updates(map[string]interface{}{
+		"primary_color": template.PrimaryColor,
+		"accent_color":  template.AccentColor,
+		"logo_path":     template.LogoPath,
+		"payment_terms": template.PaymentTerms,
+		"footer_text":   template.FooterText,
+	}).Error
+}
+
+// findTemplateCompany loads the company of an invoice template route. It responds with 404
+// and returns false when there is none.
+func findTemplateCompany(c *gin.Context) (*models.Company, bool) {
+	var company models.Company
+	if err := database.DB.First(&company, c.Param("company_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return nil, false
+	}
+	return &company, true
+}
+
+// GetInvoiceTemplate retrieves a company's invoice template
+func GetInvoiceTemplate(c *gin.Context) {
+	company, ok := findTemplateCompany(c)
+	if !ok {
+		return
+	}
+
+	template, err := loadInvoiceTemplate(database.DB, company.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateInvoiceTemplate sets a company's invoice colours, payment terms and footer text
+func UpdateInvoiceTemplate(c *gin.Context) {
+	company, ok := findTemplateCompany(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateInvoiceTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := loadInvoiceTemplate(database.DB, company.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice template"})
+		return
+	}
+
+	// Update fields if provided
+	for _, color := range []*string{req.PrimaryColor, req.AccentColor} {
+		if color != nil && !hexColorPattern.MatchString(*color) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid colour. Use #RRGGBB"})
+			return
+		}
+	}
+	if req.PrimaryColor != nil {
+		template.PrimaryColor = strings.ToUpper(*req.PrimaryColor)
+	}
+	if req.AccentColor != nil {
+		template.AccentColor = strings.ToUpper(*req.AccentColor)
+	}
+	if req.PaymentTerms != nil {
+		template.PaymentTerms = emptyToNil(req.PaymentTerms)
+	}
+	if req.FooterText != nil {
+		template.FooterText = emptyToNil(req.FooterText)
+	}
+
+	if err := saveInvoiceTemplate(database.DB, &template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save invoice template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UploadInvoiceLogo uploads a PNG or JPEG logo for a company's invoices, replacing any
+// previous logo
+func UploadInvoiceLogo(c *gin.Context) {
+	company, ok := findTemplateCompany(c)
+	if !ok {
+		return
+	}
+
+	// Get the uploaded file
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	// Validate file size (max 2MB)
+	const maxLogoSize = 2 * 1024 * 1024 // 2MB
+	if file.Size > maxLogoSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds 2MB limit"})
+		return
+	}
+
+	// Validate that the file is a PNG or JPEG image
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Logo must be a PNG or JPEG image"})
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	_, format, err := image.DecodeConfig(src)
+	src.Close()
+	if err != nil || (format != "png" && format != "jpeg") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Logo must be a PNG or JPEG image"})
+		return
+	}
+
+	template, err := loadInvoiceTemplate(database.DB, company.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice template"})
+		return
+	}
+
+	// Save the file
+	logoFolderPath := filepath.Join(fileStorage.BasePath, "logos", strconv.FormatUint(uint64(company.ID), 10))
+	_, filePath, _, err := fileStorage.SaveFile(logoFolderPath, file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file: " + err.Error()})
+		return
+	}
+
+	previousLogo := template.LogoPath
+	template.LogoPath = &filePath
+	if err := saveInvoiceTemplate(database.DB, &template); err != nil {
+		// If database save fails, clean up the file
+		fileStorage.DeleteFile(filePath)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save invoice template"})
+		return
+	}
+	if previousLogo != nil {
+		fileStorage.DeleteFile(*previousLogo)
+	}
+	template.HasLogo = true
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteInvoiceLogo removes a company's invoice logo
+func DeleteInvoiceLogo(c *gin.Context) {
+	company, ok := findTemplateCompany(c)
+	if !ok {
+		return
+	}
+
+	template, err := loadInvoiceTemplate(database.DB, company.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice template"})
+		return
+	}
+	if template.LogoPath == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Logo not found"})
+		return
+	}
+
+	logoPath := *template.LogoPath
+	template.LogoPath = nil
+	if err := saveInvoiceTemplate(database.DB, &template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save invoice template"})
+		return
+	}
+	fileStorage.DeleteFile(logoPath)
+	template.HasLogo = false
+
+	c.JSON(http.StatusOK, template)
+}
+
+// GetInvoicePDF renders an invoice as a PDF with the company's invoice template
+func GetInvoicePDF(c *gin.Context) {
+	invoiceID := c.Param("id")
+
+	var invoice models.Invoice
+	if err := database.DB.Preload("Client").Preload("Company").Preload("Items").First(&invoice, invoiceID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		return
+	}
+
+	template, err := loadInvoiceTemplate(database.DB, invoice.CompanyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice template"})
+		return
+	}
+
+	pdfBytes, err := generateInvoicePDF(&invoice, &template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set headers for PDF download
+	filename := fmt.Sprintf("Invoice_%s.pdf", invoice.InvoiceNumber)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", strconv.Itoa(len(pdfBytes)))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// invoicePaymentTerms returns the payment terms printed on an invoice: the template's
+// terms, or the number of days from issue to due date
+func invoicePaymentTerms(invoice *models.Invoice, template *models.InvoiceTemplate) string {
+	due := invoice.DueDate.Format("January 2, 2006")
+	if template.PaymentTerms != nil {
+		return fmt.Sprintf("%s - due %s", *template.PaymentTerms, due)
+	}
+	days := int(math.Round(invoice.DueDate.Sub(invoice.IssueDate).Hours() / 24))
+	if days <= 0 {
+		return "Due on receipt"
+	}
+	return fmt.Sprintf("Net %d days - due %s", days, due)
+}
+
+// brandedLine is a line item of a branded document
+type brandedLine struct {
+	Description string
+	Quantity    float64
+	Unit        *string
+	UnitPrice   models.Money
+	Amount      models.Money
+}
+
+// brandedDocument is the content of a client document rendered with the company's invoice
+// template, such as an invoice or a credit note
+type brandedDocument struct {
+	Title       string      // Printed in the primary colour, e.g. "INVOICE"
+	Reference   string      // Printed in the footer, e.g. "Invoice 2024-0001"
+	Details     [][2]string // Labels and values beside the title
+	PartyLabel  string      // Heading of the client block, e.g. "BILL TO"
+	Description *string
+	Lines       []brandedLine
+	Totals      [][2]string // Rows above the total
+	TotalLabel  string
+	Total       models.Money
+	Balance     [][2]string // Rows below the total; the last is printed in bold
+	Sections    [][2]string // Headings and paragraphs at the end, e.g. payment terms and notes
+}
+
+// hstLabel returns the label of a document's HST row with the rate charged
+func hstLabel(subtotal, hst models.Money, exempt bool) string {
+	switch {
+	case hst == 0 && exempt:
+		return "HST (exempt)"
+	case subtotal != 0:
+		rate := float64(hst) / float64(subtotal) * 100
+		return fmt.Sprintf("HST %s%%", strconv.FormatFloat(math.Round(rate*100)/100, 'f', -1, 64))
+	}
+	return "HST"
+}
+
+// generateInvoicePDF creates the PDF of an invoice. The invoice's Client, Company and
+// Items must be loaded.
+func generateInvoicePDF(invoice *models.Invoice, template *models.InvoiceTemplate) ([]byte, error) {
+	doc := brandedDocument{
+		Title:     "INVOICE",
+		Reference: "Invoice " + invoice.InvoiceNumber,
+		Details: [][2]string{
+			{"Invoice Number", invoice.InvoiceNumber},
+			{"Issue Date", invoice.IssueDate.Format("January 2, 2006")},
+			{"Due Date", invoice.DueDate.Format("January 2, 2006")},
+		},
+		PartyLabel:  "BILL TO",
+		Description: invoice.Description,
+		Totals: [][2]string{
+			{"Subtotal", fmt.Sprintf("$%s", invoice.Subtotal)},
+			{hstLabel(invoice.Subtotal, invoice.HSTAmount, invoice.Client.HSTExempt), fmt.Sprintf("$%s", invoice.HSTAmount)},
+		},
+		TotalLabel: fmt.Sprintf("Total (%s)", invoice.Currency),
+		Total:      invoice.Total,
+		Sections:   [][2]string{{"PAYMENT TERMS", invoicePaymentTerms(invoice, template)}},
+	}
+	if invoice.Currency != functionalCurrency {
+		doc.Details = append(doc.Details, [2]string{"Currency", invoice.Currency})
+	}
+	switch invoice.Status {
+	case "paid":
+		doc.Details = append(doc.Details, [2]string{"Status", "PAID"})
+	case "partially_paid":
+		doc.Details = append(doc.Details, [2]string{"Status", "PARTIALLY PAID"})
+	}
+	for _, item := range invoice.Items {
+		doc.Lines = append(doc.Lines, brandedLine{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+			UnitPrice:   item.UnitPrice,
+			Amount:      item.Total,
+		})
+	}
+
+	if invoice.AmountPaid > 0 || invoice.AmountCredited > 0 || invoice.Status == "paid" {
+		amountPaid := invoice.AmountPaid
+		if invoice.Status == "paid" && amountPaid == 0 && invoice.AmountCredited == 0 {
+			// Marked paid before payments were recorded
+			amountPaid = invoice.Total
+		}
+		if invoice.AmountCredited > 0 {
+			doc.Balance = append(doc.Balance, [2]string{"Credit Notes", fmt.Sprintf("$%s", invoice.AmountCredited)})
+		}
+		if amountPaid > 0 {
+			paid := "Amount Paid"
+			if invoice.Status == "paid" && invoice.PaidDate != nil {
+				paid = "Paid " + invoice.PaidDate.Format("January 2, 2006")
+			}
+			doc.Balance = append(doc.Balance, [2]string{paid, fmt.Sprintf("$%s", amountPaid)})
+		}
+		doc.Balance = append(doc.Balance,
+			[2]string{"Balance Due", fmt.Sprintf("$%s", invoice.Total-amountPaid-invoice.AmountCredited)})
+	}
+	if invoice.Notes != nil && *invoice.Notes != "" {
+		doc.Sections = append(doc.Sections, [2]string{"NOTES", *invoice.Notes})
+	}
+
+	return renderBrandedPDF(&doc, &invoice.Company, &invoice.Client, template)
+}
+
+// renderBrandedPDF renders a client document with the company's name, address, logo and
+// tax numbers, the client block, line items and totals in the template's colours
+func renderBrandedPDF(doc *brandedDocument, company *models.Company, client *models.Client, template *models.InvoiceTemplate) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 25)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	primaryR, primaryG, primaryB := parseHexColor(template.PrimaryColor)
+	accentR, accentG, accentB := parseHexColor(template.AccentColor)
+
+	// Colour band and footer on every page
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFillColor(primaryR, primaryG, primaryB)
+		pdf.Rect(0, 0, 210, 6, "F")
+		pdf.SetY(15)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-18)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.SetFont("Arial", "", 8)
+		if template.FooterText != nil {
+			pdf.MultiCell(0, 4, tr(*template.FooterText), "", "C", false)
+		}
+		pdf.CellFormat(0, 4, fmt.Sprintf("%s - Page %d of {nb}", doc.Reference, pdf.PageNo()), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	pdf.AddPage()
+
+	// Logo on the left
+	if template.HasLogo {
+		info := pdf.RegisterImageOptions(*template.LogoPath, gofpdf.ImageOptions{ReadDpi: true})
+		if info != nil && info.Width() > 0 && info.Height() > 0 {
+			scale := math.Min(50/info.Width(), 25/info.Height())
+			pdf.ImageOptions(*template.LogoPath, 15, 15, info.Width()*scale, info.Height()*scale, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+		}
+	}
+
+	// Company block on the right
+	pdf.SetXY(105, 15)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(90, 7, tr(company.Name), "", 2, "R", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	if company.Address != nil {
+		for _, line := range strings.Split(*company.Address, "\n") {
+			pdf.CellFormat(90, 4.5, tr(strings.TrimSpace(line)), "", 2, "R", false, 0, "")
+		}
+	}
+	pdf.CellFormat(90, 4.5, "Business Number: "+company.BusinessNumber, "", 2, "R", false, 0, "")
+	if company.HSTNumber != nil && *company.HSTNumber != "" {
+		pdf.CellFormat(90, 4.5, "GST/HST Registration: "+*company.HSTNumber, "", 2, "R", false, 0, "")
+	}
+
+	// Title and document details
+	pdf.SetY(math.Max(pdf.GetY(), 42) + 6)
+	top := pdf.GetY()
+	pdf.SetTextColor(primaryR, primaryG, primaryB)
+	pdf.SetFont("Arial", "B", 24)
+	pdf.CellFormat(90, 12, doc.Title, "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	pdf.SetXY(120, top)
+	for _, detail := range doc.Details {
+		pdf.SetX(120)
+		pdf.SetFont("Arial", "B", 9)
+		pdf.CellFormat(35, 5.5, detail[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(40, 5.5, tr(detail[1]), "", 1, "R", false, 0, "")
+	}
+	detailsBottom := pdf.GetY()
+
+	// Client block
+	pdf.SetY(top + 16)
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetTextColor(primaryR, primaryG, primaryB)
+	pdf.CellFormat(90, 5, doc.PartyLabel, "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(90, 5, tr(client.Name), "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	if client.ContactPerson != nil && *client.ContactPerson != "" {
+		pdf.CellFormat(90, 4.5, tr("Attn: "+*client.ContactPerson), "", 1, "L", false, 0, "")
+	}
+	if client.Address != nil {
+		for _, line := range strings.Split(*client.Address, "\n") {
+			pdf.CellFormat(90, 4.5, tr(strings.TrimSpace(line)), "", 1, "L", false, 0, "")
+		}
+	}
+	if client.Email != nil && *client.Email != "" {
+		pdf.CellFormat(90, 4.5, tr(*client.Email), "", 1, "L", false, 0, "")
+	}
+	pdf.SetY(math.Max(math.Max(pdf.GetY(), top+24), detailsBottom) + 6)
+
+	if doc.Description != nil && *doc.Description != "" {
+		pdf.SetFont("Arial", "I", 9)
+		pdf.MultiCell(0, 5, tr(*doc.Description), "", "L", false)
+		pdf.Ln(3)
+	}
+
+	// Line items
+	widths := []float64{85, 20, 20, 27, 28}
+	itemHeadings := func() {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetFillColor(primaryR, primaryG, primaryB)
+		pdf.SetTextColor(255, 255, 255)
+		for i, heading := range []string{"Description", "Quantity", "Unit", "Unit Price", "Amount"} {
+			align := "R"
+			if i == 0 || i == 2 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, heading, "", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Arial", "", 9)
+		pdf.SetFillColor(accentR, accentG, accentB)
+	}
+	itemHeadings()
+	for i, line := range doc.Lines {
+		texts := pdf.SplitText(tr(line.Description), widths[0]-2)
+		height := 6 * float64(len(texts))
+		if pdf.GetY()+height > 272 {
+			pdf.AddPage()
+			itemHeadings()
+		}
+		x, y := pdf.GetXY()
+		if i%2 == 1 {
+			pdf.Rect(x, y, 180, height, "F")
+		}
+		for j, text := range texts {
+			pdf.SetXY(x, y+6*float64(j))
+			pdf.CellFormat(widths[0], 6, text, "", 0, "L", false, 0, "")
+		}
+		unit := ""
+		if line.Unit != nil {
+			unit = tr(*line.Unit)
+		}
+		pdf.SetXY(x+widths[0], y)
+		pdf.CellFormat(widths[1], 6, strconv.FormatFloat(line.Quantity, 'f', -1, 64), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 6, unit, "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, fmt.Sprintf("$%s", line.UnitPrice), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, fmt.Sprintf("$%s", line.Amount), "", 0, "R", false, 0, "")
+		pdf.SetXY(x, y+height)
+	}
+	pdf.SetDrawColor(primaryR, primaryG, primaryB)
+	pdf.Line(15, pdf.GetY(), 195, pdf.GetY())
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Ln(3)
+
+	// Totals
+	for _, row := range doc.Totals {
+		pdf.SetX(120)
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(47, 6, row[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(28, 6, row[1], "", 1, "R", false, 0, "")
+	}
+	pdf.SetX(120)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFillColor(accentR, accentG, accentB)
+	pdf.CellFormat(47, 8, doc.TotalLabel, "T", 0, "R", true, 0, "")
+	pdf.CellFormat(28, 8, fmt.Sprintf("$%s", doc.Total), "T", 1, "R", true, 0, "")
+	for i, row := range doc.Balance {
+		pdf.SetX(120)
+		if i == len(doc.Balance)-1 {
+			pdf.SetFont("Arial", "B", 9)
+		} else {
+			pdf.SetFont("Arial", "", 9)
+		}
+		pdf.CellFormat(47, 6, row[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(28, 6, row[1], "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	// Closing sections such as payment terms and notes
+	for i, section := range doc.Sections {
+		if i > 0 {
+			pdf.Ln(3)
+		}
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetTextColor(primaryR, primaryG, primaryB)
+		pdf.CellFormat(0, 5, section[0], "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Arial", "", 9)
+		pdf.MultiCell(0, 5, tr(section[1]), "", "L", false)
+	}
+
+	// Output to bytes buffer
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
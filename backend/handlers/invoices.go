@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,34 +11,72 @@ import (
 	"accounting-backend/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateInvoiceRequest represents a request to create an invoice
 type CreateInvoiceRequest struct {
-	ClientID    uint                       `json:"client_id" binding:"required"`
-	IssueDate   string                     `json:"issue_date" binding:"required"`
-	DueDate     string                     `json:"due_date" binding:"required"`
-	Description *string                    `json:"description,omitempty"`
-	CompanyID   uint                       `json:"company_id" binding:"required"`
-	Items       []CreateInvoiceItemRequest `json:"items" binding:"required,min=1"`
+	ClientID     uint                       `json:"client_id" binding:"required"`
+	IssueDate    string                     `json:"issue_date" binding:"required"`
+	DueDate      string                     `json:"due_date" binding:"required"`
+	Description  *string                    `json:"description,omitempty"`
+	Notes        *string                    `json:"notes,omitempty"`
+	Currency     string                     `json:"currency,omitempty" binding:"omitempty,len=3,alpha"` // Defaults to CAD
+	ExchangeRate *float64                   `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"`   // Defaults to the recorded rate on the issue date
+	ProjectID    *uint                      `json:"project_id,omitempty"`
+	CompanyID    uint                       `json:"company_id" binding:"required"`
+	Items        []CreateInvoiceItemRequest `json:"items" binding:"required,min=1"`
 }
 
 // CreateInvoiceItemRequest represents a request to create an invoice item
 type CreateInvoiceItemRequest struct {
-	Description string  `json:"description" binding:"required"`
-	Quantity    float64 `json:"quantity" binding:"required,min=0"`
-	UnitPrice   float64 `json:"unit_price" binding:"required,min=0"`
+	Description string       `json:"description" binding:"required"`
+	Quantity    float64      `json:"quantity" binding:"required,min=0"`
+	Unit        *string      `json:"unit,omitempty"` // e.g. hour, day or each
+	UnitPrice   models.Money `json:"unit_price" binding:"required,min=0"`
+	ProjectID   *uint        `json:"project_id,omitempty"` // Defaults to the invoice's project
 }
 
-// UpdateInvoiceRequest represents a request to update an invoice
+// UpdateInvoiceRequest represents a request to update an invoice. Invoices are paid by
+// recording payments; invoices with payments can only change their dates, description,
+// notes and project.
 type UpdateInvoiceRequest struct {
-	ClientID    *uint                      `json:"client_id,omitempty"`
-	IssueDate   *string                    `json:"issue_date,omitempty"`
-	DueDate     *string                    `json:"due_date,omitempty"`
-	Status      *string                    `json:"status,omitempty" binding:"omitempty,oneof=draft sent paid overdue cancelled"`
-	PaidDate    *string                    `json:"paid_date,omitempty"`
-	Description *string                    `json:"description,omitempty"`
-	Items       []CreateInvoiceItemRequest `json:"items,omitempty"`
+	ClientID     *uint                      `json:"client_id,omitempty"`
+	IssueDate    *string                    `json:"issue_date,omitempty"`
+	DueDate      *string                    `json:"due_date,omitempty"`
+	Status       *string                    `json:"status,omitempty" binding:"omitempty,oneof=draft sent overdue cancelled"`
+	Description  *string                    `json:"description,omitempty"`
+	Notes        *string                    `json:"notes,omitempty"`
+	Currency     *string                    `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	ExchangeRate *float64                   `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"`
+	ProjectID    *uint                      `json:"project_id,omitempty"` // 0 to clear
+	Items        []CreateInvoiceItemRequest `json:"items,omitempty"`
+}
+
+// verifyInvoiceProjects checks that the projects of an invoice and its lines belong to the
+// company. It responds with 400 and returns false when one does not.
+func verifyInvoiceProjects(c *gin.Context, companyID uint, projectID *uint, items []CreateInvoiceItemRequest) bool {
+	projectIDs := []*uint{projectID}
+	for _, item := range items {
+		projectIDs = append(projectIDs, item.ProjectID)
+	}
+	for _, id := range projectIDs {
+		if id != nil && *id != 0 {
+			if _, ok := findCompanyProject(c, *id, companyID); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// invoiceHSTAmount returns the HST on an invoice subtotal at the company's rate; clients
+// exempt from HST are charged none
+func invoiceHSTAmount(subtotal models.Money, client *models.Client, company *models.Company) models.Money {
+	if client.HSTExempt {
+		return 0
+	}
+	return subtotal.MulRate(company.HSTRate)
 }
 
 // CreateInvoice creates a new invoice
@@ -48,52 +87,85 @@ func CreateInvoice(c *gin.Context) {
 		return
 	}
 
+	invoice, ok := createInvoice(c, &req, nil)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// invoiceLinkError is an error from linking a new invoice to the record it was created
+// from that is reported with its own status and body rather than as a server error
+type invoiceLinkError struct {
+	Status int
+	Body   gin.H
+}
+
+func (e *invoiceLinkError) Error() string {
+	return fmt.Sprint(e.Body["error"])
+}
+
+// createInvoice validates and creates a draft invoice and posts it to the general ledger.
+// afterCreate, when given, runs in the same transaction once the invoice exists, to link
+// the record the invoice was created from; it can return an *invoiceLinkError to reject
+// the link. It responds with an error and returns false when the invoice cannot be created.
+func createInvoice(c *gin.Context, req *CreateInvoiceRequest, afterCreate func(tx *gorm.DB, invoice *models.Invoice) error) (*models.Invoice, bool) {
 	// Parse issue date
 	issueDate, err := time.Parse("2006-01-02", req.IssueDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid issue date format. Use YYYY-MM-DD"})
-		return
+		return nil, false
 	}
 
 	// Parse due date
 	dueDate, err := time.Parse("2006-01-02", req.DueDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due date format. Use YYYY-MM-DD"})
-		return
+		return nil, false
 	}
 
 	// Verify client exists
 	var client models.Client
 	if err := database.DB.First(&client, req.ClientID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
-		return
+		return nil, false
 	}
 
 	// Verify company exists
 	var company models.Company
 	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
-		return
+		return nil, false
+	}
+
+	// Verify projects belong to the company
+	if !verifyInvoiceProjects(c, req.CompanyID, req.ProjectID, req.Items) {
+		return nil, false
+	}
+
+	// Resolve the exchange rate on the issue date
+	currency := normalizeCurrency(req.Currency)
+	exchangeRate, ok := resolveExchangeRate(c, database.DB, currency, issueDate, req.ExchangeRate)
+	if !ok {
+		return nil, false
 	}
 
 	// Generate invoice number
 	invoiceNumber, err := generateInvoiceNumber(req.CompanyID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invoice number"})
-		return
+		return nil, false
 	}
 
 	// Calculate totals
-	subtotal := 0.0
+	var subtotal models.Money
 	for _, item := range req.Items {
-		subtotal += item.Quantity * item.UnitPrice
+		subtotal += item.UnitPrice.MulRate(item.Quantity)
 	}
 
 	// Calculate HST (check if client is HST exempt)
-	hstAmount := 0.0
-	if !client.HSTExempt {
-		hstAmount = subtotal * company.HSTRate
-	}
+	hstAmount := invoiceHSTAmount(subtotal, &client, &company)
 
 	total := subtotal + hstAmount
 
@@ -106,54 +178,84 @@ func CreateInvoice(c *gin.Context) {
 		Subtotal:      subtotal,
 		HSTAmount:     hstAmount,
 		Total:         total,
+		Currency:      currency,
+		ExchangeRate:  exchangeRate,
 		Status:        "draft",
 		Description:   req.Description,
+		Notes:         emptyToNil(req.Notes),
+		ProjectID:     zeroToNil(req.ProjectID),
 		CompanyID:     req.CompanyID,
 	}
 
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, invoice.CompanyID, invoice.IssueDate) {
+		return nil, false
+	}
+
 	// Start transaction
 	tx := database.DB.Begin()
 	if tx.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
-		return
+		return nil, false
 	}
 
-	// Create invoice
-	if err := tx.Create(&invoice).Error; err != nil {
+	// Create invoice with its items and post it to the general ledger
+	if err := insertInvoice(tx, &invoice, req.Items); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice"})
-		return
+		return nil, false
 	}
 
-	// Create invoice items
-	for _, itemReq := range req.Items {
-		item := models.InvoiceItem{
-			InvoiceID:   invoice.ID,
-			Description: itemReq.Description,
-			Quantity:    itemReq.Quantity,
-			UnitPrice:   itemReq.UnitPrice,
-			Total:       itemReq.Quantity * itemReq.UnitPrice,
-		}
-		if err := tx.Create(&item).Error; err != nil {
+	if afterCreate != nil {
+		if err := afterCreate(tx, &invoice); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice item"})
-			return
+			var linkErr *invoiceLinkError
+			if errors.As(err, &linkErr) {
+				c.JSON(linkErr.Status, linkErr.Body)
+				return nil, false
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link invoice"})
+			return nil, false
 		}
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
-		return
+		return nil, false
 	}
 
 	// Load invoice with related data
-	if err := database.DB.Preload("Client").Preload("Company").Preload("Items").First(&invoice, invoice.ID).Error; err != nil {
+	if err := database.DB.Preload("Client").Preload("Company").Preload("Project").Preload("Items").First(&invoice, invoice.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invoice data"})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusCreated, invoice)
+	return &invoice, true
+}
+
+// insertInvoice creates an invoice and its items and posts it to the general ledger
+func insertInvoice(tx *gorm.DB, invoice *models.Invoice, items []CreateInvoiceItemRequest) error {
+	if err := tx.Create(invoice).Error; err != nil {
+		return err
+	}
+
+	for _, itemReq := range items {
+		item := models.InvoiceItem{
+			InvoiceID:   invoice.ID,
+			Description: itemReq.Description,
+			Quantity:    itemReq.Quantity,
+			Unit:        emptyToNil(itemReq.Unit),
+			UnitPrice:   itemReq.UnitPrice,
+			Total:       itemReq.UnitPrice.MulRate(itemReq.Quantity),
+			ProjectID:   zeroToNil(itemReq.ProjectID),
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+
+	return syncSourceJournal(tx, sourceInvoice, invoice.ID, invoiceJournalEntries(invoice))
 }
 
 // GetInvoice retrieves an invoice by ID
@@ -161,7 +263,7 @@ func GetInvoice(c *gin.Context) {
 	invoiceID := c.Param("id")
 
 	var invoice models.Invoice
-	if err := database.DB.Preload("Client").Preload("Company").Preload("Items").First(&invoice, invoiceID).Error; err != nil {
+	if err := database.DB.Preload("Client").Preload("Company").Preload("Project").Preload("Items").Preload("Payments.Payment").First(&invoice, invoiceID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
 		return
 	}
@@ -182,7 +284,6 @@ func UpdateInvoice(c *gin.Context) {
 	// Parse dates if provided
 	var issueDate *time.Time
 	var dueDate *time.Time
-	var paidDate *time.Time
 
 	if req.IssueDate != nil {
 		parsed, err := time.Parse("2006-01-02", *req.IssueDate)
@@ -202,15 +303,6 @@ func UpdateInvoice(c *gin.Context) {
 		dueDate = &parsed
 	}
 
-	if req.PaidDate != nil {
-		parsed, err := time.Parse("2006-01-02", *req.PaidDate)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid paid date format. Use YYYY-MM-DD"})
-			return
-		}
-		paidDate = &parsed
-	}
-
 	// Find invoice
 	var invoice models.Invoice
 	if err := database.DB.Preload("Client").Preload("Company").First(&invoice, invoiceID).Error; err != nil {
@@ -218,6 +310,23 @@ func UpdateInvoice(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, invoice.CompanyID, invoice.IssueDate, optionalDate(invoice.PaidDate)) {
+		return
+	}
+
+	// Reject changes to the amounts and status of invoices with payments
+	if req.ClientID != nil || req.Status != nil || req.Currency != nil || req.ExchangeRate != nil || len(req.Items) > 0 {
+		if rejectInvoiceWithPayments(c, &invoice) {
+			return
+		}
+	}
+
+	// Verify projects belong to the company
+	if !verifyInvoiceProjects(c, invoice.CompanyID, req.ProjectID, req.Items) {
+		return
+	}
+
 	// Start transaction
 	tx := database.DB.Begin()
 	if tx.Error != nil {
@@ -246,12 +355,18 @@ func UpdateInvoice(c *gin.Context) {
 	if req.Status != nil {
 		updates["status"] = *req.Status
 	}
-	if paidDate != nil {
-		updates["paid_date"] = *paidDate
-	}
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
+	if req.Notes != nil {
+		updates["notes"] = emptyToNil(req.Notes)
+	}
+	if req.Currency != nil {
+		updates["currency"] = normalizeCurrency(*req.Currency)
+	}
+	if req.ProjectID != nil {
+		updates["project_id"] = zeroToNil(req.ProjectID)
+	}
 
 	// Update invoice if there are changes
 	if len(updates) > 0 {
@@ -272,14 +387,16 @@ func UpdateInvoice(c *gin.Context) {
 		}
 
 		// Create new items
-		subtotal := 0.0
+		var subtotal models.Money
 		for _, itemReq := range req.Items {
 			item := models.InvoiceItem{
 				InvoiceID:   invoice.ID,
 				Description: itemReq.Description,
 				Quantity:    itemReq.Quantity,
+				Unit:        emptyToNil(itemReq.Unit),
 				UnitPrice:   itemReq.UnitPrice,
-				Total:       itemReq.Quantity * itemReq.UnitPrice,
+				Total:       itemReq.UnitPrice.MulRate(itemReq.Quantity),
+				ProjectID:   zeroToNil(itemReq.ProjectID),
 			}
 			if err := tx.Create(&item).Error; err != nil {
 				tx.Rollback()
@@ -304,10 +421,7 @@ func UpdateInvoice(c *gin.Context) {
 			return
 		}
 
-		hstAmount := 0.0
-		if !client.HSTExempt {
-			hstAmount = subtotal * company.HSTRate
-		}
+		hstAmount := invoiceHSTAmount(subtotal, &client, &company)
 
 		total := subtotal + hstAmount
 
@@ -323,6 +437,72 @@ func UpdateInvoice(c *gin.Context) {
 		}
 	}
 
+	// Reload the updated record
+	if err := tx.First(&invoice, invoice.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload invoice"})
+		return
+	}
+
+	// Reject changes that move the record into a closed accounting period
+	if rejectClosedPeriod(c, invoice.CompanyID, invoice.IssueDate, optionalDate(invoice.PaidDate)) {
+		tx.Rollback()
+		return
+	}
+
+	// Recalculate the status of an issued invoice when its due date changes, so it is no
+	// longer overdue once the due date moves out or becomes overdue when it moves in
+	if dueDate != nil && req.Status == nil {
+		switch invoice.Status {
+		case "sent", "partially_paid", "overdue":
+			if status := invoiceStatus(&invoice); status != invoice.Status {
+				if err := tx.Model(&invoice).Update("status", status).Error; err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice status"})
+					return
+				}
+			}
+		}
+	}
+
+	// Re-resolve the exchange rates when the currency or their dates change
+	rateUpdates := make(map[string]interface{})
+	if req.Currency != nil || req.ExchangeRate != nil || issueDate != nil {
+		exchangeRate, ok := resolveExchangeRate(c, tx, invoice.Currency, invoice.IssueDate, req.ExchangeRate)
+		if !ok {
+			tx.Rollback()
+			return
+		}
+		rateUpdates["exchange_rate"] = exchangeRate
+	}
+	if invoice.Status == "paid" && invoice.AmountPaid == 0 && (invoice.PaidExchangeRate == nil || req.Currency != nil) {
+		paidRate, ok := resolveExchangeRate(c, tx, invoice.Currency, invoicePaidDate(&invoice), nil)
+		if !ok {
+			tx.Rollback()
+			return
+		}
+		rateUpdates["paid_exchange_rate"] = paidRate
+	}
+	if len(rateUpdates) > 0 {
+		if err := tx.Model(&invoice).Updates(rateUpdates).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice exchange rates"})
+			return
+		}
+		if err := tx.First(&invoice, invoice.ID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload invoice"})
+			return
+		}
+	}
+
+	// Repost to the general ledger
+	if err := syncSourceJournal(tx, sourceInvoice, invoice.ID, invoiceJournalEntries(&invoice)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post invoice to the general ledger"})
+		return
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
@@ -330,7 +510,7 @@ func UpdateInvoice(c *gin.Context) {
 	}
 
 	// Load updated invoice with related data
-	if err := database.DB.Preload("Client").Preload("Company").Preload("Items").First(&invoice, invoice.ID).Error; err != nil {
+	if err := database.DB.Preload("Client").Preload("Company").Preload("Project").Preload("Items").First(&invoice, invoice.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated invoice data"})
 		return
 	}
@@ -349,12 +529,43 @@ func DeleteInvoice(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, invoice.CompanyID, invoice.IssueDate, optionalDate(invoice.PaidDate)) {
+		return
+	}
+
+	// Reject invoices with payments
+	if rejectInvoiceWithPayments(c, &invoice) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
 	// Soft delete invoice (items will be cascade deleted)
-	if err := database.DB.Delete(&invoice).Error; err != nil {
+	if err := tx.Delete(&invoice).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete invoice"})
 		return
 	}
 
+	// Reverse the invoice's ledger postings
+	if err := reverseSourceJournal(tx, sourceInvoice, invoice.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse invoice in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Invoice deleted successfully"})
 }
 
@@ -372,8 +583,9 @@ func ListInvoices(c *gin.Context) {
 	companyID := c.Query("company_id")
 	clientID := c.Query("client_id")
 	status := c.Query("status")
+	projectID := c.Query("project_id")
 
-	query := database.DB.Preload("Client").Preload("Company").Model(&models.Invoice{})
+	query := database.DB.Preload("Client").Preload("Company").Preload("Project").Model(&models.Invoice{})
 
 	// Apply filters
 	if search != "" {
@@ -388,6 +600,11 @@ func ListInvoices(c *gin.Context) {
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if projectID != "" {
+		// Invoices for the project or with a line for it
+		query = query.Where("project_id = ? OR id IN (?)", projectID,
+			database.DB.Model(&models.InvoiceItem{}).Select("invoice_id").Where("project_id = ?", projectID))
+	}
 
 	// Get total count
 	var total int64
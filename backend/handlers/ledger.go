@@ -0,0 +1,851 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// General ledger account codes used when posting source documents
+const (
+	accountCash                    = "1000"
+	accountAccountsReceivable      = "1100"
+	accountHSTReceivable           = "1200"
+	accountCapitalAssets           = "1500"
+	accountAccumulatedDepreciation = "1510"
+	accountAccountsPayable         = "2000"
+	accountHSTPayable              = "2100"
+	accountDividendsPayable        = "2200"
+	accountDueToShareholder        = "2300"
+	accountClientCredits           = "2400"
+	accountShareCapital            = "3000"
+	accountRetainedEarnings        = "3100"
+	accountDividendsDeclared       = "3200"
+	accountRevenue                 = "4000"
+	accountOtherIncome             = "4100"
+	accountGainOnDisposal          = "4150"
+	accountForeignExchange         = "4200"
+	accountDepreciationExpense     = "5800"
+	accountOtherExpenses           = "5900"
+)
+
+// Journal source types
+const (
+	sourceExpense           = "expense"
+	sourceInvoice           = "invoice"
+	sourceIncomeEntry       = "income_entry"
+	sourceDividend          = "dividend"
+	sourceHSTPayment        = "hst_payment"
+	sourceOwnerPayment      = "owner_payment"
+	sourceCapitalAsset      = "capital_asset"
+	sourceDepreciationEntry = "depreciation_entry"
+	sourceBill              = "bill"
+	sourceBillPayment       = "bill_payment"
+	sourcePayment           = "payment"
+	sourceCreditNote        = "credit_note"
+	sourceManual            = "manual"
+)
+
+// debitLine builds a debit journal line
+func debitLine(accountCode string, amount models.Money) models.JournalLine {
+	return models.JournalLine{AccountCode: accountCode, Debit: amount}
+}
+
+// creditLine builds a credit journal line
+func creditLine(accountCode string, amount models.Money) models.JournalLine {
+	return models.JournalLine{AccountCode: accountCode, Credit: amount}
+}
+
+// newJournalEntry builds a journal entry for a source document, dropping zero-value lines
+func newJournalEntry(companyID uint, date time.Time, description, sourceType string, sourceID uint, lines ...models.JournalLine) models.JournalEntry {
+	entry := models.JournalEntry{
+		EntryDate:   date,
+		Description: description,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		CompanyID:   companyID,
+	}
+	for _, line := range lines {
+		if line.Debit == 0 && line.Credit == 0 {
+			continue
+		}
+		line.CompanyID = companyID
+		entry.Lines = append(entry.Lines, line)
+	}
+	return entry
+}
+
+// postJournalEntry validates that an entry balances against accounts in the
+// company's chart of accounts and saves it with its lines
+func postJournalEntry(tx *gorm.DB, entry *models.JournalEntry) error {
+	if len(entry.Lines) == 0 {
+		return nil
+	}
+
+	var debits, credits models.Money
+	accountCodes := make(map[string]bool)
+	for _, line := range entry.Lines {
+		accountCodes[line.AccountCode] = true
+		if line.Debit < 0 || line.Credit < 0 {
+			return fmt.Errorf("journal line for account %s has a negative amount", line.AccountCode)
+		}
+		if line.Debit != 0 && line.Credit != 0 {
+			return fmt.Errorf("journal line for account %s has both a debit and a credit", line.AccountCode)
+		}
+		debits += line.Debit
+		credits += line.Credit
+	}
+	if debits != credits {
+		return fmt.Errorf("journal entry %q is not balanced: debits %s, credits %s", entry.Description, debits, credits)
+	}
+
+	codes := make([]string, 0, len(accountCodes))
+	for code := range accountCodes {
+		codes = append(codes, code)
+	}
+	var accountCount int64
+	if err := tx.Model(&models.Account{}).Where("company_id = ? AND code IN ?", entry.CompanyID, codes).Count(&accountCount).Error; err != nil {
+		return err
+	}
+	if int(accountCount) != len(codes) {
+		return fmt.Errorf("journal entry %q references an account that is not in the chart of accounts", entry.Description)
+	}
+
+	return tx.Create(entry).Error
+}
+
+// reverseJournalEntry posts an entry that exactly offsets an existing entry.
+// The reversal carries the original entry date so period totals always reflect
+// the current state of the source document.
+func reverseJournalEntry(tx *gorm.DB, entry *models.JournalEntry) error {
+	reversal := models.JournalEntry{
+		EntryDate:    entry.EntryDate,
+		Description:  "Reversal: " + entry.Description,
+		SourceType:   entry.SourceType,
+		SourceID:     entry.SourceID,
+		ReversalOfID: &entry.ID,
+		CompanyID:    entry.CompanyID,
+	}
+	for _, line := range entry.Lines {
+		reversal.Lines = append(reversal.Lines, models.JournalLine{
+			AccountCode: line.AccountCode,
+			Debit:       line.Credit,
+			Credit:      line.Debit,
+			Memo:        line.Memo,
+			CompanyID:   line.CompanyID,
+		})
+	}
+
+	if err := postJournalEntry(tx, &reversal); err != nil {
+		return err
+	}
+
+	return tx.Model(entry).Update("reversed_by_id", reversal.ID).Error
+}
+
+// reverseSourceJournal reverses every active entry posted for a source document
+func reverseSourceJournal(tx *gorm.DB, sourceType string, sourceID uint) error {
+	var entries []models.JournalEntry
+	if err := tx.Preload("Lines").
+		Where("source_type = ? AND source_id = ? AND reversal_of_id IS NULL AND reversed_by_id IS NULL", sourceType, sourceID).
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for i := range entries {
+		if err := reverseJournalEntry(tx, &entries[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncSourceJournal replaces the ledger postings of a source document: existing
+// entries are reversed and the given entries are posted in their place
+func syncSourceJournal(tx *gorm.DB, sourceType string, sourceID uint, entries []models.JournalEntry) error {
+	if err := reverseSourceJournal(tx, sourceType, sourceID); err != nil {
+		return fmt.Errorf("failed to reverse journal entries: %w", err)
+	}
+
+	for i := range entries {
+		if err := postJournalEntry(tx, &entries[i]); err != nil {
+			return fmt.Errorf("failed to post journal entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// paymentAccount returns the account credited when something is paid by the corporation or the owner
+func paymentAccount(paidBy string) string {
+	if paidBy == "owner" {
+		return accountDueToShareholder
+	}
+	return accountCash
+}
+
+// expenseJournalEntries builds the ledger postings for an expense against its category's expense account
+func expenseJournalEntries(expense *models.Expense, expenseAccount string) []models.JournalEntry {
+	amount := inCAD(expense.Amount, expense.ExchangeRate)
+	hst := inCAD(expense.HSTPaid, expense.ExchangeRate)
+
+	return []models.JournalEntry{
+		newJournalEntry(expense.CompanyID, expense.ExpenseDate, "Expense: "+expense.Description, sourceExpense, expense.ID,
+			debitLine(expenseAccount, amount),
+			debitLine(accountHSTReceivable, hst),
+			creditLine(paymentAccount(expense.PaidBy), amount+hst),
+		),
+	}
+}
+
+// invoicePaidDate returns the date an invoice was paid, falling back to its issue date
+func invoicePaidDate(invoice *models.Invoice) time.Time {
+	if invoice.PaidDate != nil {
+		return *invoice.PaidDate
+	}
+	return invoice.IssueDate
+}
+
+// invoicePaidRate returns the exchange rate an invoice was paid at, falling back to its issue-date rate
+func invoicePaidRate(invoice *models.Invoice) float64 {
+	if invoice.PaidExchangeRate != nil {
+		return *invoice.PaidExchangeRate
+	}
+	return invoice.ExchangeRate
+}
+
+// invoiceRealizedFXGain returns the foreign exchange gain, or loss when negative, realized
+// when a foreign currency invoice is paid at a different rate than it was issued at
+func invoiceRealizedFXGain(invoice *models.Invoice) models.Money {
+	receivable := inCAD(invoice.Subtotal, invoice.ExchangeRate) + inCAD(invoice.HSTAmount, invoice.ExchangeRate)
+	return inCAD(invoice.Total, invoicePaidRate(invoice)) - receivable
+}
+
+// invoiceJournalEntries builds the ledger postings for an invoice. Drafts and
+// cancelled invoices are not posted. Foreign currency invoices are posted in CAD at
+// the issue-date rate. Receipts are posted by payments; invoices marked paid before
+// payments were recorded also post the receipt, at the paid-date rate with the
+// difference as a realized FX gain or loss.
+func invoiceJournalEntries(invoice *models.Invoice) []models.JournalEntry {
+	if invoice.Status == "draft" || invoice.Status == "cancelled" {
+		return nil
+	}
+
+	subtotal := inCAD(invoice.Subtotal, invoice.ExchangeRate)
+	hst := inCAD(invoice.HSTAmount, invoice.ExchangeRate)
+	description := "Invoice " + invoice.InvoiceNumber
+
+	entries := []models.JournalEntry{
+		newJournalEntry(invoice.CompanyID, invoice.IssueDate, description, sourceInvoice, invoice.ID,
+			debitLine(accountAccountsReceivable, subtotal+hst),
+			creditLine(accountRevenue, subtotal),
+			creditLine(accountHSTPayable, hst),
+		),
+	}
+
+	if invoice.Status == "paid" && invoice.AmountPaid == 0 {
+		received := inCAD(invoice.Total, invoicePaidRate(invoice))
+		fxGain := invoiceRealizedFXGain(invoice)
+
+		var fxLine models.JournalLine
+		if fxGain > 0 {
+			fxLine = creditLine(accountForeignExchange, fxGain)
+		} else {
+			fxLine = debitLine(accountForeignExchange, -fxGain)
+		}
+
+		entries = append(entries, newJournalEntry(invoice.CompanyID, invoicePaidDate(invoice), "Payment received: "+description, sourceInvoice, invoice.ID,
+			debitLine(accountCash, received),
+			creditLine(accountAccountsReceivable, subtotal+hst),
+			fxLine,
+		))
+	}
+
+	return entries
+}
+
+// incomeEntryJournalEntries builds the ledger postings for an income entry
+func incomeEntryJournalEntries(incomeEntry *models.IncomeEntry) []models.JournalEntry {
+	amount := inCAD(incomeEntry.Amount, incomeEntry.ExchangeRate)
+	hst := inCAD(incomeEntry.HSTAmount, incomeEntry.ExchangeRate)
+
+	incomeAccount := accountRevenue
+	switch incomeEntry.IncomeType {
+	case "capital":
+		incomeAccount = accountDueToShareholder
+	case "other":
+		incomeAccount = accountOtherIncome
+	}
+
+	return []models.JournalEntry{
+		newJournalEntry(incomeEntry.CompanyID, incomeEntry.IncomeDate, "Income: "+incomeEntry.Description, sourceIncomeEntry, incomeEntry.ID,
+			debitLine(accountCash, amount+hst),
+			creditLine(incomeAccount, amount),
+			creditLine(accountHSTPayable, hst),
+		),
+	}
+}
+
+// dividendJournalEntries builds the ledger postings for a dividend declaration and payment
+func dividendJournalEntries(dividend *models.Dividend) []models.JournalEntry {
+	amount := dividend.Amount
+
+	entries := []models.JournalEntry{
+		newJournalEntry(dividend.CompanyID, dividend.DeclarationDate, "Dividend declared", sourceDividend, dividend.ID,
+			debitLine(accountDividendsDeclared, amount),
+			creditLine(accountDividendsPayable, amount),
+		),
+	}
+
+	if dividend.Status == "paid" {
+		paymentDate := dividend.DeclarationDate
+		if dividend.PaymentDate != nil {
+			paymentDate = *dividend.PaymentDate
+		}
+		entries = append(entries, newJournalEntry(dividend.CompanyID, paymentDate, "Dividend paid", sourceDividend, dividend.ID,
+			debitLine(accountDividendsPayable, amount),
+			creditLine(accountCash, amount),
+		))
+	}
+
+	return entries
+}
+
+// hstPaymentJournalEntries builds the ledger postings for an HST remittance
+func hstPaymentJournalEntries(payment *models.HSTPayment) []models.JournalEntry {
+	description := fmt.Sprintf("HST remittance for %s to %s",
+		payment.PeriodStart.Format("2006-01-02"), payment.PeriodEnd.Format("2006-01-02"))
+
+	return []models.JournalEntry{
+		newJournalEntry(payment.CompanyID, payment.PaymentDate, description, sourceHSTPayment, payment.ID,
+			debitLine(accountHSTPayable, payment.Amount),
+			creditLine(accountCash, payment.Amount),
+		),
+	}
+}
+
+// ownerPaymentJournalEntries builds the ledger postings for a payment to the owner
+func ownerPaymentJournalEntries(payment *models.OwnerPayment) []models.JournalEntry {
+	return []models.JournalEntry{
+		newJournalEntry(payment.CompanyID, payment.PaymentDate, "Owner payment: "+payment.Description, sourceOwnerPayment, payment.ID,
+			debitLine(accountDueToShareholder, payment.Amount),
+			creditLine(accountCash, payment.Amount),
+		),
+	}
+}
+
+// billJournalEntries builds the ledger postings for a bill: each line to the expense
+// account of its category and the HST as an input tax credit, against accounts payable.
+// Void bills are not posted.
+func billJournalEntries(bill *models.Bill, itemAccounts map[uint]string) []models.JournalEntry {
+	if bill.Status == "void" {
+		return nil
+	}
+
+	var lines []models.JournalLine
+	for _, item := range bill.Items {
+		lines = append(lines, debitLine(itemAccounts[item.CategoryID], item.Amount))
+	}
+	lines = append(lines,
+		debitLine(accountHSTReceivable, bill.HSTAmount),
+		creditLine(accountAccountsPayable, bill.Total),
+	)
+
+	return []models.JournalEntry{
+		newJournalEntry(bill.CompanyID, bill.BillDate, "Bill "+bill.BillNumber, sourceBill, bill.ID, lines...),
+	}
+}
+
+// billPaymentJournalEntries builds the ledger postings for a payment of bills
+func billPaymentJournalEntries(payment *models.BillPayment) []models.JournalEntry {
+	return []models.JournalEntry{
+		newJournalEntry(payment.CompanyID, payment.PaymentDate, "Bill payment", sourceBillPayment, payment.ID,
+			debitLine(accountAccountsPayable, payment.Amount),
+			creditLine(paymentAccount(payment.PaidBy), payment.Amount),
+		),
+	}
+}
+
+// creditUse is part of a client credit applied to an invoice on a date, or refunded to the
+// client when it has no invoice
+type creditUse struct {
+	Amount  models.Money
+	Date    time.Time
+	Invoice *models.Invoice
+}
+
+// creditUseLines builds the lines for uses of a client credit worth value in CAD: a credit
+// to accounts receivable at each invoice's issue-date rate, or to cash at the credit's
+// rate for a refund, with the difference as a realized FX gain or loss
+func creditUseLines(uses []creditUse, rate float64, value models.Money) []models.JournalLine {
+	var lines []models.JournalLine
+	fxGain := value
+	for _, use := range uses {
+		if use.Invoice == nil {
+			refund := inCAD(use.Amount, rate)
+			lines = append(lines, creditLine(accountCash, refund))
+			fxGain -= refund
+			continue
+		}
+		receivable := inCAD(use.Amount, use.Invoice.ExchangeRate)
+		lines = append(lines, creditLine(accountAccountsReceivable, receivable))
+		fxGain -= receivable
+	}
+	if fxGain > 0 {
+		lines = append(lines, creditLine(accountForeignExchange, fxGain))
+	} else if fxGain < 0 {
+		lines = append(lines, debitLine(accountForeignExchange, -fxGain))
+	}
+	return lines
+}
+
+// clientCreditEntries builds the ledger postings for an amount owed to a client, such as a
+// payment or a credit note, in a currency at rate. The first entry, on the date the credit
+// arises, has the opening lines, which debit its value in CAD; it settles the uses on that
+// date and holds the rest in client credits. Each later date the credit was used gets its
+// own entry drawing on client credits. Uses are valued as the change in the value of the
+// credit left, so rounding never leaves credit behind once it is fully used.
+func clientCreditEntries(companyID uint, sourceType string, sourceID uint, date time.Time, description, useDescription string,
+	amount models.Money, rate float64, opening []models.JournalLine, uses []creditUse) []models.JournalEntry {
+	var total models.Money
+	for _, line := range opening {
+		total += line.Debit
+	}
+	value := func(credit models.Money) models.Money {
+		if credit == amount {
+			return total
+		}
+		return inCAD(credit, rate)
+	}
+
+	var atStart []creditUse
+	later := make(map[time.Time][]creditUse)
+	var dates []time.Time
+	credit := amount
+	for _, use := range uses {
+		if !use.Date.After(date) {
+			atStart = append(atStart, use)
+			credit -= use.Amount
+			continue
+		}
+		if _, ok := later[use.Date]; !ok {
+			dates = append(dates, use.Date)
+		}
+		later[use.Date] = append(later[use.Date], use)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	creditValue := value(credit)
+	lines := append([]models.JournalLine{}, opening...)
+	lines = append(lines, creditUseLines(atStart, rate, total-creditValue)...)
+	if creditValue != 0 {
+		lines = append(lines, creditLine(accountClientCredits, creditValue))
+	}
+	entries := []models.JournalEntry{
+		newJournalEntry(companyID, date, description, sourceType, sourceID, lines...),
+	}
+
+	for _, useDate := range dates {
+		for _, use := range later[useDate] {
+			credit -= use.Amount
+		}
+		used := creditValue - value(credit)
+		creditValue -= used
+
+		lines := []models.JournalLine{debitLine(accountClientCredits, used)}
+		lines = append(lines, creditUseLines(later[useDate], rate, used)...)
+		entries = append(entries, newJournalEntry(companyID, useDate, useDescription, sourceType, sourceID, lines...))
+	}
+
+	return entries
+}
+
+// paymentJournalEntries builds the ledger postings for a payment from a client: the
+// receipt, settling the invoices it is allocated to on the payment date with the rest
+// held as a client credit, and an entry for each later date credit was applied to
+// invoices. The allocations' invoices must be loaded.
+func paymentJournalEntries(payment *models.Payment) []models.JournalEntry {
+	uses := make([]creditUse, len(payment.Allocations))
+	for i, allocation := range payment.Allocations {
+		uses[i] = creditUse{Amount: allocation.Amount, Date: allocation.AppliedDate, Invoice: allocation.Invoice}
+	}
+	received := inCAD(payment.Amount, payment.ExchangeRate)
+
+	return clientCreditEntries(payment.CompanyID, sourcePayment, payment.ID, payment.PaymentDate, "Payment received", "Client credit applied",
+		payment.Amount, payment.ExchangeRate, []models.JournalLine{debitLine(payment.AccountCode, received)}, uses)
+}
+
+// creditNoteJournalEntries builds the ledger postings for a credit note: the reversal of
+// revenue and HST collected on its issue date, held as a client credit until it is applied
+// to invoices or refunded. Credit notes are converted to CAD at their invoice's rate. The
+// applications' invoices must be loaded.
+func creditNoteJournalEntries(creditNote *models.CreditNote) []models.JournalEntry {
+	uses := make([]creditUse, len(creditNote.Applications))
+	for i, application := range creditNote.Applications {
+		uses[i] = creditUse{Amount: application.Amount, Date: application.AppliedDate, Invoice: application.Invoice}
+	}
+	description := "Credit note " + creditNote.CreditNoteNumber
+
+	return clientCreditEntries(creditNote.CompanyID, sourceCreditNote, creditNote.ID, creditNote.IssueDate, description, description+" applied",
+		creditNote.Total, creditNote.ExchangeRate, []models.JournalLine{
+			debitLine(accountRevenue, inCAD(creditNote.Subtotal, creditNote.ExchangeRate)),
+			debitLine(accountHSTPayable, inCAD(creditNote.HSTAmount, creditNote.ExchangeRate)),
+		}, uses)
+}
+
+// capitalAssetJournalEntries builds the ledger postings for the purchase and disposal of a capital asset
+func capitalAssetJournalEntries(asset *models.CapitalAsset) []models.JournalEntry {
+	totalCost := asset.TotalCost
+
+	entries := []models.JournalEntry{
+		newJournalEntry(asset.CompanyID, asset.PurchaseDate, "Capital asset purchase: "+asset.Description, sourceCapitalAsset, asset.ID,
+			debitLine(accountCapitalAssets, totalCost),
+			creditLine(paymentAccount(asset.PaidBy), totalCost),
+		),
+	}
+
+	if asset.DisposalDate != nil {
+		var proceeds models.Money
+		if asset.DisposalAmount != nil {
+			proceeds = *asset.DisposalAmount
+		}
+		accumulated := asset.AccumulatedDepreciation
+
+		lines := []models.JournalLine{
+			debitLine(accountCash, proceeds),
+			debitLine(accountAccumulatedDepreciation, accumulated),
+			creditLine(accountCapitalAssets, totalCost),
+		}
+		if gain := proceeds + accumulated - totalCost; gain > 0 {
+			lines = append(lines, creditLine(accountGainOnDisposal, gain))
+		} else if gain < 0 {
+			lines = append(lines, debitLine(accountGainOnDisposal, -gain))
+		}
+
+		entries = append(entries, newJournalEntry(asset.CompanyID, *asset.DisposalDate, "Capital asset disposal: "+asset.Description, sourceCapitalAsset, asset.ID, lines...))
+	}
+
+	return entries
+}
+
+// depreciationJournalEntries builds the ledger postings for a depreciation entry
+func depreciationJournalEntries(entry *models.DepreciationEntry) []models.JournalEntry {
+	description := fmt.Sprintf("Depreciation for fiscal year %d", entry.FiscalYear)
+
+	return []models.JournalEntry{
+		newJournalEntry(entry.CompanyID, entry.EntryDate, description, sourceDepreciationEntry, entry.ID,
+			debitLine(accountDepreciationExpense, entry.DepreciationAmount),
+			creditLine(accountAccumulatedDepreciation, entry.DepreciationAmount),
+		),
+	}
+}
+
+// ListJournalEntries lists journal entries
+func ListJournalEntries(c *gin.Context) {
+	var entries []models.JournalEntry
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	// Get filter parameters
+	companyID := c.Query("company_id")
+	sourceType := c.Query("source_type")
+	sourceID := c.Query("source_id")
+	accountCode := c.Query("account_code")
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	query := database.DB.Preload("Lines").Model(&models.JournalEntry{})
+
+	// Apply filters
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if sourceType != "" {
+		query = query.Where("source_type = ?", sourceType)
+	}
+	if sourceID != "" {
+		query = query.Where("source_id = ?", sourceID)
+	}
+	if accountCode != "" {
+		query = query.Where("id IN (?)", database.DB.Model(&models.JournalLine{}).Select("journal_entry_id").Where("account_code = ?", accountCode))
+	}
+	if startDate != "" {
+		query = query.Where("entry_date >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where("entry_date <= ?", endDate)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count journal entries"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("entry_date DESC, id DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch journal entries"})
+		return
+	}
+
+	response := gin.H{
+		"data":       entries,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetJournalEntry retrieves a journal entry by ID
+func GetJournalEntry(c *gin.Context) {
+	entryID := c.Param("id")
+
+	var entry models.JournalEntry
+	if err := database.DB.Preload("Lines").First(&entry, entryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Journal entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// CreateJournalEntry posts a manual journal entry, e.g. an accountant's adjustment
+func CreateJournalEntry(c *gin.Context) {
+	var req models.CreateJournalEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Parse entry date
+	entryDate, err := time.Parse("2006-01-02", req.EntryDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	// Reject entries dated in a closed accounting period
+	if rejectClosedPeriod(c, req.CompanyID, entryDate) {
+		return
+	}
+
+	lines := make([]models.JournalLine, 0, len(req.Lines))
+	for _, lineReq := range req.Lines {
+		line := models.JournalLine{
+			AccountCode: lineReq.AccountCode,
+			Debit:       lineReq.Debit,
+			Credit:      lineReq.Credit,
+			Memo:        lineReq.Memo,
+		}
+		lines = append(lines, line)
+	}
+
+	entry := newJournalEntry(req.CompanyID, entryDate, req.Description, sourceManual, 0, lines...)
+	if len(entry.Lines) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one line must have a non-zero debit or credit"})
+		return
+	}
+
+	if err := postJournalEntry(database.DB, &entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Load entry with lines
+	if err := database.DB.Preload("Lines").First(&entry, entry.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load journal entry data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// ReverseJournalEntry reverses a manual journal entry
+func ReverseJournalEntry(c *gin.Context) {
+	entryID := c.Param("id")
+
+	var entry models.JournalEntry
+	if err := database.DB.Preload("Lines").First(&entry, entryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Journal entry not found"})
+		return
+	}
+
+	if entry.SourceType != sourceManual {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only manual journal entries can be reversed directly; edit the source record instead"})
+		return
+	}
+	if entry.ReversalOfID != nil || entry.ReversedByID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Journal entry has already been reversed"})
+		return
+	}
+
+	// Reversals keep the original date, so the period must still be open
+	if rejectClosedPeriod(c, entry.CompanyID, entry.EntryDate) {
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return reverseJournalEntry(tx, &entry)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse journal entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Journal entry reversed successfully"})
+}
+
+// BackfillJournal posts ledger entries for existing records of a company that
+// were created before the general ledger existed
+func BackfillJournal(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id is required"})
+		return
+	}
+
+	var company models.Company
+	if err := database.DB.First(&company, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	posted := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// hasEntries reports whether a source document already has ledger postings
+		hasEntries := func(sourceType string, sourceID uint) (bool, error) {
+			var count int64
+			err := tx.Model(&models.JournalEntry{}).Where("source_type = ? AND source_id = ?", sourceType, sourceID).Count(&count).Error
+			return count > 0, err
+		}
+		post := func(sourceType string, sourceID uint, entries []models.JournalEntry) error {
+			exists, err := hasEntries(sourceType, sourceID)
+			if err != nil || exists {
+				return err
+			}
+			if err := syncSourceJournal(tx, sourceType, sourceID, entries); err != nil {
+				return err
+			}
+			posted += len(entries)
+			return nil
+		}
+
+		var expenses []models.Expense
+		if err := tx.Where("company_id = ?", company.ID).Find(&expenses).Error; err != nil {
+			return err
+		}
+		for i := range expenses {
+			expenseAccount, err := expenseAccountCode(tx, expenses[i].CategoryID)
+			if err != nil {
+				return err
+			}
+			if err := post(sourceExpense, expenses[i].ID, expenseJournalEntries(&expenses[i], expenseAccount)); err != nil {
+				return err
+			}
+		}
+
+		var invoices []models.Invoice
+		if err := tx.Where("company_id = ?", company.ID).Find(&invoices).Error; err != nil {
+			return err
+		}
+		for i := range invoices {
+			if err := post(sourceInvoice, invoices[i].ID, invoiceJournalEntries(&invoices[i])); err != nil {
+				return err
+			}
+		}
+
+		var incomeEntries []models.IncomeEntry
+		if err := tx.Where("company_id = ?", company.ID).Find(&incomeEntries).Error; err != nil {
+			return err
+		}
+		for i := range incomeEntries {
+			if err := post(sourceIncomeEntry, incomeEntries[i].ID, incomeEntryJournalEntries(&incomeEntries[i])); err != nil {
+				return err
+			}
+		}
+
+		var dividends []models.Dividend
+		if err := tx.Where("company_id = ?", company.ID).Find(&dividends).Error; err != nil {
+			return err
+		}
+		for i := range dividends {
+			if err := post(sourceDividend, dividends[i].ID, dividendJournalEntries(&dividends[i])); err != nil {
+				return err
+			}
+		}
+
+		var hstPayments []models.HSTPayment
+		if err := tx.Where("company_id = ?", company.ID).Find(&hstPayments).Error; err != nil {
+			return err
+		}
+		for i := range hstPayments {
+			if err := post(sourceHSTPayment, hstPayments[i].ID, hstPaymentJournalEntries(&hstPayments[i])); err != nil {
+				return err
+			}
+		}
+
+		var ownerPayments []models.OwnerPayment
+		if err := tx.Where("company_id = ?", company.ID).Find(&ownerPayments).Error; err != nil {
+			return err
+		}
+		for i := range ownerPayments {
+			if err := post(sourceOwnerPayment, ownerPayments[i].ID, ownerPaymentJournalEntries(&ownerPayments[i])); err != nil {
+				return err
+			}
+		}
+
+		var assets []models.CapitalAsset
+		if err := tx.Where("company_id = ?", company.ID).Find(&assets).Error; err != nil {
+			return err
+		}
+		for i := range assets {
+			if err := post(sourceCapitalAsset, assets[i].ID, capitalAssetJournalEntries(&assets[i])); err != nil {
+				return err
+			}
+		}
+
+		var depreciationEntries []models.DepreciationEntry
+		if err := tx.Where("company_id = ?", company.ID).Find(&depreciationEntries).Error; err != nil {
+			return err
+		}
+		for i := range depreciationEntries {
+			if err := post(sourceDepreciationEntry, depreciationEntries[i].ID, depreciationJournalEntries(&depreciationEntries[i])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill journal: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Journal backfilled successfully",
+		"entries_posted": posted,
+		"company_id":     company.ID,
+	})
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrialBalanceLine represents one account on the trial balance
+type TrialBalanceLine struct {
+	AccountCode    string       `json:"account_code"`
+	Name           string       `json:"name"`
+	Type           string       `json:"type"`
+	GIFICode       string       `json:"gifi_code"`
+	OpeningBalance models.Money `json:"opening_balance"`
+	PeriodDebit    models.Money `json:"period_debit"`
+	PeriodCredit   models.Money `json:"period_credit"`
+	ClosingBalance models.Money `json:"closing_balance"`
+	Debit          models.Money `json:"debit"`  // Closing balance when it falls on the debit side
+	Credit         models.Money `json:"credit"` // Closing balance when it falls on the credit side
+}
+
+// GeneralLedgerLine represents one posting to an account with its running balance.
+// SourceType and SourceID identify the expense, invoice, income entry or payment
+// that produced the posting.
+type GeneralLedgerLine struct {
+	JournalEntryID uint         `json:"journal_entry_id"`
+	EntryDate      time.Time    `json:"entry_date"`
+	Description    string       `json:"description"`
+	SourceType     string       `json:"source_type"`
+	SourceID       uint         `json:"source_id"`
+	Memo           *string      `json:"memo"`
+	Debit          models.Money `json:"debit"`
+	Credit         models.Money `json:"credit"`
+	Balance        models.Money `json:"balance"`
+}
+
+// GeneralLedgerAccount represents the postings to one account over a period
+type GeneralLedgerAccount struct {
+	AccountCode    string              `json:"account_code"`
+	Name           string              `json:"name"`
+	Type           string              `json:"type"`
+	OpeningBalance models.Money        `json:"opening_balance"`
+	PeriodDebit    models.Money        `json:"period_debit"`
+	PeriodCredit   models.Money        `json:"period_credit"`
+	ClosingBalance models.Money        `json:"closing_balance"`
+	Lines          []GeneralLedgerLine `json:"lines"`
+}
+
+// generalLedgerRow is a journal line joined with its entry
+type generalLedgerRow struct {
+	AccountCode    string
+	JournalEntryID uint
+	EntryDate      time.Time
+	Description    string
+	SourceType     string
+	SourceID       uint
+	Memo           *string
+	Debit          models.Money
+	Credit         models.Money
+}
+
+// isPeriodAccount reports whether an account's balance is closed to retained earnings
+// at the start of each period, i.e. revenue, expense and dividends declared
+func isPeriodAccount(account models.Account) bool {
+	return account.Type == "revenue" || account.Type == "expense" || account.Code == accountDividendsDeclared
+}
+
+// ledgerPeriodBalances returns the opening balance of every account at the start date
+// and the activity posted between the start and end dates. Revenue, expense and
+// dividend accounts open at zero and their earlier balances are carried in retained earnings.
+func ledgerPeriodBalances(companyID uint, accounts []models.Account, startDate, endDate time.Time) (map[string]models.Money, map[string]accountActivity, error) {
+	closing, err := ledgerActivity(database.DB, companyID, nil, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	period, err := ledgerActivity(database.DB, companyID, &startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Opening activity is everything posted before the start date
+	openingActivity := make(map[string]accountActivity, len(closing))
+	for code, activity := range closing {
+		openingActivity[code] = accountActivity{
+			AccountCode: code,
+			Debit:       activity.Debit - period[code].Debit,
+			Credit:      activity.Credit - period[code].Credit,
+		}
+	}
+
+	opening := make(map[string]models.Money, len(accounts))
+	for _, account := range accounts {
+		switch {
+		case account.Code == accountRetainedEarnings:
+			opening[account.Code] = retainedEarningsAsOf(accounts, openingActivity)
+		case isPeriodAccount(account):
+			opening[account.Code] = 0
+		default:
+			opening[account.Code] = normalBalance(account.Type, openingActivity[account.Code])
+		}
+	}
+
+	return opening, period, nil
+}
+
+// parseLedgerReportQuery reads the company_id, start_date and end_date query parameters
+func parseLedgerReportQuery(c *gin.Context) (uint, time.Time, time.Time, bool) {
+	companyID, err := strconv.ParseUint(c.Query("company_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company_id"})
+		return 0, time.Time{}, time.Time{}, false
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+		return 0, time.Time{}, time.Time{}, false
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+		return 0, time.Time{}, time.Time{}, false
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return 0, time.Time{}, time.Time{}, false
+	}
+
+	return uint(companyID), startDate, endDate, true
+}
+
+// GetTrialBalance lists every account's opening balance, period debits and credits and
+// closing balance for a date range. Use format=csv for a CSV file.
+func GetTrialBalance(c *gin.Context) {
+	companyID, startDate, endDate, ok := parseLedgerReportQuery(c)
+	if !ok {
+		return
+	}
+	includeZero := c.Query("include_zero") == "true"
+
+	var accounts []models.Account
+	if err := database.DB.Where("company_id = ?", companyID).Order("code ASC").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	opening, period, err := ledgerPeriodBalances(companyID, accounts, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate account balances"})
+		return
+	}
+
+	lines := make([]TrialBalanceLine, 0, len(accounts))
+	var totalDebit, totalCredit models.Money
+	for _, account := range accounts {
+		activity := period[account.Code]
+		closing := opening[account.Code] + normalBalance(account.Type, activity)
+
+		line := TrialBalanceLine{
+			AccountCode:    account.Code,
+			Name:           account.Name,
+			Type:           account.Type,
+			GIFICode:       account.GIFICode,
+			OpeningBalance: opening[account.Code],
+			PeriodDebit:    activity.Debit,
+			PeriodCredit:   activity.Credit,
+			ClosingBalance: closing,
+		}
+		if line.OpeningBalance == 0 && line.PeriodDebit == 0 && line.PeriodCredit == 0 && !includeZero {
+			continue
+		}
+
+		// Place the closing balance on its debit or credit side
+		if isDebitNormal(account.Type) == (closing >= 0) {
+			line.Debit = closing.Abs()
+		} else {
+			line.Credit = closing.Abs()
+		}
+		totalDebit += line.Debit
+		totalCredit += line.Credit
+
+		lines = append(lines, line)
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"company_id":   companyID,
+			"start_date":   startDate.Format("2006-01-02"),
+			"end_date":     endDate.Format("2006-01-02"),
+			"lines":        lines,
+			"total_debit":  totalDebit,
+			"total_credit": totalCredit,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"account_code", "name", "type", "gifi_code", "opening_balance", "period_debit", "period_credit", "closing_balance", "debit", "credit"})
+	for _, line := range lines {
+		writer.Write([]string{
+			line.AccountCode, line.Name, line.Type, line.GIFICode,
+			line.OpeningBalance.String(),
+			line.PeriodDebit.String(),
+			line.PeriodCredit.String(),
+			line.ClosingBalance.String(),
+			line.Debit.String(),
+			line.Credit.String(),
+		})
+	}
+	writer.Write([]string{"", "Total", "", "", "", "", "", "", totalDebit.String(), totalCredit.String()})
+	writer.Flush()
+
+	filename := fmt.Sprintf("Trial_Balance_%s_%s.csv", startDate.Format("20060102"), endDate.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// GetGeneralLedger lists every posting to each account over a date range with running
+// balances and the source document of each posting. Filter to one account with
+// account_code. Use format=csv for a CSV file.
+func GetGeneralLedger(c *gin.Context) {
+	companyID, startDate, endDate, ok := parseLedgerReportQuery(c)
+	if !ok {
+		return
+	}
+	accountCode := c.Query("account_code")
+
+	var accounts []models.Account
+	query := database.DB.Where("company_id = ?", companyID)
+	if accountCode != "" {
+		query = query.Where("code = ?", accountCode)
+	}
+	if err := query.Order("code ASC").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+	if accountCode != "" && len(accounts) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	// Retained earnings needs every account to roll forward prior income
+	var allAccounts []models.Account
+	if err := database.DB.Where("company_id = ?", companyID).Find(&allAccounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+	opening, _, err := ledgerPeriodBalances(companyID, allAccounts, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate account balances"})
+		return
+	}
+
+	// Get postings in the period
+	var rows []generalLedgerRow
+	rowQuery := database.DB.Table("journal_lines").
+		Select("journal_lines.account_code, journal_lines.journal_entry_id, journal_entries.entry_date, journal_entries.description, journal_entries.source_type, journal_entries.source_id, journal_lines.memo, journal_lines.debit, journal_lines.credit").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_lines.company_id = ? AND journal_entries.entry_date >= ? AND journal_entries.entry_date <= ?", companyID, startDate, endDate)
+	if accountCode != "" {
+		rowQuery = rowQuery.Where("journal_lines.account_code = ?", accountCode)
+	}
+	if err := rowQuery.Order("journal_lines.account_code ASC, journal_entries.entry_date ASC, journal_entries.id ASC, journal_lines.id ASC").Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ledger postings"})
+		return
+	}
+
+	rowsByAccount := make(map[string][]generalLedgerRow)
+	for _, row := range rows {
+		rowsByAccount[row.AccountCode] = append(rowsByAccount[row.AccountCode], row)
+	}
+
+	ledger := make([]GeneralLedgerAccount, 0, len(accounts))
+	for _, account := range accounts {
+		postings := rowsByAccount[account.Code]
+		if len(postings) == 0 && opening[account.Code] == 0 && accountCode == "" {
+			continue
+		}
+
+		entry := GeneralLedgerAccount{
+			AccountCode:    account.Code,
+			Name:           account.Name,
+			Type:           account.Type,
+			OpeningBalance: opening[account.Code],
+			Lines:          make([]GeneralLedgerLine, 0, len(postings)),
+		}
+
+		balance := entry.OpeningBalance
+		for _, row := range postings {
+			balance = balance + normalBalance(account.Type, accountActivity{Debit: row.Debit, Credit: row.Credit})
+			entry.PeriodDebit += row.Debit
+			entry.PeriodCredit += row.Credit
+			entry.Lines = append(entry.Lines, GeneralLedgerLine{
+				JournalEntryID: row.JournalEntryID,
+				EntryDate:      row.EntryDate,
+				Description:    row.Description,
+				SourceType:     row.SourceType,
+				SourceID:       row.SourceID,
+				Memo:           row.Memo,
+				Debit:          row.Debit,
+				Credit:         row.Credit,
+				Balance:        balance,
+			})
+		}
+		entry.ClosingBalance = balance
+
+		ledger = append(ledger, entry)
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"company_id": companyID,
+			"start_date": startDate.Format("2006-01-02"),
+			"end_date":   endDate.Format("2006-01-02"),
+			"accounts":   ledger,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"account_code", "account_name", "date", "journal_entry_id", "description", "source_type", "source_id", "debit", "credit", "balance"})
+	for _, account := range ledger {
+		writer.Write([]string{account.AccountCode, account.Name, startDate.Format("2006-01-02"), "", "Opening balance", "", "", "", "", account.OpeningBalance.String()})
+		for _, line := range account.Lines {
+			writer.Write([]string{
+				account.AccountCode, account.Name,
+				line.EntryDate.Format("2006-01-02"),
+				strconv.FormatUint(uint64(line.JournalEntryID), 10),
+				line.Description, line.SourceType,
+				strconv.FormatUint(uint64(line.SourceID), 10),
+				line.Debit.String(),
+				line.Credit.String(),
+				line.Balance.String(),
+			})
+		}
+		writer.Write([]string{account.AccountCode, account.Name, endDate.Format("2006-01-02"), "", "Closing balance", "", "", account.PeriodDebit.String(), account.PeriodCredit.String(), account.ClosingBalance.String()})
+	}
+	writer.Flush()
+
+	filename := fmt.Sprintf("General_Ledger_%s_%s.csv", startDate.Format("20060102"), endDate.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
@@ -124,11 +124,37 @@ func CreateOwnerPayment(c *gin.Context) {
 		CompanyID:   req.CompanyID,
 	}
 
-	if err := database.DB.Create(&ownerPayment).Error; err != nil {
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, ownerPayment.CompanyID, ownerPayment.PaymentDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Create(&ownerPayment).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create owner payment"})
 		return
 	}
 
+	// Post to the general ledger
+	if err := syncSourceJournal(tx, sourceOwnerPayment, ownerPayment.ID, ownerPaymentJournalEntries(&ownerPayment)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post owner payment to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load owner payment with company
 	if err := database.DB.Preload("Company").First(&ownerPayment, ownerPayment.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load owner payment data"})
@@ -168,6 +194,11 @@ func UpdateOwnerPayment(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, ownerPayment.CompanyID, ownerPayment.PaymentDate) {
+		return
+	}
+
 	// Update fields
 	updates := make(map[string]interface{})
 	if req.Description != nil {
@@ -194,11 +225,45 @@ func UpdateOwnerPayment(c *gin.Context) {
 		updates["notes"] = req.Notes
 	}
 
-	if err := database.DB.Model(&ownerPayment).Updates(updates).Error; err != nil {
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if err := tx.Model(&ownerPayment).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update owner payment"})
 		return
 	}
 
+	// Reload the updated record
+	if err := tx.First(&ownerPayment, ownerPayment.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload owner payment"})
+		return
+	}
+
+	// Reject changes that move the record into a closed accounting period
+	if rejectClosedPeriod(c, ownerPayment.CompanyID, ownerPayment.PaymentDate) {
+		tx.Rollback()
+		return
+	}
+
+	// Repost to the general ledger
+	if err := syncSourceJournal(tx, sourceOwnerPayment, ownerPayment.ID, ownerPaymentJournalEntries(&ownerPayment)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post owner payment to the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	// Load updated owner payment with company
 	if err := database.DB.Preload("Company").First(&ownerPayment, ownerPayment.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated owner payment data"})
@@ -219,12 +284,38 @@ func DeleteOwnerPayment(c *gin.Context) {
 		return
 	}
 
+	// Reject changes to records dated in a closed accounting period
+	if rejectClosedPeriod(c, ownerPayment.CompanyID, ownerPayment.PaymentDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
 	// Soft delete
-	if err := database.DB.Delete(&ownerPayment).Error; err != nil {
+	if err := tx.Delete(&ownerPayment).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete owner payment"})
 		return
 	}
 
+	// Reverse the owner payment's ledger postings
+	if err := reverseSourceJournal(tx, sourceOwnerPayment, ownerPayment.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse owner payment in the general ledger"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Owner payment deleted successfully"})
 }
 
@@ -242,6 +333,13 @@ func GetOwnerPaymentStats(c *gin.Context) {
 		return
 	}
 
+	var company models.Company
+	if err := database.DB.First(&company, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+	currentPeriod := fiscalPeriodForDate(company, time.Now())
+
 	// Parse date range
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
@@ -254,9 +352,8 @@ func GetOwnerPaymentStats(c *gin.Context) {
 			return
 		}
 	} else {
-		// Default to start of current year
-		now := time.Now()
-		startDate = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		// Default to start of current fiscal year
+		startDate = currentPeriod.StartDate
 	}
 
 	if endDateStr != "" {
@@ -266,9 +363,8 @@ func GetOwnerPaymentStats(c *gin.Context) {
 			return
 		}
 	} else {
-		// Default to end of current year
-		now := time.Now()
-		endDate = time.Date(now.Year(), 12, 31, 23, 59, 59, 0, now.Location())
+		// Default to end of current fiscal year
+		endDate = currentPeriod.EndOfDay()
 	}
 
 	// Get owner payments in date range
@@ -280,10 +376,10 @@ func GetOwnerPaymentStats(c *gin.Context) {
 	}
 
 	// Calculate statistics
-	totalPaid := 0.0
-	reimbursementTotal := 0.0
-	loanRepaymentTotal := 0.0
-	otherTotal := 0.0
+	var totalPaid models.Money
+	var reimbursementTotal models.Money
+	var loanRepaymentTotal models.Money
+	var otherTotal models.Money
 
 	for _, payment := range ownerPayments {
 		totalPaid += payment.Amount
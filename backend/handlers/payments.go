@@ -0,0 +1,607 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// invoiceBalance returns the balance due on an invoice after payments and credit notes
+func invoiceBalance(invoice *models.Invoice) models.Money {
+	return invoice.Total - invoice.AmountPaid - invoice.AmountCredited
+}
+
+// invoiceStatus returns the status of an invoice from the payments and credit notes
+// applied to it and its due date. Invoices with a balance past their due date are overdue,
+// even when partly paid.
+func invoiceStatus(invoice *models.Invoice) string {
+	settled := invoice.AmountPaid + invoice.AmountCredited
+	switch {
+	case invoice.Status == "draft" || invoice.Status == "cancelled":
+		return invoice.Status
+	case settled > 0 && settled >= invoice.Total:
+		return "paid"
+	case invoice.DueDate.Before(currentDate()):
+		return "overdue"
+	case settled > 0:
+		return "partially_paid"
+	default:
+		return "sent"
+	}
+}
+
+// refreshInvoiceBalances recalculates the amounts paid and credited and the status of
+// invoices from the payments and credit notes applied to them. Fully paid invoices take
+// the date of the last one as their paid date and the average rate they were settled at
+// as their paid exchange rate.
+func refreshInvoiceBalances(tx *gorm.DB, invoiceIDs []uint) error {
+	for _, invoiceID := range invoiceIDs {
+		var invoice models.Invoice
+		if err := tx.First(&invoice, invoiceID).Error; err != nil {
+			return err
+		}
+
+		var allocations []models.PaymentAllocation
+		if err := tx.Preload("Payment").Where("invoice_id = ?", invoiceID).Find(&allocations).Error; err != nil {
+			return err
+		}
+		var applications []models.CreditNoteApplication
+		if err := tx.Preload("CreditNote").Where("invoice_id = ?", invoiceID).Find(&applications).Error; err != nil {
+			return err
+		}
+
+		invoice.AmountPaid, invoice.AmountCredited = 0, 0
+		var received models.Money
+		var lastDate time.Time
+		for _, allocation := range allocations {
+			invoice.AmountPaid += allocation.Amount
+			received += inCAD(allocation.Amount, allocation.Payment.ExchangeRate)
+			if allocation.AppliedDate.After(lastDate) {
+				lastDate = allocation.AppliedDate
+			}
+		}
+		for _, application := range applications {
+			invoice.AmountCredited += application.Amount
+			received += inCAD(application.Amount, application.CreditNote.ExchangeRate)
+			if application.AppliedDate.After(lastDate) {
+				lastDate = application.AppliedDate
+			}
+		}
+
+		status := invoiceStatus(&invoice)
+		updates := map[string]interface{}{
+			"amount_paid":        invoice.AmountPaid,
+			"amount_credited":    invoice.AmountCredited,
+			"status":             status,
+			"paid_date":          nil,
+			"paid_exchange_rate": nil,
+		}
+		if status == "paid" {
+			updates["paid_date"] = lastDate
+			updates["paid_exchange_rate"] = received.Float64() / invoice.Total.Float64()
+		}
+		if err := tx.Model(&invoice).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejectInvoiceWithPayments responds with 409 Conflict and returns true when payments or
+// credit notes are applied to an invoice or it has been credited, so its amounts and
+// status can no longer change
+func rejectInvoiceWithPayments(c *gin.Context, invoice *models.Invoice) bool {
+	var payments, credits, creditNotes int64
+	if err := database.DB.Model(&models.PaymentAllocation{}).Where("invoice_id = ?", invoice.ID).Count(&payments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check invoice payments"})
+		return true
+	}
+	if err := database.DB.Model(&models.CreditNoteApplication{}).Where("invoice_id = ?", invoice.ID).Count(&credits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check invoice credits"})
+		return true
+	}
+	if err := database.DB.Model(&models.CreditNote{}).Where("invoice_id = ?", invoice.ID).Count(&creditNotes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check invoice credit notes"})
+		return true
+	}
+	if payments+credits+creditNotes > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Invoice has payments or credit notes; delete them first"})
+		return true
+	}
+	return false
+}
+
+// findOpenInvoice finds an invoice of a client that can take amount in a currency: it must
+// be sent, overdue or partially paid with at least that balance. The invoice is locked for
+// update in tx, so its balance cannot change before the transaction ends. It responds with
+// 400 and returns false when it cannot.
+func findOpenInvoice(c *gin.Context, tx *gorm.DB, companyID, clientID uint, currency string, invoiceID uint, amount models.Money) (*models.Invoice, bool) {
+	var invoice models.Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("company_id = ? AND client_id = ?", companyID, clientID).
+		First(&invoice, invoiceID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invoice %d not found for the client", invoiceID)})
+		return nil, false
+	}
+	if invoice.Status != "sent" && invoice.Status != "overdue" && invoice.Status != "partially_paid" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invoice %s is %s", invoice.InvoiceNumber, invoice.Status)})
+		return nil, false
+	}
+	if invoice.Currency != currency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invoice %s is in %s, not %s", invoice.InvoiceNumber, invoice.Currency, currency)})
+		return nil, false
+	}
+	if balance := invoiceBalance(&invoice); amount > balance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Amount of %s on invoice %s is more than its balance of %s",
+			amount, invoice.InvoiceNumber, balance)})
+		return nil, false
+	}
+	return &invoice, true
+}
+
+// buildPaymentAllocations builds the allocations of a payment to invoices of its client
+// on a date. Each invoice must be able to take its allocation (see findOpenInvoice), and
+// the allocations together may not be more than the amount available. Invoices are locked
+// in tx in ID order, so concurrent payments cannot deadlock. It responds with 400 and
+// returns false when they are not.
+func buildPaymentAllocations(c *gin.Context, tx *gorm.DB, payment *models.Payment, requests []models.PaymentAllocationRequest, appliedDate time.Time, available models.Money) ([]models.PaymentAllocation, []uint, bool) {
+	requests = append([]models.PaymentAllocationRequest{}, requests...)
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].InvoiceID < requests[j].InvoiceID })
+
+	allocations := make([]models.PaymentAllocation, 0, len(requests))
+	invoiceIDs := make([]uint, 0, len(requests))
+	var total models.Money
+	for _, req := range requests {
+		for _, invoiceID := range invoiceIDs {
+			if invoiceID == req.InvoiceID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invoice %d is listed more than once", invoiceID)})
+				return nil, nil, false
+			}
+		}
+		invoiceIDs = append(invoiceIDs, req.InvoiceID)
+
+		if _, ok := findOpenInvoice(c, tx, payment.CompanyID, payment.ClientID, payment.Currency, req.InvoiceID, req.Amount); !ok {
+			return nil, nil, false
+		}
+
+		allocations = append(allocations, models.PaymentAllocation{
+			InvoiceID:   req.InvoiceID,
+			Amount:      req.Amount,
+			AppliedDate: appliedDate,
+		})
+		total += req.Amount
+	}
+
+	if total > available {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Allocations of %s are more than the %s available", total, available)})
+		return nil, nil, false
+	}
+	return allocations, invoiceIDs, true
+}
+
+// loadPayment loads a payment with its client, statement line and allocations
+func loadPayment(db *gorm.DB, payment *models.Payment, id interface{}) error {
+	return db.Preload("Client").Preload("BankTransaction").Preload("Allocations.Invoice").First(payment, id).Error
+}
+
+// recordPayment saves a new payment with its allocations, posts it to the general ledger
+// and updates the invoices it pays
+func recordPayment(tx *gorm.DB, payment *models.Payment, invoiceIDs []uint) error {
+	payment.UnappliedAmount = payment.Amount
+	for _, allocation := range payment.Allocations {
+		payment.UnappliedAmount -= allocation.Amount
+	}
+	if err := tx.Create(payment).Error; err != nil {
+		return err
+	}
+	if err := loadPayment(tx, payment, payment.ID); err != nil {
+		return err
+	}
+	if err := syncSourceJournal(tx, sourcePayment, payment.ID, paymentJournalEntries(payment)); err != nil {
+		return err
+	}
+	return refreshInvoiceBalances(tx, invoiceIDs)
+}
+
+// ListPayments lists payments received, latest first. Use unapplied=true for payments
+// with client credit left to apply.
+func ListPayments(c *gin.Context) {
+	var payments []models.Payment
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	// Get filter parameters
+	companyID := c.Query("company_id")
+	clientID := c.Query("client_id")
+	invoiceID := c.Query("invoice_id")
+	unapplied := c.Query("unapplied")
+
+	query := database.DB.Preload("Client").Preload("Allocations").Model(&models.Payment{})
+
+	// Apply filters
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+	if invoiceID != "" {
+		query = query.Where("id IN (?)",
+			database.DB.Model(&models.PaymentAllocation{}).Select("payment_id").Where("invoice_id = ?", invoiceID))
+	}
+	if unapplied == "true" {
+		query = query.Where("unapplied_amount > 0")
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count payments"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("payment_date DESC, id DESC").Find(&payments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments"})
+		return
+	}
+
+	response := gin.H{
+		"data":       payments,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreatePayment records a payment from a client, allocated to any of the client's open
+// invoices. The part of the payment not allocated becomes a client credit. When the
+// payment names a statement line, it is posted to that bank account's ledger account and
+// matched to the line, whose amount must equal the payment in CAD.
+func CreatePayment(c *gin.Context) {
+	var req models.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse payment date
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment_date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Verify client belongs to the company
+	var client models.Client
+	if err := database.DB.Where("company_id = ?", req.CompanyID).First(&client, req.ClientID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
+		return
+	}
+
+	// Resolve the exchange rate on the payment date
+	currency := normalizeCurrency(req.Currency)
+	exchangeRate, ok := resolveExchangeRate(c, database.DB, currency, paymentDate, req.ExchangeRate)
+	if !ok {
+		return
+	}
+
+	payment := models.Payment{
+		ClientID:     req.ClientID,
+		PaymentDate:  paymentDate,
+		Amount:       req.Amount,
+		Currency:     currency,
+		ExchangeRate: exchangeRate,
+		Method:       req.Method,
+		Reference:    emptyToNil(req.Reference),
+		Notes:        emptyToNil(req.Notes),
+		AccountCode:  accountCash,
+		CompanyID:    req.CompanyID,
+	}
+	if payment.Method == "" {
+		payment.Method = "other"
+	}
+
+	// Verify the statement line is an unmatched deposit of the payment
+	var transaction models.BankTransaction
+	if req.BankTransactionID != nil {
+		if err := database.DB.Preload("BankAccount").Where("company_id = ?", req.CompanyID).First(&transaction, *req.BankTransactionID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bank transaction not found"})
+			return
+		}
+		if transaction.Status == "matched" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Bank transaction is already matched"})
+			return
+		}
+		if received := inCAD(payment.Amount, payment.ExchangeRate); transaction.Amount != received {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Statement amount %s does not match the payment amount %s", transaction.Amount, received)})
+			return
+		}
+		payment.AccountCode = transaction.BankAccount.AccountCode
+		payment.BankTransactionID = &transaction.ID
+	}
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, payment.CompanyID, payment.PaymentDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Verify each invoice can take its allocation
+	allocations, invoiceIDs, ok := buildPaymentAllocations(c, tx, &payment, req.Allocations, paymentDate, payment.Amount)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+	payment.Allocations = allocations
+
+	// Create payment, post it and update the invoices it pays
+	if err := recordPayment(tx, &payment, invoiceIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	// Match the statement line to the payment
+	if payment.BankTransactionID != nil {
+		if err := markBankTransactionMatched(tx, &transaction, sourcePayment, payment.ID, c.GetUint("user_id")); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match bank transaction"})
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load payment with related data
+	if err := loadPayment(database.DB, &payment, payment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payment data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// GetPayment retrieves a payment by ID with the invoices it pays
+func GetPayment(c *gin.Context) {
+	paymentID := c.Param("id")
+
+	var payment models.Payment
+	if err := loadPayment(database.DB, &payment, paymentID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// ApplyPaymentCredit applies the unallocated part of a payment, a client credit, to
+// invoices of the client on a date (applied_date, today by default)
+func ApplyPaymentCredit(c *gin.Context) {
+	paymentID := c.Param("id")
+
+	var req models.ApplyPaymentCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find payment
+	var payment models.Payment
+	if err := database.DB.First(&payment, paymentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	if payment.UnappliedAmount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Payment has no credit left to apply"})
+		return
+	}
+
+	// Parse applied date
+	appliedDate := currentDate()
+	if req.AppliedDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.AppliedDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid applied_date format. Use YYYY-MM-DD"})
+			return
+		}
+		appliedDate = parsed
+	}
+	if appliedDate.Before(payment.PaymentDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "applied_date cannot be before the payment date"})
+		return
+	}
+
+	// Reject records dated in a closed accounting period
+	if rejectClosedPeriod(c, payment.CompanyID, appliedDate) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Lock the payment so concurrent requests cannot apply the same credit twice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, payment.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock payment"})
+		return
+	}
+	if payment.UnappliedAmount == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Payment has no credit left to apply"})
+		return
+	}
+
+	// Verify each invoice can take its allocation
+	allocations, invoiceIDs, ok := buildPaymentAllocations(c, tx, &payment, req.Allocations, appliedDate, payment.UnappliedAmount)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	// Create the allocations and reduce the credit left
+	applied := payment.UnappliedAmount
+	for i := range allocations {
+		allocations[i].PaymentID = payment.ID
+		if err := tx.Create(&allocations[i]).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment allocation"})
+			return
+		}
+		applied -= allocations[i].Amount
+	}
+	if err := tx.Model(&payment).Update("unapplied_amount", applied).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update payment"})
+		return
+	}
+
+	// Repost to the general ledger
+	if err := loadPayment(tx, &payment, payment.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload payment"})
+		return
+	}
+	if err := syncSourceJournal(tx, sourcePayment, payment.ID, paymentJournalEntries(&payment)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post payment to the general ledger"})
+		return
+	}
+
+	// Update the amount paid on each invoice
+	if err := refreshInvoiceBalances(tx, invoiceIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoices"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load payment with related data
+	if err := loadPayment(database.DB, &payment, payment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payment data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// DeletePayment deletes a payment, reopening the invoices it paid and unmatching its
+// statement line
+func DeletePayment(c *gin.Context) {
+	paymentID := c.Param("id")
+
+	// Find payment
+	var payment models.Payment
+	if err := database.DB.Preload("Allocations").First(&payment, paymentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	// Reject changes to records dated in a closed accounting period
+	dates := []time.Time{payment.PaymentDate}
+	invoiceIDs := make([]uint, 0, len(payment.Allocations))
+	for _, allocation := range payment.Allocations {
+		dates = append(dates, allocation.AppliedDate)
+		invoiceIDs = append(invoiceIDs, allocation.InvoiceID)
+	}
+	if rejectClosedPeriod(c, payment.CompanyID, dates...) {
+		return
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	// Remove the allocations and soft delete the payment
+	if err := tx.Where("payment_id = ?", payment.ID).Delete(&models.PaymentAllocation{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete payment allocations"})
+		return
+	}
+	if err := tx.Delete(&payment).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete payment"})
+		return
+	}
+
+	// Reverse the payment's ledger postings
+	if err := reverseSourceJournal(tx, sourcePayment, payment.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse payment in the general ledger"})
+		return
+	}
+
+	// Update the amount paid on each invoice
+	if err := refreshInvoiceBalances(tx, invoiceIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoices"})
+		return
+	}
+
+	// Unmatch the statement lines matched to the payment
+	if err := tx.Model(&models.BankTransaction{}).
+		Where("matched_source_type = ? AND matched_source_id = ?", sourcePayment, payment.ID).
+		Updates(map[string]interface{}{
+			"status":              "unmatched",
+			"matched_source_type": nil,
+			"matched_source_id":   nil,
+			"matched_at":          nil,
+			"matched_by_id":       nil,
+		}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmatch bank transaction"})
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payment deleted successfully"})
+}
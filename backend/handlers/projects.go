@@ -0,0 +1,583 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findCompanyProject loads a project of a company. It responds with 400 and returns false
+// when there is none, for requests that tag a record with a project.
+func findCompanyProject(c *gin.Context, projectID, companyID uint) (*models.Project, bool) {
+	var project models.Project
+	if err := database.DB.Where("company_id = ?", companyID).First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+		return nil, false
+	}
+	return &project, true
+}
+
+// isHourUnit reports whether an invoice line's unit is hours
+func isHourUnit(unit *string) bool {
+	if unit == nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(*unit)) {
+	case "h", "hr", "hrs", "hour", "hours":
+		return true
+	}
+	return false
+}
+
+// parseProjectDate parses an optional project date; an empty string clears it
+func parseProjectDate(c *gin.Context, value *string, field string) (*time.Time, bool) {
+	if value == nil || *value == "" {
+		return nil, true
+	}
+	date, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + field + " format. Use YYYY-MM-DD"})
+		return nil, false
+	}
+	return &date, true
+}
+
+// ListProjects lists the projects of a company
+func ListProjects(c *gin.Context) {
+	var projects []models.Project
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	// Get filter parameters
+	search := c.Query("search")
+	companyID := c.Query("company_id")
+	clientID := c.Query("client_id")
+	status := c.Query("status")
+
+	query := database.DB.Preload("Client").Model(&models.Project{})
+
+	// Apply filters
+	if search != "" {
+		query = query.Where("name ILIKE ? OR code ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count projects"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("name").Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	response := gin.H{
+		"data":       projects,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateProject creates a project, optionally for a client
+func CreateProject(c *gin.Context) {
+	var req models.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse start and end dates
+	startDate, ok := parseProjectDate(c, req.StartDate, "start date")
+	if !ok {
+		return
+	}
+	endDate, ok := parseProjectDate(c, req.EndDate, "end date")
+	if !ok {
+		return
+	}
+	if startDate != nil && endDate != nil && endDate.Before(*startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be on or after the start date"})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Verify client belongs to the company
+	if req.ClientID != nil {
+		var client models.Client
+		if err := database.DB.Where("company_id = ?", req.CompanyID).First(&client, *req.ClientID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
+			return
+		}
+	}
+
+	if req.Status == "" {
+		req.Status = "active"
+	}
+
+	project := models.Project{
+		Name:        req.Name,
+		Code:        emptyToNil(req.Code),
+		Description: req.Description,
+		ClientID:    req.ClientID,
+		Status:      req.Status,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		CompanyID:   req.CompanyID,
+	}
+
+	if err := database.DB.Create(&project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+
+	// Load project with related data
+	if err := database.DB.Preload("Client").First(&project, project.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load project data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// GetProject retrieves a project by ID
+func GetProject(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var project models.Project
+	if err := database.DB.Preload("Client").First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// UpdateProject updates a project
+func UpdateProject(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var req models.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := database.DB.First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Code != nil {
+		updates["code"] = emptyToNil(req.Code)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.ClientID != nil {
+		// Verify client belongs to the company
+		if *req.ClientID != 0 {
+			var client models.Client
+			if err := database.DB.Where("company_id = ?", project.CompanyID).First(&client, *req.ClientID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
+				return
+			}
+		}
+		updates["client_id"] = zeroToNil(req.ClientID)
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+	if req.StartDate != nil {
+		startDate, ok := parseProjectDate(c, req.StartDate, "start date")
+		if !ok {
+			return
+		}
+		project.StartDate = startDate
+		updates["start_date"] = startDate
+	}
+	if req.EndDate != nil {
+		endDate, ok := parseProjectDate(c, req.EndDate, "end date")
+		if !ok {
+			return
+		}
+		project.EndDate = endDate
+		updates["end_date"] = endDate
+	}
+	if project.StartDate != nil && project.EndDate != nil && project.EndDate.Before(*project.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be on or after the start date"})
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := database.DB.Model(&models.Project{}).Where("id = ?", project.ID).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+			return
+		}
+	}
+
+	// Load updated project with related data
+	var updated models.Project
+	if err := database.DB.Preload("Client").First(&updated, project.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated project data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteProject deletes a project that no records are tagged with
+func DeleteProject(c *gin.Context) {
+	projectID := c.Param("id")
+
+	// Find project
+	var project models.Project
+	if err := database.DB.First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	// Check for tagged records
+	for _, model := range []interface{}{&models.Invoice{}, &models.InvoiceItem{}, &models.Expense{}, &models.Bill{}, &models.BillItem{}, &models.IncomeEntry{}, &models.CapitalAsset{}} {
+		var count int64
+		if err := database.DB.Model(model).Where("project_id = ?", project.ID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check project records"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete project with tagged records; archive it instead"})
+			return
+		}
+	}
+
+	// Soft delete project
+	if err := database.DB.Delete(&project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// ProjectExpenseCategory is a project's direct expenses in one expense category
+type ProjectExpenseCategory struct {
+	CategoryID uint         `json:"category_id"`
+	Name       string       `json:"name"`
+	Amount     models.Money `json:"amount"`
+}
+
+// ProjectProfitAndLoss is the profitability of a project in CAD
+type ProjectProfitAndLoss struct {
+	ProjectID          uint                     `json:"project_id"`
+	ProjectName        string                   `json:"project_name"`
+	ProjectCode        *string                  `json:"project_code,omitempty"`
+	ClientID           *uint                    `json:"client_id,omitempty"`
+	Status             string                   `json:"status"`
+	InvoicedRevenue    models.Money             `json:"invoiced_revenue"` // Lines of sent, paid and overdue invoices less credit notes, before HST
+	OtherRevenue       models.Money             `json:"other_revenue"`    // Client and other income entries
+	Revenue            models.Money             `json:"revenue"`
+	DirectExpenses     models.Money             `json:"direct_expenses"` // Expenses and lines of bills that are not void, before HST
+	ExpensesByCategory []ProjectExpenseCategory `json:"expenses_by_category"`
+	Depreciation       models.Money             `json:"depreciation"` // CCA on the project's capital assets
+	Margin             models.Money             `json:"margin"`
+	MarginPercent      *float64                 `json:"margin_percent"` // Margin as a percent of revenue; null without revenue
+	Hours              *float64                 `json:"hours"`          // Invoiced hours; null when no line is billed by the hour
+	RevenuePerHour     *models.Money            `json:"revenue_per_hour"`
+}
+
+// projectProfitAndLoss calculates the P&L of projects between two optional dates. Revenue
+// is recognized on the issue date of invoices that are not draft or cancelled, less credit
+// notes on their issue date, and bills are expensed on their bill date; a line's project
+// overrides its invoice's or bill's, and a credit note line takes the project of the
+// invoice line it credits.
+func projectProfitAndLoss(db *gorm.DB, projects []models.Project, startDate, endDate *time.Time) ([]ProjectProfitAndLoss, error) {
+	results := make([]ProjectProfitAndLoss, len(projects))
+	byID := make(map[uint]*ProjectProfitAndLoss, len(projects))
+	projectIDs := make([]uint, len(projects))
+	for i, project := range projects {
+		results[i] = ProjectProfitAndLoss{
+			ProjectID:          project.ID,
+			ProjectName:        project.Name,
+			ProjectCode:        project.Code,
+			ClientID:           project.ClientID,
+			Status:             project.Status,
+			ExpensesByCategory: []ProjectExpenseCategory{},
+		}
+		byID[project.ID] = &results[i]
+		projectIDs[i] = project.ID
+	}
+	if len(projects) == 0 {
+		return results, nil
+	}
+
+	inRange := func(query *gorm.DB, column string) *gorm.DB {
+		if startDate != nil {
+			query = query.Where(column+" >= ?", *startDate)
+		}
+		if endDate != nil {
+			query = query.Where(column+" <= ?", *endDate)
+		}
+		return query
+	}
+
+	// Invoice lines
+	var lines []struct {
+		ProjectID    uint
+		Quantity     float64
+		Unit         *string
+		Total        models.Money
+		ExchangeRate float64
+	}
+	lineProject := "COALESCE(invoice_items.project_id, invoices.project_id)"
+	query := db.Table("invoice_items").
+		Select(lineProject+" AS project_id, invoice_items.quantity, invoice_items.unit, invoice_items.total, invoices.exchange_rate").
+		Joins("JOIN invoices ON invoices.id = invoice_items.invoice_id").
+		Where("invoice_items.deleted_at IS NULL AND invoices.deleted_at IS NULL").
+		Where("invoices.status NOT IN ?", []string{"draft", "cancelled"}).
+		Where(lineProject+" IN ?", projectIDs)
+	if err := inRange(query, "invoices.issue_date").Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+	hours := make(map[uint]float64)
+	for _, line := range lines {
+		result := byID[line.ProjectID]
+		result.InvoicedRevenue += inCAD(line.Total, line.ExchangeRate)
+		if isHourUnit(line.Unit) {
+			hours[line.ProjectID] += line.Quantity
+		}
+	}
+
+	// Credit note lines, reversing revenue of the invoice line they credit
+	var credits []struct {
+		ProjectID    uint
+		Total        models.Money
+		ExchangeRate float64
+	}
+	query = db.Table("credit_note_items").
+		Select(lineProject+" AS project_id, credit_note_items.total, credit_notes.exchange_rate").
+		Joins("JOIN credit_notes ON credit_notes.id = credit_note_items.credit_note_id").
+		Joins("JOIN invoices ON invoices.id = credit_notes.invoice_id").
+		Joins("LEFT JOIN invoice_items ON invoice_items.id = credit_note_items.invoice_item_id AND invoice_items.deleted_at IS NULL").
+		Where("credit_notes.deleted_at IS NULL").
+		Where(lineProject+" IN ?", projectIDs)
+	if err := inRange(query, "credit_notes.issue_date").Scan(&credits).Error; err != nil {
+		return nil, err
+	}
+	for _, credit := range credits {
+		byID[credit.ProjectID].InvoicedRevenue -= inCAD(credit.Total, credit.ExchangeRate)
+	}
+
+	// Income entries; owner capital is not revenue
+	var incomeEntries []models.IncomeEntry
+	query = db.Where("project_id IN ? AND income_type <> ?", projectIDs, "capital")
+	if err := inRange(query, "income_date").Find(&incomeEntries).Error; err != nil {
+		return nil, err
+	}
+	for _, incomeEntry := range incomeEntries {
+		byID[*incomeEntry.ProjectID].OtherRevenue += inCAD(incomeEntry.Amount, incomeEntry.ExchangeRate)
+	}
+
+	// Direct expenses by category
+	var expenses []models.Expense
+	query = db.Preload("Category").Where("project_id IN ?", projectIDs)
+	if err := inRange(query, "expense_date").Find(&expenses).Error; err != nil {
+		return nil, err
+	}
+	categories := make(map[uint]map[uint]*ProjectExpenseCategory)
+	addExpense := func(projectID, categoryID uint, name string, amount models.Money) {
+		byID[projectID].DirectExpenses += amount
+
+		if categories[projectID] == nil {
+			categories[projectID] = make(map[uint]*ProjectExpenseCategory)
+		}
+		category, ok := categories[projectID][categoryID]
+		if !ok {
+			category = &ProjectExpenseCategory{CategoryID: categoryID, Name: name}
+			categories[projectID][categoryID] = category
+		}
+		category.Amount += amount
+	}
+	for _, expense := range expenses {
+		addExpense(*expense.ProjectID, expense.CategoryID, expense.Category.Name, inCAD(expense.Amount, expense.ExchangeRate))
+	}
+
+	// Bill lines, which are in CAD
+	var billLines []struct {
+		ProjectID  uint
+		CategoryID uint
+		Category   string
+		Amount     models.Money
+	}
+	billLineProject := "COALESCE(bill_items.project_id, bills.project_id)"
+	query = db.Table("bill_items").
+		Select(billLineProject+" AS project_id, bill_items.category_id, expense_categories.name AS category, bill_items.amount").
+		Joins("JOIN bills ON bills.id = bill_items.bill_id").
+		Joins("LEFT JOIN expense_categories ON expense_categories.id = bill_items.category_id").
+		Where("bills.deleted_at IS NULL AND bills.status <> ?", "void").
+		Where(billLineProject+" IN ?", projectIDs)
+	if err := inRange(query, "bills.bill_date").Scan(&billLines).Error; err != nil {
+		return nil, err
+	}
+	for _, line := range billLines {
+		addExpense(line.ProjectID, line.CategoryID, line.Category, line.Amount)
+	}
+
+	// Depreciation of the project's capital assets
+	var depreciation []struct {
+		ProjectID uint
+		Amount    models.Money
+	}
+	query = db.Table("depreciation_entries").
+		Select("capital_assets.project_id, SUM(depreciation_entries.depreciation_amount) AS amount").
+		Joins("JOIN capital_assets ON capital_assets.id = depreciation_entries.capital_asset_id").
+		Where("depreciation_entries.deleted_at IS NULL AND capital_assets.deleted_at IS NULL").
+		Where("capital_assets.project_id IN ?", projectIDs)
+	if err := inRange(query, "depreciation_entries.entry_date").Group("capital_assets.project_id").Scan(&depreciation).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range depreciation {
+		byID[row.ProjectID].Depreciation = row.Amount
+	}
+
+	for i := range results {
+		result := &results[i]
+		result.Revenue = result.InvoicedRevenue + result.OtherRevenue
+		result.Margin = result.Revenue - result.DirectExpenses - result.Depreciation
+		if result.Revenue != 0 {
+			percent := math.Round(float64(result.Margin)/float64(result.Revenue)*1000) / 10
+			result.MarginPercent = &percent
+		}
+		if projectHours, ok := hours[result.ProjectID]; ok {
+			result.Hours = &projectHours
+			if projectHours > 0 {
+				perHour := models.NewMoney(result.Revenue.Float64() / projectHours)
+				result.RevenuePerHour = &perHour
+			}
+		}
+		for _, category := range categories[result.ProjectID] {
+			result.ExpensesByCategory = append(result.ExpensesByCategory, *category)
+		}
+		sort.Slice(result.ExpensesByCategory, func(a, b int) bool {
+			return result.ExpensesByCategory[a].Amount > result.ExpensesByCategory[b].Amount
+		})
+	}
+
+	return results, nil
+}
+
+// parseProjectReportDates parses the optional start_date and end_date of a project report
+func parseProjectReportDates(c *gin.Context) (*time.Time, *time.Time, bool) {
+	startValue, endValue := c.Query("start_date"), c.Query("end_date")
+	startDate, ok := parseProjectDate(c, &startValue, "start_date")
+	if !ok {
+		return nil, nil, false
+	}
+	endDate, ok := parseProjectDate(c, &endValue, "end_date")
+	if !ok {
+		return nil, nil, false
+	}
+	if startDate != nil && endDate != nil && endDate.Before(*startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return nil, nil, false
+	}
+	return startDate, endDate, true
+}
+
+// GetProjectProfitAndLoss reports a project's revenue, direct expenses, margin and
+// invoiced hours, for all time or between optional start_date and end_date
+func GetProjectProfitAndLoss(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var project models.Project
+	if err := database.DB.First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	startDate, endDate, ok := parseProjectReportDates(c)
+	if !ok {
+		return
+	}
+
+	results, err := projectProfitAndLoss(database.DB, []models.Project{project}, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate project profit and loss"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results[0])
+}
+
+// GetProjectProfitability reports the P&L of every project of a company, highest margin
+// first, for all time or between optional start_date and end_date
+func GetProjectProfitability(c *gin.Context) {
+	companyID, err := strconv.ParseUint(c.Query("company_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company_id"})
+		return
+	}
+
+	startDate, endDate, ok := parseProjectReportDates(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("company_id = ?", companyID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var projects []models.Project
+	if err := query.Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	results, err := projectProfitAndLoss(database.DB, projects, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate project profit and loss"})
+		return
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Margin > results[j].Margin })
+
+	c.JSON(http.StatusOK, results)
+}
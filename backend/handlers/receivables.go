@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jung-kurt/gofpdf"
+	"gorm.io/gorm"
+)
+
+// markOverdueInvoices marks sent and partially paid invoices past their due date as overdue
+func markOverdueInvoices(db *gorm.DB, today time.Time) error {
+	return db.Model(&models.Invoice{}).
+		Where("status IN ? AND due_date < ?", []string{"sent", "partially_paid"}, today).
+		Update("status", "overdue").Error
+}
+
+// AgedReceivablesInvoice is an unpaid invoice on the aged receivables report
+type AgedReceivablesInvoice struct {
+	InvoiceID     uint         `json:"invoice_id"`
+	InvoiceNumber string       `json:"invoice_number"`
+	IssueDate     string       `json:"issue_date"`
+	DueDate       string       `json:"due_date"`
+	DaysPastDue   int          `json:"days_past_due"`
+	Currency      string       `json:"currency"`
+	Total         models.Money `json:"total"`
+	Balance       models.Money `json:"balance"`     // In the invoice's currency
+	BalanceCAD    models.Money `json:"balance_cad"` // At the invoice's exchange rate
+}
+
+// AgedReceivablesClient is a client's unpaid invoices on the aged receivables report,
+// bucketed in CAD
+type AgedReceivablesClient struct {
+	ClientID uint   `json:"client_id"`
+	Name     string `json:"name"`
+	AgingBuckets
+	Invoices []AgedReceivablesInvoice `json:"invoices"`
+}
+
+// agedReceivables groups the unpaid invoices of a company as of a date by client, in CAD
+// at each invoice's exchange rate. Only invoices issued and payments and credit notes
+// applied on or before the date count. clientID, when given, limits it to one client.
+func agedReceivables(db *gorm.DB, companyID uint, clientID *uint, asOfDate time.Time) ([]AgedReceivablesClient, AgingBuckets, error) {
+	var totals AgingBuckets
+
+	query := db.Preload("Client").
+		Where("company_id = ? AND status NOT IN ? AND issue_date <= ?", companyID, []string{"draft", "cancelled"}, asOfDate)
+	if clientID != nil {
+		query = query.Where("client_id = ?", *clientID)
+	}
+	var invoices []models.Invoice
+	if err := query.Order("due_date ASC, id ASC").Find(&invoices).Error; err != nil {
+		return nil, totals, err
+	}
+
+	// Amounts paid and credited on each invoice by the as-of date
+	var settlements []struct {
+		InvoiceID uint
+		Amount    models.Money
+	}
+	if err := db.Table("payment_allocations").
+		Select("payment_allocations.invoice_id, payment_allocations.amount").
+		Joins("JOIN payments ON payments.id = payment_allocations.payment_id").
+		Where("payments.deleted_at IS NULL AND payments.company_id = ? AND payment_allocations.applied_date <= ?", companyID, asOfDate).
+		Scan(&settlements).Error; err != nil {
+		return nil, totals, err
+	}
+	settled := make(map[uint]models.Money)
+	for _, settlement := range settlements {
+		settled[settlement.InvoiceID] += settlement.Amount
+	}
+	settlements = nil
+	if err := db.Table("credit_note_applications").
+		Select("credit_note_applications.invoice_id, credit_note_applications.amount").
+		Joins("JOIN credit_notes ON credit_notes.id = credit_note_applications.credit_note_id").
+		Where("credit_notes.deleted_at IS NULL AND credit_notes.company_id = ? AND credit_note_applications.invoice_id IS NOT NULL AND credit_note_applications.applied_date <= ?", companyID, asOfDate).
+		Scan(&settlements).Error; err != nil {
+		return nil, totals, err
+	}
+	for _, settlement := range settlements {
+		settled[settlement.InvoiceID] += settlement.Amount
+	}
+
+	clients := make(map[uint]*AgedReceivablesClient)
+	for i := range invoices {
+		invoice := &invoices[i]
+
+		// Invoices marked paid before payments were recorded are settled on their paid date
+		if invoice.Status == "paid" && invoice.AmountPaid == 0 && !invoicePaidDate(invoice).After(asOfDate) {
+			continue
+		}
+		balance := invoice.Total - settled[invoice.ID]
+		if balance <= 0 {
+			continue
+		}
+		balanceCAD := inCAD(balance, invoice.ExchangeRate)
+		daysPastDue := int(asOfDate.Sub(invoice.DueDate).Hours() / 24)
+
+		client := clients[invoice.ClientID]
+		if client == nil {
+			client = &AgedReceivablesClient{ClientID: invoice.ClientID, Name: invoice.Client.Name, Invoices: []AgedReceivablesInvoice{}}
+			clients[invoice.ClientID] = client
+		}
+		client.Add(balanceCAD, daysPastDue)
+		client.Invoices = append(client.Invoices, AgedReceivablesInvoice{
+			InvoiceID:     invoice.ID,
+			InvoiceNumber: invoice.InvoiceNumber,
+			IssueDate:     invoice.IssueDate.Format("2006-01-02"),
+			DueDate:       invoice.DueDate.Format("2006-01-02"),
+			DaysPastDue:   daysPastDue,
+			Currency:      invoice.Currency,
+			Total:         invoice.Total,
+			Balance:       balance,
+			BalanceCAD:    balanceCAD,
+		})
+		totals.Add(balanceCAD, daysPastDue)
+	}
+
+	report := make([]AgedReceivablesClient, 0, len(clients))
+	for _, client := range clients {
+		report = append(report, *client)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Name < report[j].Name
+	})
+
+	return report, totals, nil
+}
+
+// clientOutstandingBalance returns what a client owes today in CAD
+func clientOutstandingBalance(db *gorm.DB, client *models.Client) (models.Money, error) {
+	_, totals, err := agedReceivables(db, client.CompanyID, &client.ID, currentDate())
+	return totals.Total, err
+}
+
+// GetAgedReceivables reports the unpaid invoices of a company as of a date (as_of_date,
+// today by default) by client, in current, 1-30, 31-60, 61-90 and over-90-days-past-due
+// buckets, optionally for one client_id. format=pdf renders it as a PDF.
+func GetAgedReceivables(c *gin.Context) {
+	companyID, err := strconv.ParseUint(c.Query("company_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company_id"})
+		return
+	}
+
+	var company models.Company
+	if err := database.DB.First(&company, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Parse as-of date
+	asOfDate := currentDate()
+	if value := c.Query("as_of_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as of date format. Use YYYY-MM-DD"})
+			return
+		}
+		asOfDate = parsed
+	}
+
+	var clientID *uint
+	if value := c.Query("client_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid client_id"})
+			return
+		}
+		id := uint(parsed)
+		clientID = &id
+	}
+
+	report, totals, err := agedReceivables(database.DB, company.ID, clientID, asOfDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate aged receivables"})
+		return
+	}
+
+	if c.Query("format") == "pdf" {
+		pdfBytes, err := generateAgedReceivablesPDF(&company, asOfDate, report, totals)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Set headers for PDF download
+		filename := fmt.Sprintf("aged_receivables_%s.pdf", asOfDate.Format("2006-01-02"))
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		c.Header("Content-Length", strconv.Itoa(len(pdfBytes)))
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"company_id": company.ID,
+		"as_of_date": asOfDate.Format("2006-01-02"),
+		"clients":    report,
+		"totals":     totals,
+	})
+}
+
+// generateAgedReceivablesPDF creates the aged receivables report PDF: a summary row per
+// client followed by each client's unpaid invoices
+func generateAgedReceivablesPDF(company *models.Company, asOfDate time.Time, clients []AgedReceivablesClient, totals AgingBuckets) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "AGED RECEIVABLES")
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, company.Name)
+	pdf.Ln(7)
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("As of %s (amounts in CAD)", asOfDate.Format("January 2, 2006")))
+	pdf.Ln(10)
+
+	columns := []string{"Current", "1-30", "31-60", "61-90", "Over 90", "Total"}
+	bucketCells := func(buckets AgingBuckets) {
+		for _, amount := range []models.Money{buckets.Current, buckets.Days1To30, buckets.Days31To60, buckets.Days61To90, buckets.Over90, buckets.Total} {
+			pdf.CellFormat(30, 7, fmt.Sprintf("$%s", amount), "1", 0, "R", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Summary by client
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(87, 8, "Client", "1", 0, "L", false, 0, "")
+	for _, column := range columns {
+		pdf.CellFormat(30, 8, column, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Arial", "", 9)
+	for _, client := range clients {
+		pdf.CellFormat(87, 7, client.Name, "1", 0, "L", false, 0, "")
+		bucketCells(client.AgingBuckets)
+	}
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(87, 7, "Total", "1", 0, "L", false, 0, "")
+	bucketCells(totals)
+	pdf.Ln(8)
+
+	// Unpaid invoices by client
+	for _, client := range clients {
+		if pdf.GetY() > 170 {
+			pdf.AddPage()
+		}
+		pdf.SetFont("Arial", "B", 11)
+		pdf.Cell(0, 8, client.Name)
+		pdf.Ln(8)
+
+		pdf.SetFont("Arial", "B", 9)
+		for _, header := range []string{"Invoice #", "Issue Date", "Due Date", "Days Past Due", "Currency", "Total", "Balance", "Balance (CAD)"} {
+			pdf.CellFormat(33, 7, header, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("Arial", "", 9)
+		for _, invoice := range client.Invoices {
+			daysPastDue := ""
+			if invoice.DaysPastDue > 0 {
+				daysPastDue = strconv.Itoa(invoice.DaysPastDue)
+			}
+			pdf.CellFormat(33, 6, invoice.InvoiceNumber, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(33, 6, invoice.IssueDate, "1", 0, "C", false, 0, "")
+			pdf.CellFormat(33, 6, invoice.DueDate, "1", 0, "C", false, 0, "")
+			pdf.CellFormat(33, 6, daysPastDue, "1", 0, "R", false, 0, "")
+			pdf.CellFormat(33, 6, invoice.Currency, "1", 0, "C", false, 0, "")
+			pdf.CellFormat(33, 6, fmt.Sprintf("$%s", invoice.Total), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(33, 6, fmt.Sprintf("$%s", invoice.Balance), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(33, 6, fmt.Sprintf("$%s", invoice.BalanceCAD), "1", 1, "R", false, 0, "")
+		}
+		pdf.Ln(6)
+	}
+
+	// Output to bytes buffer
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,635 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateRecurringInvoiceRequest represents a request to create a recurring invoice profile
+type CreateRecurringInvoiceRequest struct {
+	Name           string                     `json:"name" binding:"required"`
+	ClientID       uint                       `json:"client_id" binding:"required"`
+	Cadence        string                     `json:"cadence" binding:"required,oneof=monthly quarterly yearly days"`
+	IntervalDays   *int                       `json:"interval_days,omitempty" binding:"omitempty,min=1"`
+	StartDate      string                     `json:"start_date" binding:"required"`
+	EndDate        *string                    `json:"end_date,omitempty"`
+	MaxOccurrences *int                       `json:"max_occurrences,omitempty" binding:"omitempty,min=1"`
+	DueDays        int                        `json:"due_days" binding:"min=0"` // 0 for due on receipt
+	AutoSend       bool                       `json:"auto_send"`
+	Currency       string                     `json:"currency,omitempty" binding:"omitempty,len=3,alpha"` // Defaults to CAD
+	Description    *string                    `json:"description,omitempty"`
+	Notes          *string                    `json:"notes,omitempty"`
+	ProjectID      *uint                      `json:"project_id,omitempty"`
+	CompanyID      uint                       `json:"company_id" binding:"required"`
+	Items          []CreateInvoiceItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// UpdateRecurringInvoiceRequest represents a request to update a recurring invoice profile.
+// Changing the schedule moves the next issue date to the first date after the last invoice.
+type UpdateRecurringInvoiceRequest struct {
+	Name           *string                    `json:"name,omitempty"`
+	ClientID       *uint                      `json:"client_id,omitempty"`
+	Cadence        *string                    `json:"cadence,omitempty" binding:"omitempty,oneof=monthly quarterly yearly days"`
+	IntervalDays   *int                       `json:"interval_days,omitempty" binding:"omitempty,min=1"`
+	StartDate      *string                    `json:"start_date,omitempty"`
+	EndDate        *string                    `json:"end_date,omitempty"`                                  // Empty to make the schedule open-ended
+	MaxOccurrences *int                       `json:"max_occurrences,omitempty" binding:"omitempty,min=0"` // 0 for unlimited
+	DueDays        *int                       `json:"due_days,omitempty" binding:"omitempty,min=0"`
+	AutoSend       *bool                      `json:"auto_send,omitempty"`
+	IsActive       *bool                      `json:"is_active,omitempty"`
+	Currency       *string                    `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	Description    *string                    `json:"description,omitempty"`
+	Notes          *string                    `json:"notes,omitempty"`
+	ProjectID      *uint                      `json:"project_id,omitempty"` // 0 to clear
+	Items          []CreateInvoiceItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+}
+
+// recurringInvoiceSchedule returns a recurring template with the schedule of a recurring
+// invoice, for stepping through its issue dates
+func recurringInvoiceSchedule(profile *models.RecurringInvoice) *models.RecurringTemplate {
+	return &models.RecurringTemplate{
+		Cadence:      profile.Cadence,
+		IntervalDays: profile.IntervalDays,
+		StartDate:    profile.StartDate,
+		EndDate:      profile.EndDate,
+	}
+}
+
+// nextRecurringInvoiceDate returns the first issue date of a recurring invoice after a
+// date, or its first issue date when after is nil. It returns nil when the schedule ends
+// before then or the profile has generated its maximum number of invoices.
+func nextRecurringInvoiceDate(profile *models.RecurringInvoice, after *time.Time) *time.Time {
+	if profile.MaxOccurrences != nil && profile.OccurrenceCount >= *profile.MaxOccurrences {
+		return nil
+	}
+	return nextRecurringDate(recurringInvoiceSchedule(profile), after)
+}
+
+// recurringInvoiceProblem checks that a recurring invoice's schedule is complete
+func recurringInvoiceProblem(profile *models.RecurringInvoice) string {
+	if profile.Cadence == "days" && profile.IntervalDays == nil {
+		return "interval_days is required for the days cadence"
+	}
+	if profile.EndDate != nil && profile.EndDate.Before(profile.StartDate) {
+		return "End date must be on or after the start date"
+	}
+	return ""
+}
+
+// buildRecurringInvoiceItems builds the lines of a recurring invoice
+func buildRecurringInvoiceItems(requests []CreateInvoiceItemRequest) []models.RecurringInvoiceItem {
+	items := make([]models.RecurringInvoiceItem, len(requests))
+	for i, itemReq := range requests {
+		items[i] = models.RecurringInvoiceItem{
+			Description: itemReq.Description,
+			Quantity:    itemReq.Quantity,
+			Unit:        emptyToNil(itemReq.Unit),
+			UnitPrice:   itemReq.UnitPrice,
+			ProjectID:   zeroToNil(itemReq.ProjectID),
+		}
+	}
+	return items
+}
+
+// loadRecurringInvoice loads a recurring invoice with its client, project and lines
+func loadRecurringInvoice(db *gorm.DB, profile *models.RecurringInvoice, id interface{}) error {
+	return db.Preload("Client").Preload("Project").Preload("Items").First(profile, id).Error
+}
+
+// generateRecurringInvoice creates the invoice of a recurring invoice for an issue date,
+// with HST as on any new invoice, and posts it to the general ledger. The profile's
+// Client, Company and Items must be loaded.
+func generateRecurringInvoice(tx *gorm.DB, profile *models.RecurringInvoice, issueDate time.Time) (*models.Invoice, error) {
+	exchangeRate := 1.0
+	if profile.Currency != functionalCurrency {
+		rate, err := exchangeRateOn(tx, profile.Currency, issueDate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no %s exchange rate recorded for %s", profile.Currency, issueDate.Format("2006-01-02"))
+		}
+		if err != nil {
+			return nil, err
+		}
+		exchangeRate = rate.Rate
+	}
+
+	items := make([]CreateInvoiceItemRequest, len(profile.Items))
+	var subtotal models.Money
+	for i, item := range profile.Items {
+		items[i] = CreateInvoiceItemRequest{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+			UnitPrice:   item.UnitPrice,
+			ProjectID:   item.ProjectID,
+		}
+		subtotal += item.UnitPrice.MulRate(item.Quantity)
+	}
+	hstAmount := invoiceHSTAmount(subtotal, &profile.Client, &profile.Company)
+
+	invoiceNumber, err := generateInvoiceNumber(profile.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "draft"
+	if profile.AutoSend {
+		status = "sent"
+	}
+
+	invoice := models.Invoice{
+		InvoiceNumber:      invoiceNumber,
+		ClientID:           profile.ClientID,
+		IssueDate:          issueDate,
+		DueDate:            issueDate.AddDate(0, 0, profile.DueDays),
+		Subtotal:           subtotal,
+		HSTAmount:          hstAmount,
+		Total:              subtotal + hstAmount,
+		Currency:           profile.Currency,
+		ExchangeRate:       exchangeRate,
+		Status:             status,
+		Description:        profile.Description,
+		Notes:              profile.Notes,
+		ProjectID:          profile.ProjectID,
+		RecurringInvoiceID: &profile.ID,
+		CompanyID:          profile.CompanyID,
+	}
+	if err := insertInvoice(tx, &invoice, items); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// materializeRecurringInvoice generates the invoices of a recurring invoice due on or
+// before a date, each in its own transaction. The occurrence row is inserted before the
+// invoice and its unique index makes a repeated run skip an issue date already processed,
+// so a restart never creates duplicates. Issue dates in a closed accounting period are
+// skipped. It returns the number of invoices created.
+func materializeRecurringInvoice(db *gorm.DB, profile *models.RecurringInvoice, asOf time.Time) (int, error) {
+	created := 0
+	for profile.NextIssueDate != nil && !profile.NextIssueDate.After(asOf) {
+		date := *profile.NextIssueDate
+
+		occurrence := models.RecurringInvoiceOccurrence{
+			RecurringInvoiceID: profile.ID,
+			IssueDate:          date,
+			Status:             "created",
+		}
+		period, err := closedPeriodContaining(profile.CompanyID, date)
+		if err != nil {
+			return created, err
+		}
+		if period != nil {
+			note := fmt.Sprintf("Accounting period %s to %s is closed",
+				period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02"))
+			occurrence.Status = "skipped"
+			occurrence.Note = &note
+		}
+
+		// Start transaction
+		tx := db.Begin()
+		if tx.Error != nil {
+			return created, tx.Error
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&occurrence)
+		if result.Error != nil {
+			tx.Rollback()
+			return created, result.Error
+		}
+
+		// Generate the invoice unless another run already has
+		count := profile.OccurrenceCount
+		if result.RowsAffected == 1 && occurrence.Status == "created" {
+			invoice, err := generateRecurringInvoice(tx, profile, date)
+			if err != nil {
+				tx.Rollback()
+				return created, fmt.Errorf("invoice on %s: %w", date.Format("2006-01-02"), err)
+			}
+			if err := tx.Model(&occurrence).Update("invoice_id", invoice.ID).Error; err != nil {
+				tx.Rollback()
+				return created, err
+			}
+			count++
+		}
+
+		// Advance the schedule
+		advanced := *profile
+		advanced.OccurrenceCount = count
+		next := nextRecurringInvoiceDate(&advanced, &date)
+		if err := tx.Model(&models.RecurringInvoice{}).Where("id = ?", profile.ID).Updates(map[string]interface{}{
+			"occurrence_count": count,
+			"last_issue_date":  date,
+			"next_issue_date":  next,
+		}).Error; err != nil {
+			tx.Rollback()
+			return created, err
+		}
+
+		// Commit transaction
+		if err := tx.Commit().Error; err != nil {
+			return created, err
+		}
+		if count > profile.OccurrenceCount {
+			created++
+		}
+		profile.OccurrenceCount = count
+		profile.LastIssueDate = &date
+		profile.NextIssueDate = next
+	}
+	return created, nil
+}
+
+// generateRecurringInvoices generates the due invoices of every active recurring invoice.
+// A profile that fails is retried on the next run; the others go ahead.
+func generateRecurringInvoices(db *gorm.DB, today time.Time) error {
+	var profiles []models.RecurringInvoice
+	if err := db.Preload("Company").Preload("Client").Preload("Items").
+		Where("is_active = ? AND next_issue_date <= ?", true, today).
+		Find(&profiles).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range profiles {
+		if _, err := materializeRecurringInvoice(db, &profiles[i], today); err != nil {
+			errs = append(errs, fmt.Errorf("recurring invoice %d (%s): %w", profiles[i].ID, profiles[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verifyRecurringInvoiceReferences checks that a recurring invoice's client and projects
+// belong to its company. It responds with 400 and returns false when one does not.
+func verifyRecurringInvoiceReferences(c *gin.Context, profile *models.RecurringInvoice, items []CreateInvoiceItemRequest) bool {
+	var client models.Client
+	if err := database.DB.Where("company_id = ?", profile.CompanyID).First(&client, profile.ClientID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
+		return false
+	}
+	return verifyInvoiceProjects(c, profile.CompanyID, profile.ProjectID, items)
+}
+
+// ListRecurringInvoices lists the recurring invoice profiles of a company
+func ListRecurringInvoices(c *gin.Context) {
+	var profiles []models.RecurringInvoice
+
+	query := database.DB.Model(&models.RecurringInvoice{})
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if clientID := c.Query("client_id"); clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+	if isActive := c.Query("is_active"); isActive != "" {
+		query = query.Where("is_active = ?", isActive == "true")
+	}
+
+	if err := query.Preload("Client").Preload("Items").Order("name").Find(&profiles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+// CreateRecurringInvoice creates a recurring invoice profile. Invoices from the start date
+// onward are generated by the scheduler as they fall due, including past ones.
+func CreateRecurringInvoice(c *gin.Context) {
+	var req CreateRecurringInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Parse schedule dates
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+		return
+	}
+	var endDate *time.Time
+	if req.EndDate != nil && *req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+			return
+		}
+		endDate = &parsed
+	}
+
+	profile := models.RecurringInvoice{
+		Name:           req.Name,
+		ClientID:       req.ClientID,
+		Cadence:        req.Cadence,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		MaxOccurrences: req.MaxOccurrences,
+		DueDays:        req.DueDays,
+		AutoSend:       req.AutoSend,
+		IsActive:       true,
+		Currency:       normalizeCurrency(req.Currency),
+		Description:    req.Description,
+		Notes:          emptyToNil(req.Notes),
+		ProjectID:      zeroToNil(req.ProjectID),
+		CompanyID:      req.CompanyID,
+		Items:          buildRecurringInvoiceItems(req.Items),
+	}
+	if profile.Cadence == "days" {
+		profile.IntervalDays = req.IntervalDays
+	}
+
+	if problem := recurringInvoiceProblem(&profile); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	if !verifyRecurringInvoiceReferences(c, &profile, req.Items) {
+		return
+	}
+	profile.NextIssueDate = nextRecurringInvoiceDate(&profile, nil)
+
+	// Create recurring invoice with its lines
+	if err := database.DB.Create(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring invoice"})
+		return
+	}
+
+	// Load recurring invoice with related data
+	if err := loadRecurringInvoice(database.DB, &profile, profile.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load recurring invoice data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile)
+}
+
+// GetRecurringInvoice retrieves a recurring invoice profile by ID with the history of
+// invoices it has generated, latest first
+func GetRecurringInvoice(c *gin.Context) {
+	profileID := c.Param("id")
+
+	var profile models.RecurringInvoice
+	if err := database.DB.Preload("Client").Preload("Project").Preload("Items").
+		Preload("Occurrences", func(db *gorm.DB) *gorm.DB {
+			return db.Order("issue_date DESC")
+		}).
+		Preload("Occurrences.Invoice").
+		First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring invoice not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateRecurringInvoice updates a recurring invoice profile; invoices already generated
+// are not changed. Setting is_active to false pauses it, and reactivating a paused profile
+// resumes it from today rather than generating the invoices missed while paused.
+func UpdateRecurringInvoice(c *gin.Context) {
+	profileID := c.Param("id")
+
+	var req UpdateRecurringInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find recurring invoice
+	var profile models.RecurringInvoice
+	if err := database.DB.First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring invoice not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	scheduleChanged := false
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.ClientID != nil {
+		updates["client_id"] = *req.ClientID
+		profile.ClientID = *req.ClientID
+	}
+	if req.Cadence != nil {
+		updates["cadence"] = *req.Cadence
+		profile.Cadence = *req.Cadence
+		scheduleChanged = true
+	}
+	if req.IntervalDays != nil {
+		profile.IntervalDays = req.IntervalDays
+		scheduleChanged = true
+	}
+	if profile.Cadence != "days" && profile.IntervalDays != nil {
+		profile.IntervalDays = nil
+		scheduleChanged = true
+	}
+	if req.StartDate != nil {
+		startDate, err := time.Parse("2006-01-02", *req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+			return
+		}
+		updates["start_date"] = startDate
+		profile.StartDate = startDate
+		scheduleChanged = true
+	}
+	if req.EndDate != nil {
+		profile.EndDate = nil
+		if *req.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", *req.EndDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+				return
+			}
+			profile.EndDate = &endDate
+		}
+		updates["end_date"] = profile.EndDate
+		scheduleChanged = true
+	}
+	if req.MaxOccurrences != nil {
+		profile.MaxOccurrences = nil
+		if *req.MaxOccurrences > 0 {
+			profile.MaxOccurrences = req.MaxOccurrences
+		}
+		updates["max_occurrences"] = profile.MaxOccurrences
+		scheduleChanged = true
+	}
+	if scheduleChanged {
+		updates["interval_days"] = profile.IntervalDays
+	}
+	if req.DueDays != nil {
+		updates["due_days"] = *req.DueDays
+	}
+	if req.AutoSend != nil {
+		updates["auto_send"] = *req.AutoSend
+	}
+	if req.Currency != nil {
+		updates["currency"] = normalizeCurrency(*req.Currency)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Notes != nil {
+		updates["notes"] = emptyToNil(req.Notes)
+	}
+	if req.ProjectID != nil {
+		profile.ProjectID = zeroToNil(req.ProjectID)
+		updates["project_id"] = profile.ProjectID
+	}
+
+	if problem := recurringInvoiceProblem(&profile); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	if !verifyRecurringInvoiceReferences(c, &profile, req.Items) {
+		return
+	}
+
+	// Move the next issue date past the last invoice, or past yesterday on reactivation
+	after := profile.LastIssueDate
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+		if *req.IsActive && !profile.IsActive {
+			yesterday := currentDate().AddDate(0, 0, -1)
+			if after == nil || after.Before(yesterday) {
+				after = &yesterday
+			}
+			scheduleChanged = true
+		}
+	}
+	if scheduleChanged {
+		updates["next_issue_date"] = nextRecurringInvoiceDate(&profile, after)
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&models.RecurringInvoice{}).Where("id = ?", profile.ID).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recurring invoice"})
+			return
+		}
+	}
+
+	// Replace the lines if provided
+	if len(req.Items) > 0 {
+		if err := tx.Where("recurring_invoice_id = ?", profile.ID).Delete(&models.RecurringInvoiceItem{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete existing recurring invoice items"})
+			return
+		}
+		items := buildRecurringInvoiceItems(req.Items)
+		for i := range items {
+			items[i].RecurringInvoiceID = profile.ID
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring invoice items"})
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// Load updated recurring invoice
+	var updated models.RecurringInvoice
+	if err := loadRecurringInvoice(database.DB, &updated, profile.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated recurring invoice data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteRecurringInvoice deletes a recurring invoice profile. Invoices it already
+// generated are kept.
+func DeleteRecurringInvoice(c *gin.Context) {
+	profileID := c.Param("id")
+
+	var profile models.RecurringInvoice
+	if err := database.DB.First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring invoice not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recurring invoice"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring invoice deleted successfully"})
+}
+
+// PreviewRecurringInvoice returns the next issue dates of a recurring invoice, 12 by
+// default or the number given in count, with the totals that would be invoiced
+func PreviewRecurringInvoice(c *gin.Context) {
+	profileID := c.Param("id")
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "12"))
+	if err != nil || count < 1 || count > maxRecurringPreview {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("count must be between 1 and %d", maxRecurringPreview)})
+		return
+	}
+
+	var profile models.RecurringInvoice
+	if err := database.DB.Preload("Company").Preload("Client").Preload("Items").First(&profile, profileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring invoice not found"})
+		return
+	}
+
+	var subtotal models.Money
+	for _, item := range profile.Items {
+		subtotal += item.UnitPrice.MulRate(item.Quantity)
+	}
+	hstAmount := invoiceHSTAmount(subtotal, &profile.Client, &profile.Company)
+	today := currentDate()
+
+	occurrences := []gin.H{}
+	schedule := profile
+	next := profile.NextIssueDate
+	for next != nil && len(occurrences) < count {
+		occurrences = append(occurrences, gin.H{
+			"issue_date": next.Format("2006-01-02"),
+			"due_date":   next.AddDate(0, 0, profile.DueDays).Format("2006-01-02"),
+			"subtotal":   subtotal,
+			"hst_amount": hstAmount,
+			"total":      subtotal + hstAmount,
+			"currency":   profile.Currency,
+			"due":        !next.After(today),
+		})
+		schedule.OccurrenceCount++
+		next = nextRecurringInvoiceDate(&schedule, next)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recurring_invoice_id": profile.ID,
+		"is_active":            profile.IsActive,
+		"occurrences":          occurrences,
+	})
+}
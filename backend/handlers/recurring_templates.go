@@ -0,0 +1,635 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxRecurringPreview is the most occurrences a preview returns
+const maxRecurringPreview = 120
+
+// recurringOccurrenceDate returns occurrence n, counting from 0, of a template's schedule.
+// Monthly, quarterly and yearly occurrences fall on the start date's day of the month, or
+// on the last day of shorter months.
+func recurringOccurrenceDate(template *models.RecurringTemplate, n int) time.Time {
+	start := template.StartDate
+
+	var months int
+	switch template.Cadence {
+	case "days":
+		return start.AddDate(0, 0, n*(*template.IntervalDays))
+	case "quarterly":
+		months = 3 * n
+	case "yearly":
+		months = 12 * n
+	default:
+		months = n
+	}
+
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(months), 1, 0, 0, 0, 0, start.Location())
+	day := start.Day()
+	if lastDay := firstOfMonth.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// nextRecurringDate returns the first occurrence of a template after a date, or its first
+// occurrence when after is nil. It returns nil when the schedule ends before then.
+func nextRecurringDate(template *models.RecurringTemplate, after *time.Time) *time.Time {
+	for n := 0; ; n++ {
+		date := recurringOccurrenceDate(template, n)
+		if template.EndDate != nil && date.After(*template.EndDate) {
+			return nil
+		}
+		if after == nil || date.After(*after) {
+			return &date
+		}
+	}
+}
+
+// recurringHSTAmount returns the HST of each occurrence of a template: its fixed HST
+// amount, or HST at the company's rate. Income from an HST-exempt client carries none.
+// The template's Company and Client must be loaded.
+func recurringHSTAmount(template *models.RecurringTemplate) models.Money {
+	if !template.ChargeHST {
+		return 0
+	}
+	if template.RecordType == "income_entry" && template.Client != nil && template.Client.HSTExempt {
+		return 0
+	}
+	if template.HSTAmount != nil {
+		return *template.HSTAmount
+	}
+	return template.Amount.MulRate(template.Company.HSTRate)
+}
+
+// recurringTemplateProblem checks that a template has what its record type needs
+func recurringTemplateProblem(template *models.RecurringTemplate) string {
+	if template.Cadence == "days" && template.IntervalDays == nil {
+		return "interval_days is required for the days cadence"
+	}
+	if template.EndDate != nil && template.EndDate.Before(template.StartDate) {
+		return "End date must be on or after the start date"
+	}
+
+	switch template.RecordType {
+	case "expense":
+		if template.CategoryID == nil || template.PaidBy == nil {
+			return "Expense templates need category_id and paid_by"
+		}
+	case "income_entry":
+		if template.IncomeType == nil {
+			return "Income entry templates need income_type"
+		}
+	case "owner_payment":
+		if template.PaymentType == nil {
+			return "Owner payment templates need payment_type"
+		}
+		if template.ChargeHST {
+			return "Owner payments do not carry HST"
+		}
+		if template.Currency != functionalCurrency {
+			return "Owner payments are recorded in " + functionalCurrency
+		}
+	}
+	return ""
+}
+
+// recordRecurringOccurrence creates the expense, income entry or owner payment for an
+// occurrence of a template and posts it to the general ledger. The template's Company
+// and Client must be loaded.
+func recordRecurringOccurrence(tx *gorm.DB, template *models.RecurringTemplate, date time.Time) (string, uint, error) {
+	exchangeRate := 1.0
+	if template.Currency != functionalCurrency {
+		rate, err := exchangeRateOn(tx, template.Currency, date)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", 0, fmt.Errorf("no %s exchange rate recorded for %s", template.Currency, date.Format("2006-01-02"))
+		}
+		if err != nil {
+			return "", 0, err
+		}
+		exchangeRate = rate.Rate
+	}
+	hstAmount := recurringHSTAmount(template)
+
+	switch template.RecordType {
+	case "expense":
+		expenseAccount, err := expenseAccountCode(tx, *template.CategoryID)
+		if err != nil {
+			return "", 0, err
+		}
+		expense := models.Expense{
+			Description:  template.Description,
+			CategoryID:   *template.CategoryID,
+			Amount:       template.Amount,
+			HSTPaid:      hstAmount,
+			Currency:     template.Currency,
+			ExchangeRate: exchangeRate,
+			ExpenseDate:  date,
+			PaidBy:       *template.PaidBy,
+			CompanyID:    template.CompanyID,
+		}
+		if err := tx.Create(&expense).Error; err != nil {
+			return "", 0, err
+		}
+		if err := syncSourceJournal(tx, sourceExpense, expense.ID, expenseJournalEntries(&expense, expenseAccount)); err != nil {
+			return "", 0, err
+		}
+		return sourceExpense, expense.ID, nil
+
+	case "income_entry":
+		incomeEntry := models.IncomeEntry{
+			Description:  template.Description,
+			Amount:       template.Amount,
+			HSTAmount:    hstAmount,
+			Total:        template.Amount + hstAmount,
+			Currency:     template.Currency,
+			ExchangeRate: exchangeRate,
+			IncomeType:   *template.IncomeType,
+			ClientID:     template.ClientID,
+			IncomeDate:   date,
+			CompanyID:    template.CompanyID,
+		}
+		if err := tx.Create(&incomeEntry).Error; err != nil {
+			return "", 0, err
+		}
+		if err := syncSourceJournal(tx, sourceIncomeEntry, incomeEntry.ID, incomeEntryJournalEntries(&incomeEntry)); err != nil {
+			return "", 0, err
+		}
+		return sourceIncomeEntry, incomeEntry.ID, nil
+
+	default:
+		ownerPayment := models.OwnerPayment{
+			Description: template.Description,
+			Amount:      template.Amount,
+			PaymentDate: date,
+			PaymentType: *template.PaymentType,
+			Reference:   template.Reference,
+			CompanyID:   template.CompanyID,
+		}
+		if err := tx.Create(&ownerPayment).Error; err != nil {
+			return "", 0, err
+		}
+		if err := syncSourceJournal(tx, sourceOwnerPayment, ownerPayment.ID, ownerPaymentJournalEntries(&ownerPayment)); err != nil {
+			return "", 0, err
+		}
+		return sourceOwnerPayment, ownerPayment.ID, nil
+	}
+}
+
+// materializeRecurringTemplate records the occurrences of a template due on or before a
+// date, each in its own transaction. The occurrence row is inserted before the record and
+// its unique index makes a repeated run skip an occurrence already recorded, so a restart
+// never creates duplicates. Occurrences dated in a closed accounting period are skipped.
+// It returns the number of records created.
+func materializeRecurringTemplate(db *gorm.DB, template *models.RecurringTemplate, asOf time.Time) (int, error) {
+	created := 0
+	for template.NextOccurrenceDate != nil && !template.NextOccurrenceDate.After(asOf) {
+		date := *template.NextOccurrenceDate
+
+		occurrence := models.RecurringOccurrence{
+			TemplateID:     template.ID,
+			OccurrenceDate: date,
+			Status:         "created",
+		}
+		period, err := closedPeriodContaining(template.CompanyID, date)
+		if err != nil {
+			return created, err
+		}
+		if period != nil {
+			note := fmt.Sprintf("Accounting period %s to %s is closed",
+				period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02"))
+			occurrence.Status = "skipped"
+			occurrence.Note = &note
+		}
+
+		// Start transaction
+		tx := db.Begin()
+		if tx.Error != nil {
+			return created, tx.Error
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&occurrence)
+		if result.Error != nil {
+			tx.Rollback()
+			return created, result.Error
+		}
+
+		// Create the record unless another run already has
+		if result.RowsAffected == 1 && occurrence.Status == "created" {
+			sourceType, sourceID, err := recordRecurringOccurrence(tx, template, date)
+			if err != nil {
+				tx.Rollback()
+				return created, fmt.Errorf("occurrence on %s: %w", date.Format("2006-01-02"), err)
+			}
+			if err := tx.Model(&occurrence).Updates(map[string]interface{}{
+				"source_type": sourceType,
+				"source_id":   sourceID,
+			}).Error; err != nil {
+				tx.Rollback()
+				return created, err
+			}
+			created++
+		}
+
+		// Advance the schedule
+		next := nextRecurringDate(template, &date)
+		if err := tx.Model(&models.RecurringTemplate{}).Where("id = ?", template.ID).Updates(map[string]interface{}{
+			"last_occurrence_date": date,
+			"next_occurrence_date": next,
+		}).Error; err != nil {
+			tx.Rollback()
+			return created, err
+		}
+
+		// Commit transaction
+		if err := tx.Commit().Error; err != nil {
+			return created, err
+		}
+		template.LastOccurrenceDate = &date
+		template.NextOccurrenceDate = next
+	}
+	return created, nil
+}
+
+// materializeRecurringTemplates records the due occurrences of every active template. A
+// template that fails is retried on the next run; the others go ahead.
+func materializeRecurringTemplates(db *gorm.DB, today time.Time) error {
+	var templates []models.RecurringTemplate
+	if err := db.Preload("Company").Preload("Client").
+		Where("is_active = ? AND next_occurrence_date <= ?", true, today).
+		Find(&templates).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range templates {
+		if _, err := materializeRecurringTemplate(db, &templates[i], today); err != nil {
+			errs = append(errs, fmt.Errorf("template %d (%s): %w", templates[i].ID, templates[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verifyRecurringTemplateReferences checks that a template's category and client exist.
+// It responds with an error and returns false when one does not.
+func verifyRecurringTemplateReferences(c *gin.Context, template *models.RecurringTemplate) bool {
+	if template.CategoryID != nil {
+		var category models.ExpenseCategory
+		if err := database.DB.First(&category, *template.CategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+			return false
+		}
+	}
+	if template.ClientID != nil {
+		var client models.Client
+		if err := database.DB.First(&client, *template.ClientID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Client not found"})
+			return false
+		}
+	}
+	return true
+}
+
+// ListRecurringTemplates lists the recurring templates of a company
+func ListRecurringTemplates(c *gin.Context) {
+	var templates []models.RecurringTemplate
+
+	query := database.DB.Model(&models.RecurringTemplate{})
+	if companyID := c.Query("company_id"); companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if recordType := c.Query("record_type"); recordType != "" {
+		query = query.Where("record_type = ?", recordType)
+	}
+
+	if err := query.Preload("Category").Preload("Client").Order("name").Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateRecurringTemplate creates a new recurring template. Occurrences from the start
+// date onward are recorded by the scheduler as they fall due, including past ones.
+func CreateRecurringTemplate(c *gin.Context) {
+	var req models.CreateRecurringTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Parse schedule dates
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+		return
+	}
+	var endDate *time.Time
+	if req.EndDate != nil && *req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+			return
+		}
+		endDate = &parsed
+	}
+
+	template := models.RecurringTemplate{
+		Name:        req.Name,
+		RecordType:  req.RecordType,
+		Description: req.Description,
+		Cadence:     req.Cadence,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		IsActive:    true,
+		Amount:      req.Amount,
+		ChargeHST:   req.ChargeHST,
+		HSTAmount:   req.HSTAmount,
+		Currency:    normalizeCurrency(req.Currency),
+		CompanyID:   req.CompanyID,
+	}
+	if template.Cadence == "days" {
+		template.IntervalDays = req.IntervalDays
+	}
+
+	// Keep only the settings of the record type
+	switch template.RecordType {
+	case "expense":
+		template.CategoryID = req.CategoryID
+		template.PaidBy = req.PaidBy
+	case "income_entry":
+		template.IncomeType = req.IncomeType
+		template.ClientID = req.ClientID
+	case "owner_payment":
+		template.PaymentType = req.PaymentType
+		template.Reference = req.Reference
+	}
+
+	if problem := recurringTemplateProblem(&template); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	if !verifyRecurringTemplateReferences(c, &template) {
+		return
+	}
+	template.NextOccurrenceDate = nextRecurringDate(&template, nil)
+
+	if err := database.DB.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring template"})
+		return
+	}
+
+	// Load template with related data
+	if err := database.DB.Preload("Category").Preload("Client").First(&template, template.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load recurring template data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetRecurringTemplate retrieves a recurring template by ID with its occurrences so far
+func GetRecurringTemplate(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var template models.RecurringTemplate
+	if err := database.DB.Preload("Category").Preload("Client").
+		Preload("Occurrences", func(db *gorm.DB) *gorm.DB {
+			return db.Order("occurrence_date DESC")
+		}).
+		First(&template, templateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateRecurringTemplate updates a recurring template. Reactivating a paused template
+// resumes it from today rather than recording the occurrences missed while paused.
+func UpdateRecurringTemplate(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var req models.UpdateRecurringTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find template
+	var template models.RecurringTemplate
+	if err := database.DB.First(&template, templateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring template not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	scheduleChanged := false
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Cadence != nil {
+		updates["cadence"] = *req.Cadence
+		template.Cadence = *req.Cadence
+		scheduleChanged = true
+	}
+	if req.IntervalDays != nil {
+		template.IntervalDays = req.IntervalDays
+		scheduleChanged = true
+	}
+	if template.Cadence != "days" && template.IntervalDays != nil {
+		template.IntervalDays = nil
+		scheduleChanged = true
+	}
+	if req.StartDate != nil {
+		startDate, err := time.Parse("2006-01-02", *req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+			return
+		}
+		updates["start_date"] = startDate
+		template.StartDate = startDate
+		scheduleChanged = true
+	}
+	if req.EndDate != nil {
+		template.EndDate = nil
+		if *req.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", *req.EndDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+				return
+			}
+			template.EndDate = &endDate
+		}
+		updates["end_date"] = template.EndDate
+		scheduleChanged = true
+	}
+	if scheduleChanged {
+		updates["interval_days"] = template.IntervalDays
+	}
+	if req.Amount != nil {
+		updates["amount"] = *req.Amount
+	}
+	if req.ChargeHST != nil {
+		updates["charge_hst"] = *req.ChargeHST
+		template.ChargeHST = *req.ChargeHST
+	}
+	if req.HSTAmount != nil {
+		updates["hst_amount"] = *req.HSTAmount
+	}
+	if req.ClearHSTAmount {
+		updates["hst_amount"] = nil
+	}
+	if req.Currency != nil {
+		updates["currency"] = normalizeCurrency(*req.Currency)
+		template.Currency = normalizeCurrency(*req.Currency)
+	}
+
+	// Settings of the template's record type
+	switch template.RecordType {
+	case "expense":
+		if req.CategoryID != nil {
+			updates["category_id"] = *req.CategoryID
+			template.CategoryID = req.CategoryID
+		}
+		if req.PaidBy != nil {
+			updates["paid_by"] = *req.PaidBy
+		}
+	case "income_entry":
+		if req.IncomeType != nil {
+			updates["income_type"] = *req.IncomeType
+		}
+		if req.ClientID != nil {
+			template.ClientID = zeroToNil(req.ClientID)
+			updates["client_id"] = template.ClientID
+		}
+	case "owner_payment":
+		if req.PaymentType != nil {
+			updates["payment_type"] = *req.PaymentType
+		}
+		if req.Reference != nil {
+			updates["reference"] = emptyToNil(req.Reference)
+		}
+	}
+
+	if problem := recurringTemplateProblem(&template); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	if !verifyRecurringTemplateReferences(c, &template) {
+		return
+	}
+
+	// Move the next occurrence past the last one recorded, or past yesterday on reactivation
+	after := template.LastOccurrenceDate
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+		if *req.IsActive && !template.IsActive {
+			yesterday := currentDate().AddDate(0, 0, -1)
+			if after == nil || after.Before(yesterday) {
+				after = &yesterday
+			}
+			scheduleChanged = true
+		}
+	}
+	if scheduleChanged {
+		updates["next_occurrence_date"] = nextRecurringDate(&template, after)
+	}
+
+	if err := database.DB.Model(&template).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recurring template"})
+		return
+	}
+
+	// Load updated template
+	if err := database.DB.Preload("Category").Preload("Client").First(&template, template.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated recurring template data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteRecurringTemplate deletes a recurring template. Records it already created are kept.
+func DeleteRecurringTemplate(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var template models.RecurringTemplate
+	if err := database.DB.First(&template, templateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring template not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recurring template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring template deleted successfully"})
+}
+
+// PreviewRecurringTemplate returns the next occurrences of a template, 12 by default or
+// the number given in count, with the amounts that would be recorded. Occurrences
+// already due are waiting for the next scheduler run.
+func PreviewRecurringTemplate(c *gin.Context) {
+	templateID := c.Param("id")
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "12"))
+	if err != nil || count < 1 || count > maxRecurringPreview {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("count must be between 1 and %d", maxRecurringPreview)})
+		return
+	}
+
+	var template models.RecurringTemplate
+	if err := database.DB.Preload("Company").Preload("Client").First(&template, templateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring template not found"})
+		return
+	}
+
+	hstAmount := recurringHSTAmount(&template)
+	today := currentDate()
+
+	occurrences := []gin.H{}
+	next := template.NextOccurrenceDate
+	for next != nil && len(occurrences) < count {
+		occurrences = append(occurrences, gin.H{
+			"date":       next.Format("2006-01-02"),
+			"amount":     template.Amount,
+			"hst_amount": hstAmount,
+			"total":      template.Amount + hstAmount,
+			"currency":   template.Currency,
+			"due":        !next.After(today),
+		})
+		next = nextRecurringDate(&template, next)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template_id": template.ID,
+		"is_active":   template.IsActive,
+		"occurrences": occurrences,
+	})
+}
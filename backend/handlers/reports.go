@@ -6,11 +6,13 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jung-kurt/gofpdf"
+	"gorm.io/gorm"
 )
 
 // TaxReportRequest represents the request for generating a tax report
@@ -19,38 +21,374 @@ type TaxReportRequest struct {
 	FiscalYear int    `json:"fiscal_year" binding:"required"`
 	StartDate  string `json:"start_date,omitempty"`
 	EndDate    string `json:"end_date,omitempty"`
-	ReportType string `json:"report_type" binding:"required"` // "comprehensive", "pandl", "hst", "retained"
+	AsOfDate   string `json:"as_of_date,omitempty"`           // balance sheet date, defaults to the end of the period
+	ReportType string `json:"report_type" binding:"required"` // "comprehensive", "pandl", "hst", "retained", "balance_sheet"
+	Basis      string `json:"basis,omitempty"`                // "accrual" (default) or "cash"
+	Format     string `json:"format,omitempty"`               // "pdf" (default) or "json"
 }
 
 // TaxReportData contains all the data needed for tax reports
 type TaxReportData struct {
-	Company       *models.Company       `json:"company"`
-	FiscalYear    int                   `json:"fiscal_year"`
-	StartDate     time.Time             `json:"start_date"`
-	EndDate       time.Time             `json:"end_date"`
-	Invoices      []models.Invoice      `json:"invoices"`
-	Expenses      []models.Expense      `json:"expenses"`
-	Dividends     []models.Dividend     `json:"dividends"`
-	CapitalAssets []models.CapitalAsset `json:"capital_assets"`
-	HSTPayments   []models.HSTPayment   `json:"hst_payments"`
-	TaxReturns    []models.TaxReturn    `json:"tax_returns"`
-	Summary       TaxReportSummary      `json:"summary"`
+	Company       *models.Company         `json:"company"`
+	FiscalYear    int                     `json:"fiscal_year"`
+	Basis         string                  `json:"basis"`
+	StartDate     time.Time               `json:"start_date"`
+	EndDate       time.Time               `json:"end_date"`
+	Invoices      []models.Invoice        `json:"invoices"` // Issued in the period on the accrual basis, with amounts received in it on the cash basis
+	Income        []TaxReportIncome       `json:"income"`
+	CreditNotes   []models.CreditNote     `json:"credit_notes"` // Issued in the period on the accrual basis, applied or refunded in it on the cash basis
+	Credits       []TaxReportCredit       `json:"credits"`
+	Expenses      []models.Expense        `json:"expenses"`
+	BillLines     []TaxReportBillLine     `json:"bill_lines"` // Lines of bills dated in the period on the accrual basis, with their shares of amounts paid in it on the cash basis
+	Dividends     []models.Dividend       `json:"dividends"`
+	CapitalAssets []models.CapitalAsset   `json:"capital_assets"`
+	HSTPayments   []models.HSTPayment     `json:"hst_payments"`
+	TaxReturns    []models.TaxReturn      `json:"tax_returns"`
+	FXSettlements []TaxReportFXSettlement `json:"fx_settlements"` // Accrual basis only
+	Summary       TaxReportSummary        `json:"summary"`
+}
+
+// TaxReportIncome is revenue and HST recognized from an invoice in a report period, in
+// CAD: all of it on the issue date on the accrual basis, or on the cash basis its share of
+// an amount received against the invoice, on the date received
+type TaxReportIncome struct {
+	InvoiceID     uint         `json:"invoice_id"`
+	InvoiceNumber string       `json:"invoice_number"`
+	ClientName    string       `json:"client_name"`
+	Date          time.Time    `json:"date"`
+	Source        string       `json:"source"` // invoice, payment, credit_note, or paid for an invoice marked paid before payments were recorded
+	Subtotal      models.Money `json:"subtotal"`
+	HSTAmount     models.Money `json:"hst_amount"`
+}
+
+// TaxReportCredit is revenue and HST reversed by a credit note in a report period, in CAD:
+// all of it on the issue date on the accrual basis, or on the cash basis its share of an
+// amount applied to an invoice or refunded, on that date
+type TaxReportCredit struct {
+	CreditNoteID     uint         `json:"credit_note_id"`
+	CreditNoteNumber string       `json:"credit_note_number"`
+	ClientName       string       `json:"client_name"`
+	Date             time.Time    `json:"date"`
+	Source           string       `json:"source"` // issued, applied or refunded
+	Subtotal         models.Money `json:"subtotal"`
+	HSTAmount        models.Money `json:"hst_amount"`
+}
+
+// TaxReportFXSettlement is the foreign exchange gain, or loss when negative, realized on
+// an amount received against a foreign currency invoice at a different rate than the
+// invoice was issued at
+type TaxReportFXSettlement struct {
+	InvoiceID     uint         `json:"invoice_id"`
+	InvoiceNumber string       `json:"invoice_number"`
+	ClientName    string       `json:"client_name"`
+	Date          time.Time    `json:"date"`
+	Source        string       `json:"source"` // payment, credit_note, or paid for an invoice marked paid before payments were recorded
+	Currency      string       `json:"currency"`
+	Amount        models.Money `json:"amount"`     // In the invoice's currency
+	IssueRate     float64      `json:"issue_rate"` // CAD per unit of currency
+	SettledRate   float64      `json:"settled_rate"`
+	Gain          models.Money `json:"gain"`
+}
+
+// TaxReportBillLine is an expense and input tax credit recognized from a line of a bill in a
+// report period: all of it on the bill date on the accrual basis, or on the cash basis its
+// share of an amount paid on the bill, on the payment date
+type TaxReportBillLine struct {
+	BillID      uint         `json:"bill_id"`
+	BillNumber  string       `json:"bill_number"`
+	BillDate    time.Time    `json:"bill_date"`
+	Date        time.Time    `json:"date"`
+	Description string       `json:"description"`
+	Category    string       `json:"category"`
+	Amount      models.Money `json:"amount"` // Before HST
+	HSTAmount   models.Money `json:"hst_amount"`
 }
 
 // TaxReportSummary contains calculated summary data
 type TaxReportSummary struct {
-	GrossIncome          float64 `json:"gross_income"`
-	TotalExpenses        float64 `json:"total_expenses"`
-	NetIncomeBeforeTax   float64 `json:"net_income_before_tax"`
-	SmallBusinessTax     float64 `json:"small_business_tax"`
-	NetIncomeAfterTax    float64 `json:"net_income_after_tax"`
-	HSTCollected         float64 `json:"hst_collected"`
-	HSTPaid              float64 `json:"hst_paid"`
-	HSTRemittance        float64 `json:"hst_remittance"`
-	TotalDividends       float64 `json:"total_dividends"`
-	RetainedEarnings     float64 `json:"retained_earnings"`
-	TotalDepreciation    float64 `json:"total_depreciation"`
-	CapitalCostAllowance float64 `json:"capital_cost_allowance"`
+	GrossIncome          models.Money `json:"gross_income"` // Net of credit notes
+	CreditNotes          models.Money `json:"credit_notes"` // Subtotal of credit notes recognized in the period
+	CreditNoteHST        models.Money `json:"credit_note_hst"`
+	ForeignExchangeGain  models.Money `json:"foreign_exchange_gain"` // Realized on amounts received against foreign currency invoices; negative for a loss
+	TotalExpenses        models.Money `json:"total_expenses"`
+	NetIncomeBeforeTax   models.Money `json:"net_income_before_tax"`
+	SmallBusinessTax     models.Money `json:"small_business_tax"`
+	NetIncomeAfterTax    models.Money `json:"net_income_after_tax"`
+	HSTCollected         models.Money `json:"hst_collected"`
+	HSTPaid              models.Money `json:"hst_paid"`
+	HSTRemittance        models.Money `json:"hst_remittance"`
+	TotalDividends       models.Money `json:"total_dividends"`
+	RetainedEarnings     models.Money `json:"retained_earnings"`
+	TotalDepreciation    models.Money `json:"total_depreciation"`
+	CapitalCostAllowance models.Money `json:"capital_cost_allowance"`
+}
+
+// Reporting bases. Accrual recognizes revenue and HST collected when an invoice is
+// issued, bills and their input tax credits on the bill date, and dividends when declared;
+// cash recognizes revenue and HST in proportion to the amounts received against each
+// invoice, bills and their input tax credits in proportion to the amounts paid on each
+// bill, and dividends when paid. Expenses and their input tax credits are recorded on the
+// date they are paid under both bases.
+const (
+	basisAccrual = "accrual"
+	basisCash    = "cash"
+)
+
+// proRataSubtotal returns the part of a document's subtotal covered by a cumulative amount
+// of its total, so the shares of successive amounts add up to the subtotal exactly
+func proRataSubtotal(subtotal, total, amount models.Money) models.Money {
+	if amount >= total {
+		return subtotal
+	}
+	return subtotal.MulRate(amount.Float64() / total.Float64())
+}
+
+// invoiceReceipt is an amount received against an invoice, in its currency at the rate
+// it was received at, with the shares of it that are revenue and HST
+type invoiceReceipt struct {
+	Invoice   *models.Invoice
+	Date      time.Time
+	Source    string // payment, credit_note or paid
+	Amount    models.Money
+	Rate      float64
+	Subtotal  models.Money
+	HSTAmount models.Money
+}
+
+// invoiceReceipts returns the amounts received against a company's invoices between two
+// dates, in date order: payments allocated to them, credit notes applied to them and, for
+// invoices marked paid before payments were recorded, their total on the paid date. Each
+// amount is split into revenue and HST in proportion to the invoice's subtotal and HST,
+// counting the invoice's earlier receipts so the shares add up to the invoice exactly.
+func invoiceReceipts(db *gorm.DB, companyID uint, startDate, endDate time.Time) ([]invoiceReceipt, error) {
+	// Find the invoices with amounts received in the period
+	var allocated, credited []uint
+	if err := db.Model(&models.PaymentAllocation{}).
+		Joins("JOIN invoices ON invoices.id = payment_allocations.invoice_id").
+		Where("invoices.company_id = ? AND invoices.deleted_at IS NULL", companyID).
+		Where("payment_allocations.applied_date >= ? AND payment_allocations.applied_date <= ?", startDate, endDate).
+		Pluck("payment_allocations.invoice_id", &allocated).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.CreditNoteApplication{}).
+		Joins("JOIN invoices ON invoices.id = credit_note_applications.invoice_id").
+		Where("invoices.company_id = ? AND invoices.deleted_at IS NULL", companyID).
+		Where("credit_note_applications.applied_date >= ? AND credit_note_applications.applied_date <= ?", startDate, endDate).
+		Pluck("credit_note_applications.invoice_id", &credited).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[uint]bool)
+	var invoiceIDs []uint
+	for _, id := range append(allocated, credited...) {
+		if !seen[id] {
+			seen[id] = true
+			invoiceIDs = append(invoiceIDs, id)
+		}
+	}
+
+	var invoices []models.Invoice
+	if err := db.Preload("Client").
+		Where("id IN ? OR (company_id = ? AND status = ? AND amount_paid = 0 AND amount_credited = 0 AND COALESCE(paid_date, issue_date) >= ? AND COALESCE(paid_date, issue_date) <= ?)",
+			invoiceIDs, companyID, "paid", startDate, endDate).
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	// Gather each invoice's receipts up to the end of the period
+	byInvoice := make(map[uint][]invoiceReceipt, len(invoices))
+	invoiceByID := make(map[uint]*models.Invoice, len(invoices))
+	for i := range invoices {
+		invoice := &invoices[i]
+		invoiceByID[invoice.ID] = invoice
+		if !seen[invoice.ID] {
+			byInvoice[invoice.ID] = []invoiceReceipt{{
+				Invoice: invoice, Date: invoicePaidDate(invoice), Source: "paid", Amount: invoice.Total, Rate: invoicePaidRate(invoice),
+			}}
+		}
+	}
+	var allocations []models.PaymentAllocation
+	if err := db.Preload("Payment").Where("invoice_id IN ? AND applied_date <= ?", invoiceIDs, endDate).
+		Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+	for _, allocation := range allocations {
+		byInvoice[allocation.InvoiceID] = append(byInvoice[allocation.InvoiceID], invoiceReceipt{
+			Invoice: invoiceByID[allocation.InvoiceID], Date: allocation.AppliedDate, Source: "payment",
+			Amount: allocation.Amount, Rate: allocation.Payment.ExchangeRate,
+		})
+	}
+	var applications []models.CreditNoteApplication
+	if err := db.Preload("CreditNote").Where("invoice_id IN ? AND applied_date <= ?", invoiceIDs, endDate).
+		Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	for _, application := range applications {
+		byInvoice[*application.InvoiceID] = append(byInvoice[*application.InvoiceID], invoiceReceipt{
+			Invoice: invoiceByID[*application.InvoiceID], Date: application.AppliedDate, Source: "credit_note",
+			Amount: application.Amount, Rate: application.CreditNote.ExchangeRate,
+		})
+	}
+
+	// Split each receipt into revenue and HST, keeping those in the period
+	var receipts []invoiceReceipt
+	for _, invoice := range invoices {
+		list := byInvoice[invoice.ID]
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Date.Before(list[j].Date) })
+		var received, recognized models.Money
+		for _, receipt := range list {
+			received += receipt.Amount
+			subtotal := proRataSubtotal(invoice.Subtotal, invoice.Total, received)
+			receipt.Subtotal = subtotal - recognized
+			receipt.HSTAmount = receipt.Amount - receipt.Subtotal
+			recognized = subtotal
+			if !receipt.Date.Before(startDate) {
+				receipts = append(receipts, receipt)
+			}
+		}
+	}
+	sort.SliceStable(receipts, func(i, j int) bool { return receipts[i].Date.Before(receipts[j].Date) })
+	return receipts, nil
+}
+
+// billPayments returns the expenses and input tax credits recognized from a company's
+// bills as they were paid between two dates, in date order. Each payment expenses every
+// line of the bill and its HST in proportion to the bill's total, counting the bill's
+// earlier payments so the shares add up to the bill exactly.
+func billPayments(db *gorm.DB, companyID uint, startDate, endDate time.Time) ([]TaxReportBillLine, error) {
+	// Find the bills with amounts paid in the period
+	var billIDs []uint
+	if err := db.Model(&models.BillPaymentAllocation{}).
+		Joins("JOIN bill_payments ON bill_payments.id = bill_payment_allocations.bill_payment_id").
+		Where("bill_payments.company_id = ? AND bill_payments.deleted_at IS NULL", companyID).
+		Where("bill_payments.payment_date >= ? AND bill_payments.payment_date <= ?", startDate, endDate).
+		Distinct().Pluck("bill_payment_allocations.bill_id", &billIDs).Error; err != nil {
+		return nil, err
+	}
+
+	var bills []models.Bill
+	if err := db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Items.Category").
+		Where("id IN ? AND status <> ?", billIDs, "void").Order("bill_date, id").
+		Find(&bills).Error; err != nil {
+		return nil, err
+	}
+
+	// Gather each bill's payments up to the end of the period
+	var allocations []models.BillPaymentAllocation
+	if err := db.Preload("BillPayment").
+		Joins("JOIN bill_payments ON bill_payments.id = bill_payment_allocations.bill_payment_id").
+		Where("bill_payment_allocations.bill_id IN ? AND bill_payments.deleted_at IS NULL AND bill_payments.payment_date <= ?", billIDs, endDate).
+		Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+	byBill := make(map[uint][]models.BillPaymentAllocation, len(bills))
+	for _, allocation := range allocations {
+		byBill[allocation.BillID] = append(byBill[allocation.BillID], allocation)
+	}
+
+	// Split each payment across the bill's lines, keeping those in the period
+	var lines []TaxReportBillLine
+	for _, bill := range bills {
+		list := byBill[bill.ID]
+		sort.SliceStable(list, func(i, j int) bool { return list[i].BillPayment.PaymentDate.Before(list[j].BillPayment.PaymentDate) })
+		recognized := make([]models.Money, len(bill.Items))
+		recognizedHST := make([]models.Money, len(bill.Items))
+		var paid models.Money
+		for _, allocation := range list {
+			paid += allocation.Amount
+			date := allocation.BillPayment.PaymentDate
+			for i, item := range bill.Items {
+				amount := proRataSubtotal(item.Amount, bill.Total, paid)
+				hstAmount := proRataSubtotal(item.HSTAmount, bill.Total, paid)
+				if !date.Before(startDate) {
+					lines = append(lines, TaxReportBillLine{
+						BillID:      bill.ID,
+						BillNumber:  bill.BillNumber,
+						BillDate:    bill.BillDate,
+						Date:        date,
+						Description: item.Description,
+						Category:    item.Category.Name,
+						Amount:      amount - recognized[i],
+						HSTAmount:   hstAmount - recognizedHST[i],
+					})
+				}
+				recognized[i] = amount
+				recognizedHST[i] = hstAmount
+			}
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Date.Before(lines[j].Date) })
+	return lines, nil
+}
+
+// creditNoteUses returns the revenue and HST reversed by a company's credit notes as they
+// were applied to invoices or refunded between two dates, in date order. Each use reverses
+// the credit note's subtotal and HST in proportion to its total, counting the credit
+// note's earlier uses so the shares add up to the credit note exactly. It also returns the
+// credit notes used in the period.
+func creditNoteUses(db *gorm.DB, companyID uint, startDate, endDate time.Time) ([]TaxReportCredit, []models.CreditNote, error) {
+	var creditNoteIDs []uint
+	if err := db.Model(&models.CreditNoteApplication{}).
+		Joins("JOIN credit_notes ON credit_notes.id = credit_note_applications.credit_note_id").
+		Where("credit_notes.company_id = ? AND credit_notes.deleted_at IS NULL", companyID).
+		Where("credit_note_applications.applied_date >= ? AND credit_note_applications.applied_date <= ?", startDate, endDate).
+		Distinct().Pluck("credit_note_applications.credit_note_id", &creditNoteIDs).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var creditNotes []models.CreditNote
+	if err := db.Preload("Client").Preload("Invoice").
+		Preload("Applications", "applied_date <= ?", endDate).
+		Where("id IN ?", creditNoteIDs).Order("issue_date, id").
+		Find(&creditNotes).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var credits []TaxReportCredit
+	for _, creditNote := range creditNotes {
+		applications := creditNote.Applications
+		sort.SliceStable(applications, func(i, j int) bool { return applications[i].AppliedDate.Before(applications[j].AppliedDate) })
+		var used, reversed models.Money
+		for _, application := range applications {
+			used += application.Amount
+			subtotal := proRataSubtotal(creditNote.Subtotal, creditNote.Total, used)
+			share := subtotal - reversed
+			reversed = subtotal
+			if application.AppliedDate.Before(startDate) {
+				continue
+			}
+			source := "applied"
+			if application.InvoiceID == nil {
+				source = "refunded"
+			}
+			credits = append(credits, TaxReportCredit{
+				CreditNoteID:     creditNote.ID,
+				CreditNoteNumber: creditNote.CreditNoteNumber,
+				ClientName:       creditNote.Client.Name,
+				Date:             application.AppliedDate,
+				Source:           source,
+				Subtotal:         inCAD(share, creditNote.ExchangeRate),
+				HSTAmount:        inCAD(application.Amount-share, creditNote.ExchangeRate),
+			})
+		}
+	}
+	sort.SliceStable(credits, func(i, j int) bool { return credits[i].Date.Before(credits[j].Date) })
+	return credits, creditNotes, nil
+}
+
+// dividendRecognitionDate returns the date a dividend reduces retained earnings under a
+// reporting basis, and false when the dividend is not recognized at all
+func dividendRecognitionDate(dividend models.Dividend, basis string) (time.Time, bool) {
+	if basis == basisCash {
+		if dividend.Status != "paid" {
+			return time.Time{}, false
+		}
+		if dividend.PaymentDate != nil {
+			return *dividend.PaymentDate, true
+		}
+	}
+	return dividend.DeclarationDate, true
 }
 
 // GenerateTaxReport generates a comprehensive tax report
@@ -80,6 +418,24 @@ func GenerateTaxReport(c *gin.Context) {
 		return
 	}
 
+	if req.Format != "" && req.Format != "pdf" && req.Format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Use pdf or json"})
+		return
+	}
+	if req.Basis == "" {
+		req.Basis = basisAccrual
+	}
+	if req.Basis != basisAccrual && req.Basis != basisCash {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid basis. Use accrual or cash"})
+		return
+	}
+
+	// The balance sheet is built from ledger balances rather than period data
+	if req.ReportType == "balance_sheet" {
+		generateBalanceSheetReport(c, req)
+		return
+	}
+
 	// Generate report data
 	reportData, err := generateReportData(req)
 	if err != nil {
@@ -87,6 +443,16 @@ func GenerateTaxReport(c *gin.Context) {
 		return
 	}
 
+	if req.Format == "json" {
+		switch req.ReportType {
+		case "comprehensive", "pandl", "hst", "retained":
+			c.JSON(http.StatusOK, reportData)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report type"})
+		}
+		return
+	}
+
 	// Generate PDF based on report type
 	var pdfBytes []byte
 	switch req.ReportType {
@@ -116,10 +482,69 @@ func GenerateTaxReport(c *gin.Context) {
 	c.Data(http.StatusOK, "application/pdf", pdfBytes)
 }
 
+// generateBalanceSheetReport responds with the balance sheet as JSON or PDF
+func generateBalanceSheetReport(c *gin.Context, req TaxReportRequest) {
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Default to the end of the requested fiscal year
+	asOfDate := resolveFiscalPeriod(company, req.FiscalYear).EndDate
+	if req.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+			return
+		}
+		asOfDate = endDate
+	}
+	if req.AsOfDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.AsOfDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as of date format. Use YYYY-MM-DD"})
+			return
+		}
+		asOfDate = parsed
+	}
+
+	data, err := generateBalanceSheetData(req.CompanyID, asOfDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Format == "json" {
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	pdfBytes, err := generateBalanceSheetPDF(data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set headers for PDF download
+	filename := fmt.Sprintf("Balance_Sheet_%s.pdf", asOfDate.Format("2006-01-02"))
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", strconv.Itoa(len(pdfBytes)))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
 // generateReportData fetches and calculates all data needed for the report
 func generateReportData(req TaxReportRequest) (*TaxReportData, error) {
 	var reportData TaxReportData
 
+	// Get company information
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		return nil, fmt.Errorf("company not found")
+	}
+	reportData.Company = &company
+
 	// Set date range
 	if req.StartDate != "" && req.EndDate != "" {
 		startDate, err := time.Parse("2006-01-02", req.StartDate)
@@ -133,33 +558,97 @@ func generateReportData(req TaxReportRequest) (*TaxReportData, error) {
 		reportData.StartDate = startDate
 		reportData.EndDate = endDate
 	} else {
-		// Default to fiscal year
-		reportData.StartDate = time.Date(req.FiscalYear, 1, 1, 0, 0, 0, 0, time.UTC)
-		reportData.EndDate = time.Date(req.FiscalYear, 12, 31, 23, 59, 59, 0, time.UTC)
+		// Default to the company's fiscal year
+		period := resolveFiscalPeriod(company, req.FiscalYear)
+		reportData.StartDate = period.StartDate
+		reportData.EndDate = period.EndOfDay()
 	}
 
 	reportData.FiscalYear = req.FiscalYear
+	reportData.Basis = req.Basis
 
-	// Get company information
-	var company models.Company
-	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
-		return nil, fmt.Errorf("company not found")
+	// Get the income recognized in the period under the reporting basis: invoices by issue
+	// date on accrual, the amounts received against them on cash
+	if req.Basis == basisCash {
+		receipts, err := invoiceReceipts(database.DB, req.CompanyID, reportData.StartDate, reportData.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch invoice payments: %v", err)
+		}
+		seen := make(map[uint]bool)
+		for _, receipt := range receipts {
+			invoice := receipt.Invoice
+			reportData.Income = append(reportData.Income, TaxReportIncome{
+				InvoiceID:     invoice.ID,
+				InvoiceNumber: invoice.InvoiceNumber,
+				ClientName:    invoice.Client.Name,
+				Date:          receipt.Date,
+				Source:        receipt.Source,
+				Subtotal:      inCAD(receipt.Subtotal, receipt.Rate),
+				HSTAmount:     inCAD(receipt.HSTAmount, receipt.Rate),
+			})
+			if !seen[invoice.ID] {
+				seen[invoice.ID] = true
+				reportData.Invoices = append(reportData.Invoices, *invoice)
+			}
+		}
+	} else {
+		var invoices []models.Invoice
+		if err := database.DB.Preload("Client").Preload("Items").
+			Where("company_id = ? AND status NOT IN ? AND issue_date >= ? AND issue_date <= ?",
+				req.CompanyID, []string{"draft", "cancelled"}, reportData.StartDate, reportData.EndDate).
+			Order("issue_date, id").
+			Find(&invoices).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch invoices: %v", err)
+		}
+		for _, invoice := range invoices {
+			reportData.Income = append(reportData.Income, TaxReportIncome{
+				InvoiceID:     invoice.ID,
+				InvoiceNumber: invoice.InvoiceNumber,
+				ClientName:    invoice.Client.Name,
+				Date:          invoice.IssueDate,
+				Source:        "invoice",
+				Subtotal:      inCAD(invoice.Subtotal, invoice.ExchangeRate),
+				HSTAmount:     inCAD(invoice.HSTAmount, invoice.ExchangeRate),
+			})
+		}
+		reportData.Invoices = invoices
 	}
-	reportData.Company = &company
 
-	// Get invoices
-	var invoices []models.Invoice
-	query := database.DB.Preload("Client").Preload("Items").
-		Where("company_id = ? AND issue_date >= ? AND issue_date <= ?",
-			req.CompanyID, reportData.StartDate, reportData.EndDate)
-	if err := query.Find(&invoices).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch invoices: %v", err)
+	// Get credit notes, which reduce income and HST collected when issued on accrual, and
+	// as they are applied to invoices or refunded on cash
+	if req.Basis == basisCash {
+		credits, creditNotes, err := creditNoteUses(database.DB, req.CompanyID, reportData.StartDate, reportData.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch credit notes: %v", err)
+		}
+		reportData.Credits = credits
+		reportData.CreditNotes = creditNotes
+	} else {
+		var creditNotes []models.CreditNote
+		if err := database.DB.Preload("Client").Preload("Invoice").
+			Where("company_id = ? AND issue_date >= ? AND issue_date <= ?",
+				req.CompanyID, reportData.StartDate, reportData.EndDate).
+			Order("issue_date, id").
+			Find(&creditNotes).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch credit notes: %v", err)
+		}
+		for _, creditNote := range creditNotes {
+			reportData.Credits = append(reportData.Credits, TaxReportCredit{
+				CreditNoteID:     creditNote.ID,
+				CreditNoteNumber: creditNote.CreditNoteNumber,
+				ClientName:       creditNote.Client.Name,
+				Date:             creditNote.IssueDate,
+				Source:           "issued",
+				Subtotal:         inCAD(creditNote.Subtotal, creditNote.ExchangeRate),
+				HSTAmount:        inCAD(creditNote.HSTAmount, creditNote.ExchangeRate),
+			})
+		}
+		reportData.CreditNotes = creditNotes
 	}
-	reportData.Invoices = invoices
 
 	// Get expenses
 	var expenses []models.Expense
-	query = database.DB.Preload("Category").
+	query := database.DB.Preload("Category").
 		Where("company_id = ? AND expense_date >= ? AND expense_date <= ?",
 			req.CompanyID, reportData.StartDate, reportData.EndDate)
 	if err := query.Find(&expenses).Error; err != nil {
@@ -167,10 +656,69 @@ func generateReportData(req TaxReportRequest) (*TaxReportData, error) {
 	}
 	reportData.Expenses = expenses
 
-	// Get dividends
+	// Get the lines of bills, which are expensed by bill date on accrual and as the bills
+	// are paid on cash
+	if req.Basis == basisCash {
+		billLines, err := billPayments(database.DB, req.CompanyID, reportData.StartDate, reportData.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch bill payments: %v", err)
+		}
+		reportData.BillLines = billLines
+	} else {
+		var billLines []TaxReportBillLine
+		if err := database.DB.Table("bill_items").
+			Select("bills.id AS bill_id, bills.bill_number, bills.bill_date, bills.bill_date AS date, bill_items.description, expense_categories.name AS category, bill_items.amount, bill_items.hst_amount").
+			Joins("JOIN bills ON bills.id = bill_items.bill_id").
+			Joins("LEFT JOIN expense_categories ON expense_categories.id = bill_items.category_id").
+			Where("bills.company_id = ? AND bills.status <> ? AND bills.deleted_at IS NULL", req.CompanyID, "void").
+			Where("bills.bill_date >= ? AND bills.bill_date <= ?", reportData.StartDate, reportData.EndDate).
+			Order("bills.bill_date, bills.id, bill_items.id").
+			Scan(&billLines).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch bills: %v", err)
+		}
+		reportData.BillLines = billLines
+	}
+
+	// Get the exchange gains and losses realized on amounts received in the period against
+	// foreign currency invoices, which are reported separately on the accrual basis
+	if req.Basis == basisAccrual {
+		receipts, err := invoiceReceipts(database.DB, req.CompanyID, reportData.StartDate, reportData.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch foreign currency payments: %v", err)
+		}
+		for _, receipt := range receipts {
+			invoice := receipt.Invoice
+			if invoice.Currency == functionalCurrency {
+				continue
+			}
+			gain := inCAD(receipt.Amount, receipt.Rate) - inCAD(receipt.Amount, invoice.ExchangeRate)
+			if receipt.Source == "paid" {
+				gain = invoiceRealizedFXGain(invoice)
+			}
+			reportData.FXSettlements = append(reportData.FXSettlements, TaxReportFXSettlement{
+				InvoiceID:     invoice.ID,
+				InvoiceNumber: invoice.InvoiceNumber,
+				ClientName:    invoice.Client.Name,
+				Date:          receipt.Date,
+				Source:        receipt.Source,
+				Currency:      invoice.Currency,
+				Amount:        receipt.Amount,
+				IssueRate:     invoice.ExchangeRate,
+				SettledRate:   receipt.Rate,
+				Gain:          gain,
+			})
+		}
+	}
+
+	// Get dividends recognized in the period under the reporting basis
 	var dividends []models.Dividend
-	query = database.DB.Where("company_id = ? AND declaration_date >= ? AND declaration_date <= ?",
-		req.CompanyID, reportData.StartDate, reportData.EndDate)
+	if req.Basis == basisCash {
+		query = database.DB.Where("company_id = ? AND status = ? AND COALESCE(payment_date, declaration_date) >= ? AND COALESCE(payment_date, declaration_date) <= ?",
+			req.CompanyID, "paid", reportData.StartDate, reportData.EndDate)
+	} else {
+		query = database.DB.Where("company_id = ? AND declaration_date >= ? AND declaration_date <= ?",
+			req.CompanyID, reportData.StartDate, reportData.EndDate)
+	}
 	if err := query.Find(&dividends).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch dividends: %v", err)
 	}
@@ -212,23 +760,38 @@ func generateReportData(req TaxReportRequest) (*TaxReportData, error) {
 func calculateTaxReportSummary(data *TaxReportData) TaxReportSummary {
 	var summary TaxReportSummary
 
-	// Calculate income from paid invoices
-	for _, invoice := range data.Invoices {
-		if invoice.Status == "paid" {
-			summary.GrossIncome += invoice.Subtotal
-			summary.HSTCollected += invoice.HSTAmount
-		}
+	// Calculate income in CAD recognized under the reporting basis
+	for _, income := range data.Income {
+		summary.GrossIncome += income.Subtotal
+		summary.HSTCollected += income.HSTAmount
+	}
+
+	// Deduct credit notes at the rate of the invoice they credit
+	for _, credit := range data.Credits {
+		summary.CreditNotes += credit.Subtotal
+		summary.CreditNoteHST += credit.HSTAmount
 	}
+	summary.GrossIncome -= summary.CreditNotes
+	summary.HSTCollected -= summary.CreditNoteHST
 
-	// Calculate expenses
+	// Calculate realized foreign exchange gains and losses
+	for _, settlement := range data.FXSettlements {
+		summary.ForeignExchangeGain += settlement.Gain
+	}
+
+	// Calculate expenses in CAD
 	for _, expense := range data.Expenses {
-		summary.TotalExpenses += expense.Amount
-		summary.HSTPaid += expense.HSTPaid
+		summary.TotalExpenses += inCAD(expense.Amount, expense.ExchangeRate)
+		summary.HSTPaid += inCAD(expense.HSTPaid, expense.ExchangeRate)
+	}
+	for _, line := range data.BillLines {
+		summary.TotalExpenses += line.Amount
+		summary.HSTPaid += line.HSTAmount
 	}
 
 	// Calculate dividends
 	for _, dividend := range data.Dividends {
-		if dividend.Status == "paid" {
+		if _, recognized := dividendRecognitionDate(dividend, data.Basis); recognized {
 			summary.TotalDividends += dividend.Amount
 		}
 	}
@@ -242,17 +805,17 @@ func calculateTaxReportSummary(data *TaxReportData) TaxReportSummary {
 		}
 		// Calculate CCA for the year
 		if asset.PurchaseDate.Before(data.EndDate) {
-			summary.CapitalCostAllowance += asset.DepreciableAmount * asset.CCARate
+			summary.CapitalCostAllowance += asset.DepreciableAmount.MulRate(asset.CCARate)
 		}
 	}
 
 	// Calculate tax and net income
-	summary.NetIncomeBeforeTax = summary.GrossIncome - summary.TotalExpenses - summary.TotalDepreciation
+	summary.NetIncomeBeforeTax = summary.GrossIncome + summary.ForeignExchangeGain - summary.TotalExpenses - summary.TotalDepreciation
 	smallBusinessRate := 0.125 // 12.5% default, should come from company settings
 	if data.Company != nil && data.Company.SmallBusinessRate > 0 {
 		smallBusinessRate = data.Company.SmallBusinessRate
 	}
-	summary.SmallBusinessTax = summary.NetIncomeBeforeTax * smallBusinessRate
+	summary.SmallBusinessTax = summary.NetIncomeBeforeTax.MulRate(smallBusinessRate)
 	summary.NetIncomeAfterTax = summary.NetIncomeBeforeTax - summary.SmallBusinessTax
 	summary.HSTRemittance = summary.HSTCollected - summary.HSTPaid
 	summary.RetainedEarnings = summary.NetIncomeAfterTax - summary.TotalDividends
@@ -283,7 +846,7 @@ func generateComprehensiveTaxReportPDF(data *TaxReportData) ([]byte, error) {
 		}
 	}
 
-	pdf.Cell(0, 7, fmt.Sprintf("Fiscal Year: %d", data.FiscalYear))
+	pdf.Cell(0, 7, fmt.Sprintf("Fiscal Year: %d (%s basis)", data.FiscalYear, data.Basis))
 	pdf.Cell(0, 7, fmt.Sprintf("Report Period: %s to %s",
 		data.StartDate.Format("January 2, 2006"),
 		data.EndDate.Format("January 2, 2006")))
@@ -300,40 +863,44 @@ func generateComprehensiveTaxReportPDF(data *TaxReportData) ([]byte, error) {
 
 	// Create a summary table
 	pdf.Cell(80, 8, "Gross Revenue:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.GrossIncome))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.GrossIncome))
+	pdf.Ln(8)
+
+	pdf.Cell(80, 8, "Realized Foreign Exchange Gain/Loss:")
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.ForeignExchangeGain))
 	pdf.Ln(8)
 
 	pdf.Cell(80, 8, "Total Business Expenses:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.TotalExpenses))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.TotalExpenses))
 	pdf.Ln(8)
 
 	pdf.Cell(80, 8, "Depreciation/CCA:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.TotalDepreciation))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.TotalDepreciation))
 	pdf.Ln(8)
 
 	pdf.SetFont("Arial", "B", 11)
 	pdf.Cell(80, 8, "Net Income Before Tax:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.NetIncomeBeforeTax))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.NetIncomeBeforeTax))
 	pdf.Ln(8)
 
 	pdf.SetFont("Arial", "", 11)
 	pdf.Cell(80, 8, "Small Business Tax:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.SmallBusinessTax))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.SmallBusinessTax))
 	pdf.Ln(8)
 
 	pdf.SetFont("Arial", "B", 11)
 	pdf.Cell(80, 8, "Net Income After Tax:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.NetIncomeAfterTax))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.NetIncomeAfterTax))
 	pdf.Ln(8)
 
 	pdf.SetFont("Arial", "", 11)
 	pdf.Cell(80, 8, "Dividends Paid:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.TotalDividends))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.TotalDividends))
 	pdf.Ln(8)
 
 	pdf.SetFont("Arial", "B", 11)
 	pdf.Cell(80, 8, "Retained Earnings:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.RetainedEarnings))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.RetainedEarnings))
 	pdf.Ln(15)
 
 	// HST Summary
@@ -343,16 +910,16 @@ func generateComprehensiveTaxReportPDF(data *TaxReportData) ([]byte, error) {
 
 	pdf.SetFont("Arial", "", 11)
 	pdf.Cell(80, 8, "HST Collected:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.HSTCollected))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.HSTCollected))
 	pdf.Ln(8)
 
 	pdf.Cell(80, 8, "HST Paid (Input Tax Credits):")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.HSTPaid))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.HSTPaid))
 	pdf.Ln(8)
 
 	pdf.SetFont("Arial", "B", 11)
 	pdf.Cell(80, 8, "HST Remittance Due:")
-	pdf.Cell(40, 8, fmt.Sprintf("$%.2f", summary.HSTRemittance))
+	pdf.Cell(40, 8, fmt.Sprintf("$%s", summary.HSTRemittance))
 	pdf.Ln(15)
 
 	// Check if we need a new page
@@ -376,33 +943,42 @@ func generateComprehensiveTaxReportPDF(data *TaxReportData) ([]byte, error) {
 
 	// Table rows
 	pdf.SetFont("Arial", "", 9)
-	for _, invoice := range data.Invoices {
-		if invoice.Status == "paid" {
-			// Check if we need a new page
-			if pdf.GetY() > 250 {
-				pdf.AddPage()
-				// Reprint header
-				pdf.SetFont("Arial", "B", 10)
-				pdf.CellFormat(25, 8, "Invoice #", "1", 0, "C", false, 0, "")
-				pdf.CellFormat(45, 8, "Client", "1", 0, "C", false, 0, "")
-				pdf.CellFormat(25, 8, "Date", "1", 0, "C", false, 0, "")
-				pdf.CellFormat(25, 8, "Subtotal", "1", 0, "C", false, 0, "")
-				pdf.CellFormat(25, 8, "HST", "1", 0, "C", false, 0, "")
-				pdf.CellFormat(25, 8, "Total", "1", 1, "C", false, 0, "")
-				pdf.SetFont("Arial", "", 9)
-			}
+	for _, income := range data.Income {
+		// Check if we need a new page
+		if pdf.GetY() > 250 {
+			pdf.AddPage()
+			// Reprint header
+			pdf.SetFont("Arial", "B", 10)
+			pdf.CellFormat(25, 8, "Invoice #", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(45, 8, "Client", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(25, 8, "Date", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(25, 8, "Subtotal", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(25, 8, "HST", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(25, 8, "Total", "1", 1, "C", false, 0, "")
+			pdf.SetFont("Arial", "", 9)
+		}
 
-			pdf.CellFormat(25, 7, invoice.InvoiceNumber, "1", 0, "L", false, 0, "")
-			clientName := "Unknown"
-			if invoice.Client.Name != "" {
-				clientName = invoice.Client.Name
-			}
-			pdf.CellFormat(45, 7, clientName, "1", 0, "L", false, 0, "")
-			pdf.CellFormat(25, 7, invoice.IssueDate.Format("2006-01-02"), "1", 0, "C", false, 0, "")
-			pdf.CellFormat(25, 7, fmt.Sprintf("$%.2f", invoice.Subtotal), "1", 0, "R", false, 0, "")
-			pdf.CellFormat(25, 7, fmt.Sprintf("$%.2f", invoice.HSTAmount), "1", 0, "R", false, 0, "")
-			pdf.CellFormat(25, 7, fmt.Sprintf("$%.2f", invoice.Total), "1", 1, "R", false, 0, "")
+		pdf.CellFormat(25, 7, income.InvoiceNumber, "1", 0, "L", false, 0, "")
+		clientName := "Unknown"
+		if income.ClientName != "" {
+			clientName = income.ClientName
+		}
+		pdf.CellFormat(45, 7, clientName, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(25, 7, income.Date.Format("2006-01-02"), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("$%s", income.Subtotal), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("$%s", income.HSTAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("$%s", income.Subtotal+income.HSTAmount), "1", 1, "R", false, 0, "")
+	}
+	for _, credit := range data.Credits {
+		if pdf.GetY() > 250 {
+			pdf.AddPage()
 		}
+		pdf.CellFormat(25, 7, credit.CreditNoteNumber, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(45, 7, credit.ClientName, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(25, 7, credit.Date.Format("2006-01-02"), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("-$%s", credit.Subtotal), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("-$%s", credit.HSTAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("-$%s", credit.Subtotal+credit.HSTAmount), "1", 1, "R", false, 0, "")
 	}
 	pdf.Ln(10)
 
@@ -447,8 +1023,18 @@ func generateComprehensiveTaxReportPDF(data *TaxReportData) ([]byte, error) {
 			categoryName = expense.Category.Name
 		}
 		pdf.CellFormat(30, 7, categoryName, "1", 0, "L", false, 0, "")
-		pdf.CellFormat(25, 7, fmt.Sprintf("$%.2f", expense.Amount), "1", 0, "R", false, 0, "")
-		pdf.CellFormat(25, 7, fmt.Sprintf("$%.2f", expense.HSTPaid), "1", 1, "R", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("$%s", inCAD(expense.Amount, expense.ExchangeRate)), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("$%s", inCAD(expense.HSTPaid, expense.ExchangeRate)), "1", 1, "R", false, 0, "")
+	}
+	for _, line := range data.BillLines {
+		if pdf.GetY() > 250 {
+			pdf.AddPage()
+		}
+		pdf.CellFormat(30, 7, line.Date.Format("2006-01-02"), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(60, 7, fmt.Sprintf("Bill %s: %s", line.BillNumber, line.Description), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 7, line.Category, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("$%s", line.Amount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 7, fmt.Sprintf("$%s", line.HSTAmount), "1", 1, "R", false, 0, "")
 	}
 	pdf.Ln(10)
 
@@ -491,10 +1077,10 @@ func generateComprehensiveTaxReportPDF(data *TaxReportData) ([]byte, error) {
 
 			pdf.CellFormat(50, 7, asset.Description, "1", 0, "L", false, 0, "")
 			pdf.CellFormat(25, 7, asset.PurchaseDate.Format("2006-01-02"), "1", 0, "C", false, 0, "")
-			pdf.CellFormat(25, 7, fmt.Sprintf("$%.2f", asset.PurchaseAmount), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(25, 7, fmt.Sprintf("$%s", asset.PurchaseAmount), "1", 0, "R", false, 0, "")
 			pdf.CellFormat(20, 7, asset.CCAClass, "1", 0, "C", false, 0, "")
 			pdf.CellFormat(25, 7, fmt.Sprintf("%.1f%%", asset.CCARate*100), "1", 0, "C", false, 0, "")
-			pdf.CellFormat(25, 7, fmt.Sprintf("$%.2f", asset.DepreciableAmount*asset.CCARate), "1", 1, "R", false, 0, "")
+			pdf.CellFormat(25, 7, fmt.Sprintf("$%s", asset.DepreciableAmount.MulRate(asset.CCARate)), "1", 1, "R", false, 0, "")
 		}
 		pdf.Ln(10)
 	}
@@ -533,7 +1119,7 @@ func generateComprehensiveTaxReportPDF(data *TaxReportData) ([]byte, error) {
 			}
 
 			pdf.CellFormat(40, 7, dividend.DeclarationDate.Format("2006-01-02"), "1", 0, "C", false, 0, "")
-			pdf.CellFormat(30, 7, fmt.Sprintf("$%.2f", dividend.Amount), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(30, 7, fmt.Sprintf("$%s", dividend.Amount), "1", 0, "R", false, 0, "")
 			pdf.CellFormat(30, 7, dividend.Status, "1", 0, "C", false, 0, "")
 			notes := ""
 			if dividend.Notes != nil {
@@ -575,7 +1161,7 @@ func generatePandLReportPDF(data *TaxReportData) ([]byte, error) {
 	}
 
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Fiscal Year: %d", data.FiscalYear))
+	pdf.Cell(0, 6, fmt.Sprintf("Fiscal Year: %d (%s basis)", data.FiscalYear, data.Basis))
 	pdf.Cell(0, 6, fmt.Sprintf("Report Period: %s to %s",
 		data.StartDate.Format("January 2, 2006"),
 		data.EndDate.Format("January 2, 2006")))
@@ -587,50 +1173,51 @@ func generatePandLReportPDF(data *TaxReportData) ([]byte, error) {
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "INCOME")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Gross Revenue: $%.2f", summary.GrossIncome))
+	pdf.Cell(0, 6, fmt.Sprintf("Gross Revenue: $%s", summary.GrossIncome))
+	pdf.Cell(0, 6, fmt.Sprintf("Realized Foreign Exchange Gain/Loss: $%s", summary.ForeignExchangeGain))
 	pdf.Ln(5)
 
 	// Expenses Section
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "EXPENSES")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Total Business Expenses: $%.2f", summary.TotalExpenses))
-	pdf.Cell(0, 6, fmt.Sprintf("Depreciation/CCA: $%.2f", summary.TotalDepreciation))
+	pdf.Cell(0, 6, fmt.Sprintf("Total Business Expenses: $%s", summary.TotalExpenses))
+	pdf.Cell(0, 6, fmt.Sprintf("Depreciation/CCA: $%s", summary.TotalDepreciation))
 	pdf.Ln(5)
 
 	// Net Income Section
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "NET INCOME BEFORE TAX")
 	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("$%.2f", summary.NetIncomeBeforeTax))
+	pdf.Cell(0, 6, fmt.Sprintf("$%s", summary.NetIncomeBeforeTax))
 	pdf.Ln(5)
 
 	// Tax Section
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "TAXES")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Small Business Tax: $%.2f", summary.SmallBusinessTax))
+	pdf.Cell(0, 6, fmt.Sprintf("Small Business Tax: $%s", summary.SmallBusinessTax))
 	pdf.Ln(5)
 
 	// Net Income After Tax
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "NET INCOME AFTER TAX")
 	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("$%.2f", summary.NetIncomeAfterTax))
+	pdf.Cell(0, 6, fmt.Sprintf("$%s", summary.NetIncomeAfterTax))
 	pdf.Ln(5)
 
 	// Dividends
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "DIVIDENDS PAID")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Total Dividends: $%.2f", summary.TotalDividends))
+	pdf.Cell(0, 6, fmt.Sprintf("Total Dividends: $%s", summary.TotalDividends))
 	pdf.Ln(5)
 
 	// Retained Earnings
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "RETAINED EARNINGS")
 	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("$%.2f", summary.RetainedEarnings))
+	pdf.Cell(0, 6, fmt.Sprintf("$%s", summary.RetainedEarnings))
 
 	// Output to bytes buffer
 	var buf bytes.Buffer
@@ -657,7 +1244,7 @@ func generateHSTReportPDF(data *TaxReportData) ([]byte, error) {
 	}
 
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Fiscal Year: %d", data.FiscalYear))
+	pdf.Cell(0, 6, fmt.Sprintf("Fiscal Year: %d (%s basis)", data.FiscalYear, data.Basis))
 	pdf.Cell(0, 6, fmt.Sprintf("Report Period: %s to %s",
 		data.StartDate.Format("January 2, 2006"),
 		data.EndDate.Format("January 2, 2006")))
@@ -669,9 +1256,12 @@ func generateHSTReportPDF(data *TaxReportData) ([]byte, error) {
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "HST SUMMARY")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("HST Collected: $%.2f", summary.HSTCollected))
-	pdf.Cell(0, 6, fmt.Sprintf("HST Paid (Input Tax Credits): $%.2f", summary.HSTPaid))
-	pdf.Cell(0, 6, fmt.Sprintf("HST Remittance Due: $%.2f", summary.HSTRemittance))
+	pdf.Cell(0, 6, fmt.Sprintf("HST Collected: $%s", summary.HSTCollected))
+	if summary.CreditNoteHST != 0 {
+		pdf.Cell(0, 6, fmt.Sprintf("(net of $%s HST on credit notes)", summary.CreditNoteHST))
+	}
+	pdf.Cell(0, 6, fmt.Sprintf("HST Paid (Input Tax Credits): $%s", summary.HSTPaid))
+	pdf.Cell(0, 6, fmt.Sprintf("HST Remittance Due: $%s", summary.HSTRemittance))
 	pdf.Ln(10)
 
 	// Monthly Breakdown
@@ -686,29 +1276,39 @@ func generateHSTReportPDF(data *TaxReportData) ([]byte, error) {
 	pdf.Cell(30, 6, "Net HST")
 	pdf.Ln(6)
 
-	// Generate monthly breakdown
-	for month := 1; month <= 12; month++ {
-		monthStart := time.Date(data.FiscalYear, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-		monthEnd := monthStart.AddDate(0, 1, -1)
+	// Generate monthly breakdown over the months of the report period
+	firstMonth := time.Date(data.StartDate.Year(), data.StartDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for monthStart := firstMonth; !monthStart.After(data.EndDate); monthStart = monthStart.AddDate(0, 1, 0) {
+		nextMonth := monthStart.AddDate(0, 1, 0)
 
-		var monthHSTCollected, monthHSTPaid float64
+		var monthHSTCollected, monthHSTPaid models.Money
 
-		for _, invoice := range data.Invoices {
-			if invoice.Status == "paid" && invoice.IssueDate.After(monthStart) && invoice.IssueDate.Before(monthEnd) {
-				monthHSTCollected += invoice.HSTAmount
+		for _, income := range data.Income {
+			if !income.Date.Before(monthStart) && income.Date.Before(nextMonth) {
+				monthHSTCollected += income.HSTAmount
+			}
+		}
+		for _, credit := range data.Credits {
+			if !credit.Date.Before(monthStart) && credit.Date.Before(nextMonth) {
+				monthHSTCollected -= credit.HSTAmount
 			}
 		}
 
 		for _, expense := range data.Expenses {
-			if expense.ExpenseDate.After(monthStart) && expense.ExpenseDate.Before(monthEnd) {
-				monthHSTPaid += expense.HSTPaid
+			if !expense.ExpenseDate.Before(monthStart) && expense.ExpenseDate.Before(nextMonth) {
+				monthHSTPaid += inCAD(expense.HSTPaid, expense.ExchangeRate)
+			}
+		}
+		for _, line := range data.BillLines {
+			if !line.Date.Before(monthStart) && line.Date.Before(nextMonth) {
+				monthHSTPaid += line.HSTAmount
 			}
 		}
 
 		pdf.Cell(30, 6, monthStart.Format("Jan 2006"))
-		pdf.Cell(30, 6, fmt.Sprintf("$%.2f", monthHSTCollected))
-		pdf.Cell(30, 6, fmt.Sprintf("$%.2f", monthHSTPaid))
-		pdf.Cell(30, 6, fmt.Sprintf("$%.2f", monthHSTCollected-monthHSTPaid))
+		pdf.Cell(30, 6, fmt.Sprintf("$%s", monthHSTCollected))
+		pdf.Cell(30, 6, fmt.Sprintf("$%s", monthHSTPaid))
+		pdf.Cell(30, 6, fmt.Sprintf("$%s", monthHSTCollected-monthHSTPaid))
 		pdf.Ln(6)
 	}
 
@@ -737,7 +1337,7 @@ func generateRetainedEarningsReportPDF(data *TaxReportData) ([]byte, error) {
 	}
 
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Fiscal Year: %d", data.FiscalYear))
+	pdf.Cell(0, 6, fmt.Sprintf("Fiscal Year: %d (%s basis)", data.FiscalYear, data.Basis))
 	pdf.Ln(10)
 
 	summary := data.Summary
@@ -746,11 +1346,11 @@ func generateRetainedEarningsReportPDF(data *TaxReportData) ([]byte, error) {
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(0, 8, "RETAINED EARNINGS CALCULATION")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Net Income After Tax: $%.2f", summary.NetIncomeAfterTax))
-	pdf.Cell(0, 6, fmt.Sprintf("Less: Dividends Paid: $%.2f", summary.TotalDividends))
+	pdf.Cell(0, 6, fmt.Sprintf("Net Income After Tax: $%s", summary.NetIncomeAfterTax))
+	pdf.Cell(0, 6, fmt.Sprintf("Less: Dividends Paid: $%s", summary.TotalDividends))
 	pdf.Ln(5)
 	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(0, 6, fmt.Sprintf("Retained Earnings: $%.2f", summary.RetainedEarnings))
+	pdf.Cell(0, 6, fmt.Sprintf("Retained Earnings: $%s", summary.RetainedEarnings))
 	pdf.Ln(10)
 
 	// Dividend Details
@@ -769,7 +1369,7 @@ func generateRetainedEarningsReportPDF(data *TaxReportData) ([]byte, error) {
 		// Table rows
 		for _, dividend := range data.Dividends {
 			pdf.Cell(40, 6, dividend.DeclarationDate.Format("2006-01-02"))
-			pdf.Cell(30, 6, fmt.Sprintf("$%.2f", dividend.Amount))
+			pdf.Cell(30, 6, fmt.Sprintf("$%s", dividend.Amount))
 			pdf.Cell(30, 6, dividend.Status)
 			notes := ""
 			if dividend.Notes != nil {
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"accounting-backend/database"
+
+	"gorm.io/gorm"
+)
+
+// schedulerJob is work the in-process scheduler runs periodically. Jobs must be safe to
+// run again after a restart or a failure partway through.
+type schedulerJob struct {
+	name string
+	run  func(db *gorm.DB, today time.Time) error
+}
+
+// schedulerJobs are the jobs the scheduler runs, in order
+var schedulerJobs = []schedulerJob{
+	{name: "recurring templates", run: materializeRecurringTemplates},
+	{name: "estimate expiry", run: expireEstimates},
+	{name: "recurring invoices", run: generateRecurringInvoices},
+	{name: "overdue invoices", run: markOverdueInvoices},
+}
+
+// schedulerMutex keeps scheduler runs from overlapping
+var schedulerMutex sync.Mutex
+
+// StartScheduler runs the scheduled jobs in the background, once at startup and then at
+// every interval
+func StartScheduler(interval time.Duration) {
+	go func() {
+		runSchedulerJobs()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runSchedulerJobs()
+		}
+	}()
+}
+
+// runSchedulerJobs runs every scheduled job, logging the ones that fail
+func runSchedulerJobs() {
+	schedulerMutex.Lock()
+	defer schedulerMutex.Unlock()
+
+	today := currentDate()
+	for _, job := range schedulerJobs {
+		if err := job.run(database.DB, today); err != nil {
+			log.Printf("Scheduler: %s failed: %v", job.name, err)
+		}
+	}
+}
+
+// currentDate returns today's date at midnight UTC, the form dates are stored in
+func currentDate() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
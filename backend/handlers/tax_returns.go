@@ -12,31 +12,31 @@ import (
 
 // CreateTaxReturnRequest represents a request to create a tax return
 type CreateTaxReturnRequest struct {
-	FiscalYear         int     `json:"fiscal_year" binding:"required,min=2000,max=2100"`
-	GrossIncome        float64 `json:"gross_income" binding:"required,min=0"`
-	TotalExpenses      float64 `json:"total_expenses" binding:"required,min=0"`
-	NetIncomeBeforeTax float64 `json:"net_income_before_tax" binding:"required"`
-	SmallBusinessTax   float64 `json:"small_business_tax" binding:"required,min=0"`
-	NetIncomeAfterTax  float64 `json:"net_income_after_tax" binding:"required"`
-	HSTCollected       float64 `json:"hst_collected" binding:"required,min=0"`
-	HSTPaid            float64 `json:"hst_paid" binding:"required,min=0"`
-	HSTRemittance      float64 `json:"hst_remittance" binding:"required"`
-	RetainedEarnings   float64 `json:"retained_earnings" binding:"required"`
-	CompanyID          uint    `json:"company_id" binding:"required"`
+	FiscalYear         int          `json:"fiscal_year" binding:"required,min=2000,max=2100"`
+	GrossIncome        models.Money `json:"gross_income" binding:"required,min=0"`
+	TotalExpenses      models.Money `json:"total_expenses" binding:"required,min=0"`
+	NetIncomeBeforeTax models.Money `json:"net_income_before_tax" binding:"required"`
+	SmallBusinessTax   models.Money `json:"small_business_tax" binding:"required,min=0"`
+	NetIncomeAfterTax  models.Money `json:"net_income_after_tax" binding:"required"`
+	HSTCollected       models.Money `json:"hst_collected" binding:"required,min=0"`
+	HSTPaid            models.Money `json:"hst_paid" binding:"required,min=0"`
+	HSTRemittance      models.Money `json:"hst_remittance" binding:"required"`
+	RetainedEarnings   models.Money `json:"retained_earnings" binding:"required"`
+	CompanyID          uint         `json:"company_id" binding:"required"`
 }
 
 // UpdateTaxReturnRequest represents a request to update a tax return
 type UpdateTaxReturnRequest struct {
-	FiscalYear         *int     `json:"fiscal_year,omitempty" binding:"omitempty,min=2000,max=2100"`
-	GrossIncome        *float64 `json:"gross_income,omitempty" binding:"omitempty,min=0"`
-	TotalExpenses      *float64 `json:"total_expenses,omitempty" binding:"omitempty,min=0"`
-	NetIncomeBeforeTax *float64 `json:"net_income_before_tax,omitempty"`
-	SmallBusinessTax   *float64 `json:"small_business_tax,omitempty" binding:"omitempty,min=0"`
-	NetIncomeAfterTax  *float64 `json:"net_income_after_tax,omitempty"`
-	HSTCollected       *float64 `json:"hst_collected,omitempty" binding:"omitempty,min=0"`
-	HSTPaid            *float64 `json:"hst_paid,omitempty" binding:"omitempty,min=0"`
-	HSTRemittance      *float64 `json:"hst_remittance,omitempty"`
-	RetainedEarnings   *float64 `json:"retained_earnings,omitempty"`
+	FiscalYear         *int          `json:"fiscal_year,omitempty" binding:"omitempty,min=2000,max=2100"`
+	GrossIncome        *models.Money `json:"gross_income,omitempty" binding:"omitempty,min=0"`
+	TotalExpenses      *models.Money `json:"total_expenses,omitempty" binding:"omitempty,min=0"`
+	NetIncomeBeforeTax *models.Money `json:"net_income_before_tax,omitempty"`
+	SmallBusinessTax   *models.Money `json:"small_business_tax,omitempty" binding:"omitempty,min=0"`
+	NetIncomeAfterTax  *models.Money `json:"net_income_after_tax,omitempty"`
+	HSTCollected       *models.Money `json:"hst_collected,omitempty" binding:"omitempty,min=0"`
+	HSTPaid            *models.Money `json:"hst_paid,omitempty" binding:"omitempty,min=0"`
+	HSTRemittance      *models.Money `json:"hst_remittance,omitempty"`
+	RetainedEarnings   *models.Money `json:"retained_earnings,omitempty"`
 }
 
 // CreateTaxReturn creates a new tax return
@@ -61,9 +61,14 @@ func CreateTaxReturn(c *gin.Context) {
 		return
 	}
 
+	// Resolve the fiscal period from the company's year end
+	period := resolveFiscalPeriod(company, req.FiscalYear)
+
 	// Create tax return
 	taxReturn := models.TaxReturn{
 		FiscalYear:         req.FiscalYear,
+		PeriodStart:        period.StartDate,
+		PeriodEnd:          period.EndDate,
 		GrossIncome:        req.GrossIncome,
 		TotalExpenses:      req.TotalExpenses,
 		NetIncomeBeforeTax: req.NetIncomeBeforeTax,
@@ -130,6 +135,16 @@ func UpdateTaxReturn(c *gin.Context) {
 			return
 		}
 		updates["fiscal_year"] = *req.FiscalYear
+
+		// Resolve the new fiscal period from the company's year end
+		var company models.Company
+		if err := database.DB.First(&company, taxReturn.CompanyID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+			return
+		}
+		period := resolveFiscalPeriod(company, *req.FiscalYear)
+		updates["period_start"] = period.StartDate
+		updates["period_end"] = period.EndDate
 	}
 	if req.GrossIncome != nil {
 		updates["gross_income"] = *req.GrossIncome
@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"accounting-backend/database"
+	"accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hstNumberPattern matches a GST/HST registration number: a 9-digit business number,
+// optionally with its RT program account
+var hstNumberPattern = regexp.MustCompile(`^[0-9]{9}(RT[0-9]{4})?$`)
+
+// normalizeHSTNumber removes spaces and dashes from an HST registration number and
+// checks its format. Empty numbers are returned as nil.
+func normalizeHSTNumber(number *string) (*string, error) {
+	if number == nil {
+		return nil, nil
+	}
+	normalized := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(*number))
+	if normalized == "" {
+		return nil, nil
+	}
+	if !hstNumberPattern.MatchString(normalized) {
+		return nil, fmt.Errorf("HST number must be a 9-digit business number followed by RT and 4 digits, e.g. 123456789RT0001")
+	}
+	return &normalized, nil
+}
+
+// findCompanyVendor loads a vendor of a company. It responds with an error and returns
+// false when the company has no such vendor.
+func findCompanyVendor(c *gin.Context, vendorID, companyID uint) (*models.Vendor, bool) {
+	var vendor models.Vendor
+	if err := database.DB.Where("company_id = ?", companyID).First(&vendor, vendorID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vendor not found"})
+		return nil, false
+	}
+	return &vendor, true
+}
+
+// ListVendors lists the vendors of a company
+func ListVendors(c *gin.Context) {
+	var vendors []models.Vendor
+
+	// Get pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	// Get search and company filter parameters
+	search := c.Query("search")
+	companyID := c.Query("company_id")
+
+	query := database.DB.Preload("DefaultCategory").Model(&models.Vendor{})
+
+	// Apply search filter if provided
+	if search != "" {
+		query = query.Where("name ILIKE ? OR contact_person ILIKE ? OR email ILIKE ?",
+			"%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+
+	// Apply company filter if provided
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count vendors"})
+		return
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Order("name").Find(&vendors).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendors"})
+		return
+	}
+
+	response := gin.H{
+		"data":       vendors,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateVendor creates a new vendor
+func CreateVendor(c *gin.Context) {
+	var req models.CreateVendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify company exists
+	var company models.Company
+	if err := database.DB.First(&company, req.CompanyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Verify default category exists if provided
+	if req.DefaultCategoryID != nil {
+		var category models.ExpenseCategory
+		if err := database.DB.First(&category, *req.DefaultCategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+			return
+		}
+	}
+
+	hstNumber, err := normalizeHSTNumber(req.HSTNumber)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Check for a vendor with the same name
+	var count int64
+	database.DB.Model(&models.Vendor{}).Where("company_id = ? AND LOWER(name) = LOWER(?)", req.CompanyID, req.Name).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A vendor with this name already exists"})
+		return
+	}
+
+	// Create vendor
+	vendor := models.Vendor{
+		Name:              req.Name,
+		ContactPerson:     req.ContactPerson,
+		Email:             req.Email,
+		Phone:             req.Phone,
+		Address:           req.Address,
+		HSTNumber:         hstNumber,
+		DefaultCategoryID: req.DefaultCategoryID,
+		Notes:             req.Notes,
+		CompanyID:         req.CompanyID,
+	}
+
+	if err := database.DB.Create(&vendor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vendor"})
+		return
+	}
+
+	// Load vendor with default category
+	if err := database.DB.Preload("DefaultCategory").First(&vendor, vendor.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load vendor data"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vendor)
+}
+
+// GetVendor retrieves a vendor by ID
+func GetVendor(c *gin.Context) {
+	vendorID := c.Param("id")
+
+	var vendor models.Vendor
+	if err := database.DB.Preload("DefaultCategory").First(&vendor, vendorID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, vendor)
+}
+
+// UpdateVendor updates a vendor
+func UpdateVendor(c *gin.Context) {
+	vendorID := c.Param("id")
+
+	var req models.UpdateVendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find vendor
+	var vendor models.Vendor
+	if err := database.DB.First(&vendor, vendorID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		return
+	}
+
+	// Update fields if provided
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		var count int64
+		database.DB.Model(&models.Vendor{}).
+			Where("company_id = ? AND LOWER(name) = LOWER(?) AND id != ?", vendor.CompanyID, *req.Name, vendor.ID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A vendor with this name already exists"})
+			return
+		}
+		updates["name"] = *req.Name
+	}
+	if req.ContactPerson != nil {
+		updates["contact_person"] = *req.ContactPerson
+	}
+	if req.Email != nil {
+		updates["email"] = *req.Email
+	}
+	if req.Phone != nil {
+		updates["phone"] = *req.Phone
+	}
+	if req.Address != nil {
+		updates["address"] = *req.Address
+	}
+	if req.HSTNumber != nil {
+		hstNumber, err := normalizeHSTNumber(req.HSTNumber)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["hst_number"] = hstNumber
+	}
+	if req.DefaultCategoryID != nil {
+		if *req.DefaultCategoryID != 0 {
+			var category models.ExpenseCategory
+			if err := database.DB.First(&category, *req.DefaultCategoryID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Expense category not found"})
+				return
+			}
+		}
+		updates["default_category_id"] = zeroToNil(req.DefaultCategoryID)
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+
+	if err := database.DB.Model(&vendor).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vendor"})
+		return
+	}
+
+	// Load updated vendor with default category
+	if err := database.DB.Preload("DefaultCategory").First(&vendor, vendor.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated vendor data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, vendor)
+}
+
+// DeleteVendor deletes a vendor
+func DeleteVendor(c *gin.Context) {
+	vendorID := c.Param("id")
+
+	// Find vendor
+	var vendor models.Vendor
+	if err := database.DB.First(&vendor, vendorID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		return
+	}
+
+	// Check if vendor has associated expenses, capital assets or bills
+	var expenseCount, assetCount, billCount int64
+	if err := database.DB.Model(&models.Expense{}).Where("vendor_id = ?", vendor.ID).Count(&expenseCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check vendor dependencies"})
+		return
+	}
+	if err := database.DB.Model(&models.CapitalAsset{}).Where("vendor_id = ?", vendor.ID).Count(&assetCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check vendor dependencies"})
+		return
+	}
+	if err := database.DB.Model(&models.Bill{}).Where("vendor_id = ?", vendor.ID).Count(&billCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check vendor dependencies"})
+		return
+	}
+
+	if expenseCount > 0 || assetCount > 0 || billCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete vendor with associated expenses, capital assets or bills"})
+		return
+	}
+
+	// Soft delete vendor
+	if err := database.DB.Delete(&vendor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vendor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vendor deleted successfully"})
+}
+
+// VendorSpendLine is a vendor's spending over a report period, in CAD
+type VendorSpendLine struct {
+	VendorID     *uint        `json:"vendor_id"` // Not set for spending with no vendor
+	Name         string       `json:"name"`
+	HSTNumber    *string      `json:"hst_number"`
+	ExpenseCount int          `json:"expense_count"`
+	AssetCount   int          `json:"asset_count"`
+	BillCount    int          `json:"bill_count"`
+	Amount       models.Money `json:"amount"` // Before HST
+	HSTPaid      models.Money `json:"hst_paid"`
+	Total        models.Money `json:"total"`
+}
+
+// GetVendorSpendReport totals a company's expenses, capital asset purchases and bills by
+// vendor for the company_id, start_date and end_date query parameters, largest first. Spending
+// with no vendor is totalled on its own line. Use format=csv for a CSV file.
+func GetVendorSpendReport(c *gin.Context) {
+	companyID, startDate, endDate, ok := parseLedgerReportQuery(c)
+	if !ok {
+		return
+	}
+
+	var vendors []models.Vendor
+	if err := database.DB.Where("company_id = ?", companyID).Find(&vendors).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendors"})
+		return
+	}
+	var expenses []models.Expense
+	if err := database.DB.Where("company_id = ? AND expense_date BETWEEN ? AND ?", companyID, startDate, endDate).
+		Find(&expenses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expenses"})
+		return
+	}
+	var assets []models.CapitalAsset
+	if err := database.DB.Where("company_id = ? AND purchase_date BETWEEN ? AND ?", companyID, startDate, endDate).
+		Find(&assets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch capital assets"})
+		return
+	}
+	var bills []models.Bill
+	if err := database.DB.Where("company_id = ? AND status <> ? AND bill_date BETWEEN ? AND ?", companyID, "void", startDate, endDate).
+		Find(&bills).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bills"})
+		return
+	}
+
+	// Total spending by vendor, with key 0 for spending with no vendor
+	lines := make(map[uint]*VendorSpendLine)
+	line := func(vendorID *uint) *VendorSpendLine {
+		var key uint
+		if vendorID != nil {
+			key = *vendorID
+		}
+		if lines[key] == nil {
+			lines[key] = &VendorSpendLine{Name: "No vendor"}
+		}
+		return lines[key]
+	}
+	for _, expense := range expenses {
+		l := line(expense.VendorID)
+		l.ExpenseCount++
+		l.Amount += inCAD(expense.Amount, expense.ExchangeRate)
+		l.HSTPaid += inCAD(expense.HSTPaid, expense.ExchangeRate)
+	}
+	for _, asset := range assets {
+		l := line(asset.VendorID)
+		l.AssetCount++
+		l.Amount += asset.PurchaseAmount
+		l.HSTPaid += asset.HSTPaid
+	}
+	for _, bill := range bills {
+		l := line(&bill.VendorID)
+		l.BillCount++
+		l.Amount += bill.Subtotal
+		l.HSTPaid += bill.HSTAmount
+	}
+	for i := range vendors {
+		if l := lines[vendors[i].ID]; l != nil {
+			l.VendorID = &vendors[i].ID
+			l.Name = vendors[i].Name
+			l.HSTNumber = vendors[i].HSTNumber
+		}
+	}
+
+	report := make([]VendorSpendLine, 0, len(lines))
+	var totalAmount, totalHST models.Money
+	for _, l := range lines {
+		l.Total = l.Amount + l.HSTPaid
+		totalAmount += l.Amount
+		totalHST += l.HSTPaid
+		report = append(report, *l)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Total != report[j].Total {
+			return report[i].Total > report[j].Total
+		}
+		return report[i].Name < report[j].Name
+	})
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"company_id":   companyID,
+			"start_date":   startDate.Format("2006-01-02"),
+			"end_date":     endDate.Format("2006-01-02"),
+			"vendors":      report,
+			"total_amount": totalAmount,
+			"total_hst":    totalHST,
+			"total":        totalAmount + totalHST,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"vendor_id", "name", "hst_number", "expense_count", "asset_count", "bill_count", "amount", "hst_paid", "total"})
+	for _, l := range report {
+		vendorID, hstNumber := "", ""
+		if l.VendorID != nil {
+			vendorID = strconv.FormatUint(uint64(*l.VendorID), 10)
+		}
+		if l.HSTNumber != nil {
+			hstNumber = *l.HSTNumber
+		}
+		writer.Write([]string{
+			vendorID, l.Name, hstNumber,
+			strconv.Itoa(l.ExpenseCount),
+			strconv.Itoa(l.AssetCount),
+			strconv.Itoa(l.BillCount),
+			l.Amount.String(),
+			l.HSTPaid.String(),
+			l.Total.String(),
+		})
+	}
+	writer.Write([]string{"", "Total", "", "", "", "", totalAmount.String(), totalHST.String(), (totalAmount + totalHST).String()})
+	writer.Flush()
+
+	filename := fmt.Sprintf("Vendor_Spend_%s_%s.csv", startDate.Format("20060102"), endDate.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
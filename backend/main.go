@@ -40,6 +40,9 @@ func main() {
 	// Create default expense categories if they don't exist
 	createDefaultExpenseCategories()
 
+	// Create the default chart of accounts for every company
+	createDefaultChartOfAccounts()
+
 	// Initialize file storage service
 	expenseStoragePath := os.Getenv("EXPENSE_STORAGE_PATH")
 	if expenseStoragePath == "" {
@@ -47,6 +50,9 @@ func main() {
 	}
 	handlers.InitializeFileStorage(expenseStoragePath)
 
+	// Start the scheduler that records recurring transactions
+	handlers.StartScheduler(time.Hour)
+
 	// Initialize Gin router
 	r := gin.Default()
 
@@ -110,9 +116,55 @@ func main() {
 			{
 				invoices.GET("", handlers.ListInvoices)
 				invoices.POST("", handlers.CreateInvoice)
+				invoices.GET("/aged-receivables", handlers.GetAgedReceivables)
 				invoices.GET("/:id", handlers.GetInvoice)
 				invoices.PUT("/:id", handlers.UpdateInvoice)
 				invoices.DELETE("/:id", handlers.DeleteInvoice)
+				invoices.GET("/:id/pdf", handlers.GetInvoicePDF)
+			}
+
+			// Payment routes
+			payments := protected.Group("/payments")
+			{
+				payments.GET("", handlers.ListPayments)
+				payments.POST("", handlers.CreatePayment)
+				payments.GET("/:id", handlers.GetPayment)
+				payments.POST("/:id/apply", handlers.ApplyPaymentCredit)
+				payments.DELETE("/:id", handlers.DeletePayment)
+			}
+
+			// Estimate routes
+			estimates := protected.Group("/estimates")
+			{
+				estimates.GET("", handlers.ListEstimates)
+				estimates.POST("", handlers.CreateEstimate)
+				estimates.GET("/win-rate", handlers.GetEstimateWinRate)
+				estimates.GET("/:id", handlers.GetEstimate)
+				estimates.PUT("/:id", handlers.UpdateEstimate)
+				estimates.DELETE("/:id", handlers.DeleteEstimate)
+				estimates.GET("/:id/pdf", handlers.GetEstimatePDF)
+				estimates.POST("/:id/convert", handlers.ConvertEstimate)
+			}
+
+			// Credit note routes
+			creditNotes := protected.Group("/credit-notes")
+			{
+				creditNotes.GET("", handlers.ListCreditNotes)
+				creditNotes.POST("", handlers.CreateCreditNote)
+				creditNotes.GET("/:id", handlers.GetCreditNote)
+				creditNotes.GET("/:id/pdf", handlers.GetCreditNotePDF)
+				creditNotes.POST("/:id/apply", handlers.ApplyCreditNote)
+				creditNotes.POST("/:id/refund", handlers.RefundCreditNote)
+				creditNotes.DELETE("/:id", handlers.DeleteCreditNote)
+			}
+
+			// Invoice template routes
+			invoiceTemplates := protected.Group("/invoice-templates")
+			{
+				invoiceTemplates.GET("/:company_id", handlers.GetInvoiceTemplate)
+				invoiceTemplates.PUT("/:company_id", handlers.UpdateInvoiceTemplate)
+				invoiceTemplates.POST("/:company_id/logo", handlers.UploadInvoiceLogo)
+				invoiceTemplates.DELETE("/:company_id/logo", handlers.DeleteInvoiceLogo)
 			}
 
 			// Expense category routes
@@ -209,10 +261,178 @@ func main() {
 				ownerPayments.GET("/stats", handlers.GetOwnerPaymentStats)
 			}
 
+			// General ledger routes
+			journalEntries := protected.Group("/journal-entries")
+			{
+				journalEntries.GET("", handlers.ListJournalEntries)
+				journalEntries.GET("/:id", handlers.GetJournalEntry)
+				journalEntries.POST("", middleware.RequireAccountantOrAdmin(), handlers.CreateJournalEntry)
+				journalEntries.POST("/:id/reverse", middleware.RequireAccountantOrAdmin(), handlers.ReverseJournalEntry)
+				journalEntries.POST("/backfill", middleware.RequireAdmin(), handlers.BackfillJournal)
+			}
+
+			// Accounting period routes
+			accountingPeriods := protected.Group("/accounting-periods")
+			{
+				accountingPeriods.GET("", handlers.ListAccountingPeriods)
+				accountingPeriods.GET("/:id", handlers.GetAccountingPeriod)
+				accountingPeriods.POST("/close", middleware.RequireAdmin(), handlers.CloseAccountingPeriod)
+				accountingPeriods.POST("/:id/reopen", middleware.RequireAdmin(), handlers.ReopenAccountingPeriod)
+			}
+
+			// Chart of accounts routes
+			accounts := protected.Group("/accounts")
+			{
+				accounts.GET("", handlers.ListAccounts)
+				accounts.POST("", middleware.RequireAccountantOrAdmin(), handlers.CreateAccount)
+				accounts.GET("/:id", handlers.GetAccount)
+				accounts.PUT("/:id", middleware.RequireAccountantOrAdmin(), handlers.UpdateAccount)
+				accounts.DELETE("/:id", middleware.RequireAccountantOrAdmin(), handlers.DeleteAccount)
+				accounts.POST("/seed", middleware.RequireAdmin(), handlers.SeedAccounts)
+			}
+
+			// Bank account and reconciliation routes
+			bankAccounts := protected.Group("/bank-accounts")
+			{
+				bankAccounts.GET("", handlers.ListBankAccounts)
+				bankAccounts.POST("", handlers.CreateBankAccount)
+				bankAccounts.GET("/:id", handlers.GetBankAccount)
+				bankAccounts.PUT("/:id", handlers.UpdateBankAccount)
+				bankAccounts.DELETE("/:id", handlers.DeleteBankAccount)
+				bankAccounts.GET("/:id/reconciliation", handlers.GetBankReconciliation)
+				bankAccounts.POST("/:id/import-ofx", handlers.ImportOFXStatement)
+				bankAccounts.POST("/:id/import-camt053", handlers.ImportCAMT053Statement)
+			}
+
+			bankTransactions := protected.Group("/bank-transactions")
+			{
+				bankTransactions.GET("", handlers.ListBankTransactions)
+				bankTransactions.POST("", handlers.CreateBankTransactions)
+				bankTransactions.POST("/bulk-create-records", handlers.BulkCreateRecordsFromBankTransactions)
+				bankTransactions.DELETE("/:id", handlers.DeleteBankTransaction)
+				bankTransactions.GET("/:id/suggestions", handlers.GetBankTransactionSuggestions)
+				bankTransactions.POST("/:id/match", handlers.MatchBankTransaction)
+				bankTransactions.POST("/:id/unmatch", handlers.UnmatchBankTransaction)
+				bankTransactions.POST("/:id/create-record", handlers.CreateRecordFromBankTransaction)
+			}
+
+			// CSV statement import routes
+			csvImportProfiles := protected.Group("/csv-import-profiles")
+			{
+				csvImportProfiles.GET("", handlers.ListCSVImportProfiles)
+				csvImportProfiles.POST("", handlers.CreateCSVImportProfile)
+				csvImportProfiles.GET("/:id", handlers.GetCSVImportProfile)
+				csvImportProfiles.PUT("/:id", handlers.UpdateCSVImportProfile)
+				csvImportProfiles.DELETE("/:id", handlers.DeleteCSVImportProfile)
+				csvImportProfiles.POST("/:id/preview", handlers.PreviewCSVImport)
+				csvImportProfiles.POST("/:id/import", handlers.ImportCSVStatement)
+			}
+
+			// Categorization rule routes
+			categorizationRules := protected.Group("/categorization-rules")
+			{
+				categorizationRules.GET("", handlers.ListCategorizationRules)
+				categorizationRules.POST("", handlers.CreateCategorizationRule)
+				categorizationRules.POST("/test", handlers.TestCategorizationRules)
+				categorizationRules.POST("/learn", handlers.LearnCategorizationRule)
+				categorizationRules.GET("/:id", handlers.GetCategorizationRule)
+				categorizationRules.PUT("/:id", handlers.UpdateCategorizationRule)
+				categorizationRules.DELETE("/:id", handlers.DeleteCategorizationRule)
+			}
+
+			// Vendor routes
+			vendors := protected.Group("/vendors")
+			{
+				vendors.GET("", handlers.ListVendors)
+				vendors.POST("", handlers.CreateVendor)
+				vendors.GET("/spend", handlers.GetVendorSpendReport)
+				vendors.GET("/:id", handlers.GetVendor)
+				vendors.PUT("/:id", handlers.UpdateVendor)
+				vendors.DELETE("/:id", handlers.DeleteVendor)
+			}
+
+			// Bill routes
+			bills := protected.Group("/bills")
+			{
+				bills.GET("", handlers.ListBills)
+				bills.POST("", handlers.CreateBill)
+				bills.GET("/aged-payables", handlers.GetAgedPayables)
+				bills.GET("/:id", handlers.GetBill)
+				bills.PUT("/:id", handlers.UpdateBill)
+				bills.DELETE("/:id", handlers.DeleteBill)
+				bills.POST("/:id/void", handlers.VoidBill)
+			}
+
+			// Bill payment routes
+			billPayments := protected.Group("/bill-payments")
+			{
+				billPayments.GET("", handlers.ListBillPayments)
+				billPayments.POST("", handlers.CreateBillPayment)
+				billPayments.GET("/:id", handlers.GetBillPayment)
+				billPayments.DELETE("/:id", handlers.DeleteBillPayment)
+			}
+
+			// Project routes
+			projects := protected.Group("/projects")
+			{
+				projects.GET("", handlers.ListProjects)
+				projects.POST("", handlers.CreateProject)
+				projects.GET("/profitability", handlers.GetProjectProfitability)
+				projects.GET("/:id", handlers.GetProject)
+				projects.PUT("/:id", handlers.UpdateProject)
+				projects.DELETE("/:id", handlers.DeleteProject)
+				projects.GET("/:id/profit-and-loss", handlers.GetProjectProfitAndLoss)
+			}
+
+			// Budget routes
+			budgets := protected.Group("/budgets")
+			{
+				budgets.GET("", handlers.ListBudgets)
+				budgets.POST("", handlers.CreateBudget)
+				budgets.GET("/:id", handlers.GetBudget)
+				budgets.PUT("/:id", handlers.UpdateBudget)
+				budgets.DELETE("/:id", handlers.DeleteBudget)
+				budgets.GET("/:id/vs-actual", handlers.GetBudgetVsActual)
+			}
+
+			// Recurring template routes
+			recurringTemplates := protected.Group("/recurring-templates")
+			{
+				recurringTemplates.GET("", handlers.ListRecurringTemplates)
+				recurringTemplates.POST("", handlers.CreateRecurringTemplate)
+				recurringTemplates.GET("/:id", handlers.GetRecurringTemplate)
+				recurringTemplates.PUT("/:id", handlers.UpdateRecurringTemplate)
+				recurringTemplates.DELETE("/:id", handlers.DeleteRecurringTemplate)
+				recurringTemplates.GET("/:id/preview", handlers.PreviewRecurringTemplate)
+			}
+
+			// Recurring invoice routes
+			recurringInvoices := protected.Group("/recurring-invoices")
+			{
+				recurringInvoices.GET("", handlers.ListRecurringInvoices)
+				recurringInvoices.POST("", handlers.CreateRecurringInvoice)
+				recurringInvoices.GET("/:id", handlers.GetRecurringInvoice)
+				recurringInvoices.PUT("/:id", handlers.UpdateRecurringInvoice)
+				recurringInvoices.DELETE("/:id", handlers.DeleteRecurringInvoice)
+				recurringInvoices.GET("/:id/preview", handlers.PreviewRecurringInvoice)
+			}
+
+			// Exchange rate routes
+			exchangeRates := protected.Group("/exchange-rates")
+			{
+				exchangeRates.GET("", handlers.ListExchangeRates)
+				exchangeRates.GET("/lookup", handlers.LookupExchangeRate)
+				exchangeRates.POST("", middleware.RequireAccountantOrAdmin(), handlers.CreateExchangeRate)
+				exchangeRates.POST("/import", middleware.RequireAccountantOrAdmin(), handlers.ImportExchangeRates)
+			}
+
 			// Reports routes
 			reports := protected.Group("/reports")
 			{
 				reports.POST("/tax-report", handlers.GenerateTaxReport)
+				reports.GET("/gifi", handlers.ExportGIFI)
+				reports.GET("/trial-balance", handlers.GetTrialBalance)
+				reports.GET("/general-ledger", handlers.GetGeneralLedger)
 			}
 		}
 	}
@@ -342,6 +562,44 @@ func createDefaultExpenseCategories() {
 	}
 }
 
+// defaultCategoryAccounts maps the default expense categories to their expense accounts
+var defaultCategoryAccounts = map[string]string{
+	"Office Supplies":          "5100",
+	"Travel & Transportation":  "5110",
+	"Meals & Entertainment":    "5120",
+	"Professional Services":    "5130",
+	"Software & Subscriptions": "5140",
+	"Marketing & Advertising":  "5150",
+	"Equipment & Technology":   "5160",
+	"Utilities":                "5170",
+	"Insurance":                "5180",
+	"Other":                    "5900",
+}
+
+// createDefaultChartOfAccounts seeds the default chart of accounts for companies that
+// are missing it and maps unmapped default expense categories to their accounts
+func createDefaultChartOfAccounts() {
+	var companies []models.Company
+	if err := database.DB.Find(&companies).Error; err != nil {
+		log.Printf("Error loading companies: %v", err)
+		return
+	}
+
+	for _, company := range companies {
+		if err := handlers.SeedChartOfAccounts(database.DB, company.ID); err != nil {
+			log.Printf("Error creating chart of accounts for company %d: %v", company.ID, err)
+		}
+	}
+
+	for name, accountCode := range defaultCategoryAccounts {
+		if err := database.DB.Model(&models.ExpenseCategory{}).
+			Where("name = ? AND account_code IS NULL", name).
+			Update("account_code", accountCode).Error; err != nil {
+			log.Printf("Error mapping expense category %s: %v", name, err)
+		}
+	}
+}
+
 // stringPtr returns a pointer to a string
 func stringPtr(s string) *string {
 	return &s
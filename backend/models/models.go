@@ -25,9 +25,11 @@ type Company struct {
 	ID                uint           `json:"id" gorm:"primaryKey"`
 	Name              string         `json:"name" gorm:"not null"`
 	BusinessNumber    string         `json:"business_number" gorm:"uniqueIndex;not null"`
+	Address           *string        `json:"address"` // Mailing address printed on invoices
 	HSTNumber         *string        `json:"hst_number"`
 	HSTRegistered     bool           `json:"hst_registered" gorm:"default:false"` // Can claim Input Tax Credits
-	FiscalYearEnd     time.Time      `json:"fiscal_year_end" gorm:"not null"`
+	FiscalYearEnd     time.Time      `json:"fiscal_year_end" gorm:"not null"`     // Only the month and day are used
+	IncorporationDate *time.Time     `json:"incorporation_date"`                  // Start of the first (possibly short) fiscal year
 	SmallBusinessRate float64        `json:"small_business_rate" gorm:"not null;default:0.15"`
 	HSTRate           float64        `json:"hst_rate" gorm:"not null;default:0.13"`
 	CreatedAt         time.Time      `json:"created_at"`
@@ -37,40 +39,52 @@ type Company struct {
 
 // Client represents a client/customer
 type Client struct {
-	ID            uint           `json:"id" gorm:"primaryKey"`
-	Name          string         `json:"name" gorm:"not null"`
-	ContactPerson *string        `json:"contact_person"`
-	Email         *string        `json:"email"`
-	Phone         *string        `json:"phone"`
-	Address       *string        `json:"address"`
-	HSTExempt     bool           `json:"hst_exempt" gorm:"default:false"`
-	CompanyID     uint           `json:"company_id" gorm:"not null"`
-	Company       Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	Name               string         `json:"name" gorm:"not null"`
+	ContactPerson      *string        `json:"contact_person"`
+	Email              *string        `json:"email"`
+	Phone              *string        `json:"phone"`
+	Address            *string        `json:"address"`
+	HSTExempt          bool           `json:"hst_exempt" gorm:"default:false"`
+	CompanyID          uint           `json:"company_id" gorm:"not null"`
+	Company            Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	OutstandingBalance *Money         `json:"outstanding_balance,omitempty" gorm:"-"` // Owed in CAD today; set when a single client is fetched
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // Invoice represents an invoice
 type Invoice struct {
-	ID            uint           `json:"id" gorm:"primaryKey"`
-	InvoiceNumber string         `json:"invoice_number" gorm:"uniqueIndex;not null"`
-	ClientID      uint           `json:"client_id" gorm:"not null"`
-	Client        Client         `json:"client,omitempty" gorm:"foreignKey:ClientID"`
-	IssueDate     time.Time      `json:"issue_date" gorm:"not null"`
-	DueDate       time.Time      `json:"due_date" gorm:"not null"`
-	Subtotal      float64        `json:"subtotal" gorm:"not null"`
-	HSTAmount     float64        `json:"hst_amount" gorm:"not null"`
-	Total         float64        `json:"total" gorm:"not null"`
-	Status        string         `json:"status" gorm:"not null;default:'draft'"` // draft, sent, paid, overdue, cancelled
-	PaidDate      *time.Time     `json:"paid_date"`
-	Description   *string        `json:"description"`
-	CompanyID     uint           `json:"company_id" gorm:"not null"`
-	Company       Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
-	Items         []InvoiceItem  `json:"items,omitempty" gorm:"foreignKey:InvoiceID"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                 uint                `json:"id" gorm:"primaryKey"`
+	InvoiceNumber      string              `json:"invoice_number" gorm:"uniqueIndex;not null"`
+	ClientID           uint                `json:"client_id" gorm:"not null"`
+	Client             Client              `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	IssueDate          time.Time           `json:"issue_date" gorm:"not null"`
+	DueDate            time.Time           `json:"due_date" gorm:"not null"`
+	Subtotal           Money               `json:"subtotal" gorm:"not null"`
+	HSTAmount          Money               `json:"hst_amount" gorm:"not null"`
+	Total              Money               `json:"total" gorm:"not null"`
+	Currency           string              `json:"currency" gorm:"not null;default:'CAD'"`    // ISO 4217 code of the amounts
+	ExchangeRate       float64             `json:"exchange_rate" gorm:"not null;default:1"`   // CAD per unit of currency on the issue date
+	Status             string              `json:"status" gorm:"not null;default:'draft'"`    // draft, sent, partially_paid, paid, overdue, cancelled
+	AmountPaid         Money               `json:"amount_paid" gorm:"not null;default:0"`     // Sum of the payments allocated to the invoice
+	AmountCredited     Money               `json:"amount_credited" gorm:"not null;default:0"` // Sum of the credit notes applied to the invoice
+	PaidDate           *time.Time          `json:"paid_date"`
+	PaidExchangeRate   *float64            `json:"paid_exchange_rate"` // CAD per unit of currency on the paid date
+	Description        *string             `json:"description"`
+	Notes              *string             `json:"notes"` // Printed at the foot of the invoice
+	ProjectID          *uint               `json:"project_id" gorm:"index"`
+	Project            *Project            `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	EstimateID         *uint               `json:"estimate_id" gorm:"index"`          // Estimate the invoice was converted from
+	RecurringInvoiceID *uint               `json:"recurring_invoice_id" gorm:"index"` // Recurring invoice profile that generated the invoice
+	CompanyID          uint                `json:"company_id" gorm:"not null"`
+	Company            Company             `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Items              []InvoiceItem       `json:"items,omitempty" gorm:"foreignKey:InvoiceID"`
+	Payments           []PaymentAllocation `json:"payments,omitempty" gorm:"foreignKey:InvoiceID"`
+	CreatedAt          time.Time           `json:"created_at"`
+	UpdatedAt          time.Time           `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt      `json:"-" gorm:"index"`
 }
 
 // InvoiceItem represents a line item in an invoice
@@ -80,8 +94,11 @@ type InvoiceItem struct {
 	Invoice     Invoice        `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
 	Description string         `json:"description" gorm:"not null"`
 	Quantity    float64        `json:"quantity" gorm:"not null"`
-	UnitPrice   float64        `json:"unit_price" gorm:"not null"`
-	Total       float64        `json:"total" gorm:"not null"`
+	Unit        *string        `json:"unit"` // e.g. hour, day or each; hours are totalled on project P&Ls
+	UnitPrice   Money          `json:"unit_price" gorm:"not null"`
+	Total       Money          `json:"total" gorm:"not null"`
+	ProjectID   *uint          `json:"project_id" gorm:"index"` // Overrides the invoice's project
+	Project     *Project       `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -92,6 +109,7 @@ type ExpenseCategory struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Name        string         `json:"name" gorm:"not null"`
 	Description *string        `json:"description"`
+	AccountCode *string        `json:"account_code"` // Expense account in the chart of accounts
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -103,11 +121,17 @@ type Expense struct {
 	Description     string          `json:"description" gorm:"not null"`
 	CategoryID      uint            `json:"category_id" gorm:"not null"`
 	Category        ExpenseCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
-	Amount          float64         `json:"amount" gorm:"not null"`
-	HSTPaid         float64         `json:"hst_paid" gorm:"not null"`
+	Amount          Money           `json:"amount" gorm:"not null"`
+	HSTPaid         Money           `json:"hst_paid" gorm:"not null"`
+	Currency        string          `json:"currency" gorm:"not null;default:'CAD'"`  // ISO 4217 code of the amounts
+	ExchangeRate    float64         `json:"exchange_rate" gorm:"not null;default:1"` // CAD per unit of currency on the expense date
 	ExpenseDate     time.Time       `json:"expense_date" gorm:"not null"`
 	ReceiptAttached bool            `json:"receipt_attached" gorm:"default:false"`
 	PaidBy          string          `json:"paid_by" gorm:"not null;default:'corp'"` // "corp" or "owner"
+	VendorID        *uint           `json:"vendor_id" gorm:"index"`
+	Vendor          *Vendor         `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+	ProjectID       *uint           `json:"project_id" gorm:"index"`
+	Project         *Project        `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
 	CompanyID       uint            `json:"company_id" gorm:"not null"`
 	Company         Company         `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
 	Files           []ExpenseFile   `json:"files,omitempty" gorm:"foreignKey:ExpenseID"`
@@ -135,7 +159,7 @@ type ExpenseFile struct {
 // Dividend represents a dividend declaration/payment
 type Dividend struct {
 	ID              uint           `json:"id" gorm:"primaryKey"`
-	Amount          float64        `json:"amount" gorm:"not null"`
+	Amount          Money          `json:"amount" gorm:"not null"`
 	DeclarationDate time.Time      `json:"declaration_date" gorm:"not null"`
 	PaymentDate     *time.Time     `json:"payment_date"`
 	Status          string         `json:"status" gorm:"not null;default:'declared'"` // declared, paid
@@ -149,26 +173,30 @@ type Dividend struct {
 
 // IncomeEntry represents an income entry (from clients or owner capital)
 type IncomeEntry struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Description string         `json:"description" gorm:"not null"`
-	Amount      float64        `json:"amount" gorm:"not null"`
-	HSTAmount   float64        `json:"hst_amount" gorm:"not null"`
-	Total       float64        `json:"total" gorm:"not null"`
-	IncomeType  string         `json:"income_type" gorm:"not null"` // "client", "capital", "other"
-	ClientID    *uint          `json:"client_id"`                   // Optional, only for client income
-	Client      *Client        `json:"client,omitempty" gorm:"foreignKey:ClientID"`
-	IncomeDate  time.Time      `json:"income_date" gorm:"not null"`
-	CompanyID   uint           `json:"company_id" gorm:"not null"`
-	Company     Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Description  string         `json:"description" gorm:"not null"`
+	Amount       Money          `json:"amount" gorm:"not null"`
+	HSTAmount    Money          `json:"hst_amount" gorm:"not null"`
+	Total        Money          `json:"total" gorm:"not null"`
+	Currency     string         `json:"currency" gorm:"not null;default:'CAD'"`  // ISO 4217 code of the amounts
+	ExchangeRate float64        `json:"exchange_rate" gorm:"not null;default:1"` // CAD per unit of currency on the income date
+	IncomeType   string         `json:"income_type" gorm:"not null"`             // "client", "capital", "other"
+	ClientID     *uint          `json:"client_id"`                               // Optional, only for client income
+	Client       *Client        `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	IncomeDate   time.Time      `json:"income_date" gorm:"not null"`
+	ProjectID    *uint          `json:"project_id" gorm:"index"`
+	Project      *Project       `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	CompanyID    uint           `json:"company_id" gorm:"not null"`
+	Company      Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // HSTPayment represents HST payments made to CRA
 type HSTPayment struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
-	Amount      float64        `json:"amount" gorm:"not null"`
+	Amount      Money          `json:"amount" gorm:"not null"`
 	PaymentDate time.Time      `json:"payment_date" gorm:"not null"`
 	PeriodStart time.Time      `json:"period_start" gorm:"not null"`
 	PeriodEnd   time.Time      `json:"period_end" gorm:"not null"`
@@ -185,15 +213,17 @@ type HSTPayment struct {
 type TaxReturn struct {
 	ID                 uint           `json:"id" gorm:"primaryKey"`
 	FiscalYear         int            `json:"fiscal_year" gorm:"not null"`
-	GrossIncome        float64        `json:"gross_income" gorm:"not null"`
-	TotalExpenses      float64        `json:"total_expenses" gorm:"not null"`
-	NetIncomeBeforeTax float64        `json:"net_income_before_tax" gorm:"not null"`
-	SmallBusinessTax   float64        `json:"small_business_tax" gorm:"not null"`
-	NetIncomeAfterTax  float64        `json:"net_income_after_tax" gorm:"not null"`
-	HSTCollected       float64        `json:"hst_collected" gorm:"not null"`
-	HSTPaid            float64        `json:"hst_paid" gorm:"not null"`
-	HSTRemittance      float64        `json:"hst_remittance" gorm:"not null"`
-	RetainedEarnings   float64        `json:"retained_earnings" gorm:"not null"`
+	PeriodStart        time.Time      `json:"period_start"` // Resolved from the company's fiscal year end
+	PeriodEnd          time.Time      `json:"period_end"`
+	GrossIncome        Money          `json:"gross_income" gorm:"not null"`
+	TotalExpenses      Money          `json:"total_expenses" gorm:"not null"`
+	NetIncomeBeforeTax Money          `json:"net_income_before_tax" gorm:"not null"`
+	SmallBusinessTax   Money          `json:"small_business_tax" gorm:"not null"`
+	NetIncomeAfterTax  Money          `json:"net_income_after_tax" gorm:"not null"`
+	HSTCollected       Money          `json:"hst_collected" gorm:"not null"`
+	HSTPaid            Money          `json:"hst_paid" gorm:"not null"`
+	HSTRemittance      Money          `json:"hst_remittance" gorm:"not null"`
+	RetainedEarnings   Money          `json:"retained_earnings" gorm:"not null"`
 	CompanyID          uint           `json:"company_id" gorm:"not null"`
 	Company            Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
 	CreatedAt          time.Time      `json:"created_at"`
@@ -239,48 +269,58 @@ type UpdateUserRequest struct {
 
 // CreateCompanyRequest represents a request to create a company
 type CreateCompanyRequest struct {
-	Name              string    `json:"name" binding:"required"`
-	BusinessNumber    string    `json:"business_number" binding:"required"`
-	HSTNumber         *string   `json:"hst_number,omitempty"`
-	HSTRegistered     bool      `json:"hst_registered"`
-	FiscalYearEnd     time.Time `json:"fiscal_year_end" binding:"required"`
-	SmallBusinessRate float64   `json:"small_business_rate" binding:"required,min=0,max=1"`
-	HSTRate           float64   `json:"hst_rate" binding:"required,min=0,max=1"`
+	Name              string     `json:"name" binding:"required"`
+	BusinessNumber    string     `json:"business_number" binding:"required"`
+	Address           *string    `json:"address,omitempty"`
+	HSTNumber         *string    `json:"hst_number,omitempty"`
+	HSTRegistered     bool       `json:"hst_registered"`
+	FiscalYearEnd     time.Time  `json:"fiscal_year_end" binding:"required"`
+	IncorporationDate *time.Time `json:"incorporation_date,omitempty"`
+	SmallBusinessRate float64    `json:"small_business_rate" binding:"required,min=0,max=1"`
+	HSTRate           float64    `json:"hst_rate" binding:"required,min=0,max=1"`
 }
 
 // UpdateCompanyRequest represents a request to update a company
 type UpdateCompanyRequest struct {
 	Name              *string    `json:"name,omitempty"`
 	BusinessNumber    *string    `json:"business_number,omitempty"`
+	Address           *string    `json:"address,omitempty"`
 	HSTNumber         *string    `json:"hst_number,omitempty"`
 	HSTRegistered     *bool      `json:"hst_registered,omitempty"`
 	FiscalYearEnd     *time.Time `json:"fiscal_year_end,omitempty"`
+	IncorporationDate *time.Time `json:"incorporation_date,omitempty"`
 	SmallBusinessRate *float64   `json:"small_business_rate,omitempty" binding:"omitempty,min=0,max=1"`
 	HSTRate           *float64   `json:"hst_rate,omitempty" binding:"omitempty,min=0,max=1"`
 }
 
 // CreateIncomeEntryRequest represents a request to create an income entry
 type CreateIncomeEntryRequest struct {
-	Description string  `json:"description" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,min=0"`
-	IncomeType  string  `json:"income_type" binding:"required,oneof=client capital other"`
-	ClientID    *uint   `json:"client_id,omitempty"`
-	IncomeDate  string  `json:"income_date" binding:"required"`
-	CompanyID   uint    `json:"company_id" binding:"required"`
+	Description  string   `json:"description" binding:"required"`
+	Amount       Money    `json:"amount" binding:"required,min=0"`
+	Currency     string   `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	ExchangeRate *float64 `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"`
+	IncomeType   string   `json:"income_type" binding:"required,oneof=client capital other"`
+	ClientID     *uint    `json:"client_id,omitempty"`
+	IncomeDate   string   `json:"income_date" binding:"required"`
+	ProjectID    *uint    `json:"project_id,omitempty"`
+	CompanyID    uint     `json:"company_id" binding:"required"`
 }
 
 // UpdateIncomeEntryRequest represents a request to update an income entry
 type UpdateIncomeEntryRequest struct {
-	Description *string  `json:"description,omitempty"`
-	Amount      *float64 `json:"amount,omitempty" binding:"omitempty,min=0"`
-	IncomeType  *string  `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
-	ClientID    *uint    `json:"client_id,omitempty"`
-	IncomeDate  *string  `json:"income_date,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	Amount       *Money   `json:"amount,omitempty" binding:"omitempty,min=0"`
+	Currency     *string  `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	ExchangeRate *float64 `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"`
+	IncomeType   *string  `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	ClientID     *uint    `json:"client_id,omitempty"`
+	IncomeDate   *string  `json:"income_date,omitempty"`
+	ProjectID    *uint    `json:"project_id,omitempty"` // 0 to clear
 }
 
 // CreateHSTPaymentRequest represents a request to create an HST payment
 type CreateHSTPaymentRequest struct {
-	Amount      float64   `json:"amount" binding:"required,min=0"`
+	Amount      Money     `json:"amount" binding:"required,min=0"`
 	PaymentDate time.Time `json:"payment_date" binding:"required"`
 	PeriodStart time.Time `json:"period_start" binding:"required"`
 	PeriodEnd   time.Time `json:"period_end" binding:"required"`
@@ -291,7 +331,7 @@ type CreateHSTPaymentRequest struct {
 
 // UpdateHSTPaymentRequest represents a request to update an HST payment
 type UpdateHSTPaymentRequest struct {
-	Amount      *float64   `json:"amount,omitempty" binding:"omitempty,min=0"`
+	Amount      *Money     `json:"amount,omitempty" binding:"omitempty,min=0"`
 	PaymentDate *time.Time `json:"payment_date,omitempty"`
 	PeriodStart *time.Time `json:"period_start,omitempty"`
 	PeriodEnd   *time.Time `json:"period_end,omitempty"`
@@ -306,18 +346,22 @@ type CapitalAsset struct {
 	CategoryID              uint                `json:"category_id" gorm:"not null"`
 	Category                ExpenseCategory     `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
 	PurchaseDate            time.Time           `json:"purchase_date" gorm:"not null"`
-	PurchaseAmount          float64             `json:"purchase_amount" gorm:"not null"`
-	HSTPaid                 float64             `json:"hst_paid" gorm:"not null"`
-	TotalCost               float64             `json:"total_cost" gorm:"not null"`         // Purchase amount + HST
+	PurchaseAmount          Money               `json:"purchase_amount" gorm:"not null"`
+	HSTPaid                 Money               `json:"hst_paid" gorm:"not null"`
+	TotalCost               Money               `json:"total_cost" gorm:"not null"`         // Purchase amount + HST
 	CCAClass                string              `json:"cca_class" gorm:"not null"`          // CCA class (e.g., "10", "12", "50")
 	CCARate                 float64             `json:"cca_rate" gorm:"not null"`           // CCA rate as decimal (e.g., 0.20 for 20%)
-	DepreciableAmount       float64             `json:"depreciable_amount" gorm:"not null"` // Amount eligible for depreciation
-	AccumulatedDepreciation float64             `json:"accumulated_depreciation" gorm:"default:0"`
-	BookValue               float64             `json:"book_value" gorm:"not null"` // Total cost - accumulated depreciation
+	DepreciableAmount       Money               `json:"depreciable_amount" gorm:"not null"` // Amount eligible for depreciation
+	AccumulatedDepreciation Money               `json:"accumulated_depreciation" gorm:"default:0"`
+	BookValue               Money               `json:"book_value" gorm:"not null"` // Total cost - accumulated depreciation
 	DisposalDate            *time.Time          `json:"disposal_date"`
-	DisposalAmount          *float64            `json:"disposal_amount"`
+	DisposalAmount          *Money              `json:"disposal_amount"`
 	PaidBy                  string              `json:"paid_by" gorm:"not null;default:'corp'"` // "corp" or "owner"
 	ReceiptAttached         bool                `json:"receipt_attached" gorm:"default:false"`
+	VendorID                *uint               `json:"vendor_id" gorm:"index"`
+	Vendor                  *Vendor             `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+	ProjectID               *uint               `json:"project_id" gorm:"index"`
+	Project                 *Project            `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
 	CompanyID               uint                `json:"company_id" gorm:"not null"`
 	Company                 Company             `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
 	DepreciationEntries     []DepreciationEntry `json:"depreciation_entries,omitempty" gorm:"foreignKey:CapitalAssetID"`
@@ -332,7 +376,7 @@ type DepreciationEntry struct {
 	CapitalAssetID     uint           `json:"capital_asset_id" gorm:"not null"`
 	CapitalAsset       CapitalAsset   `json:"capital_asset,omitempty" gorm:"foreignKey:CapitalAssetID"`
 	FiscalYear         int            `json:"fiscal_year" gorm:"not null"`
-	DepreciationAmount float64        `json:"depreciation_amount" gorm:"not null"`
+	DepreciationAmount Money          `json:"depreciation_amount" gorm:"not null"`
 	IsHalfYearRule     bool           `json:"is_half_year_rule" gorm:"default:false"`
 	EntryDate          time.Time      `json:"entry_date" gorm:"not null"`
 	CompanyID          uint           `json:"company_id" gorm:"not null"`
@@ -355,36 +399,40 @@ type CCAClass struct {
 
 // CreateCapitalAssetRequest represents a request to create a capital asset
 type CreateCapitalAssetRequest struct {
-	Description     string  `json:"description" binding:"required"`
-	CategoryID      uint    `json:"category_id" binding:"required"`
-	PurchaseDate    string  `json:"purchase_date" binding:"required"`
-	PurchaseAmount  float64 `json:"purchase_amount" binding:"required,min=0"`
-	HSTPaid         float64 `json:"hst_paid" binding:"min=0"`
-	CCAClass        string  `json:"cca_class" binding:"required"`
-	PaidBy          string  `json:"paid_by" binding:"required,oneof=corp owner"`
-	ReceiptAttached bool    `json:"receipt_attached"`
-	CompanyID       uint    `json:"company_id" binding:"required"`
+	Description     string `json:"description" binding:"required"`
+	CategoryID      uint   `json:"category_id"` // Defaults to the vendor's default category
+	PurchaseDate    string `json:"purchase_date" binding:"required"`
+	PurchaseAmount  Money  `json:"purchase_amount" binding:"required,min=0"`
+	HSTPaid         Money  `json:"hst_paid" binding:"min=0"`
+	CCAClass        string `json:"cca_class" binding:"required"`
+	PaidBy          string `json:"paid_by" binding:"required,oneof=corp owner"`
+	ReceiptAttached bool   `json:"receipt_attached"`
+	VendorID        *uint  `json:"vendor_id,omitempty"`
+	ProjectID       *uint  `json:"project_id,omitempty"`
+	CompanyID       uint   `json:"company_id" binding:"required"`
 }
 
 // UpdateCapitalAssetRequest represents a request to update a capital asset
 type UpdateCapitalAssetRequest struct {
-	Description     *string  `json:"description,omitempty"`
-	CategoryID      *uint    `json:"category_id,omitempty"`
-	PurchaseDate    *string  `json:"purchase_date,omitempty"`
-	PurchaseAmount  *float64 `json:"purchase_amount,omitempty" binding:"omitempty,min=0"`
-	HSTPaid         *float64 `json:"hst_paid,omitempty" binding:"omitempty,min=0"`
-	CCAClass        *string  `json:"cca_class,omitempty"`
-	DisposalDate    *string  `json:"disposal_date,omitempty"`
-	DisposalAmount  *float64 `json:"disposal_amount,omitempty" binding:"omitempty,min=0"`
-	PaidBy          *string  `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
-	ReceiptAttached *bool    `json:"receipt_attached,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	CategoryID      *uint   `json:"category_id,omitempty"`
+	PurchaseDate    *string `json:"purchase_date,omitempty"`
+	PurchaseAmount  *Money  `json:"purchase_amount,omitempty" binding:"omitempty,min=0"`
+	HSTPaid         *Money  `json:"hst_paid,omitempty" binding:"omitempty,min=0"`
+	CCAClass        *string `json:"cca_class,omitempty"`
+	DisposalDate    *string `json:"disposal_date,omitempty"`
+	DisposalAmount  *Money  `json:"disposal_amount,omitempty" binding:"omitempty,min=0"`
+	PaidBy          *string `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	ReceiptAttached *bool   `json:"receipt_attached,omitempty"`
+	VendorID        *uint   `json:"vendor_id,omitempty"`  // 0 to clear
+	ProjectID       *uint   `json:"project_id,omitempty"` // 0 to clear
 }
 
 // OwnerPayment represents a payment made by the corporation to the owner
 type OwnerPayment struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Description string         `json:"description" gorm:"not null"`
-	Amount      float64        `json:"amount" gorm:"not null"`
+	Amount      Money          `json:"amount" gorm:"not null"`
 	PaymentDate time.Time      `json:"payment_date" gorm:"not null"`
 	PaymentType string         `json:"payment_type" gorm:"not null"` // "reimbursement", "loan_repayment", "other"
 	Reference   *string        `json:"reference"`                    // Check number, transfer reference, etc.
@@ -399,7 +447,7 @@ type OwnerPayment struct {
 // CreateOwnerPaymentRequest represents a request to create an owner payment
 type CreateOwnerPaymentRequest struct {
 	Description string  `json:"description" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,min=0"`
+	Amount      Money   `json:"amount" binding:"required,min=0"`
 	PaymentDate string  `json:"payment_date" binding:"required"`
 	PaymentType string  `json:"payment_type" binding:"required,oneof=reimbursement loan_repayment other"`
 	Reference   *string `json:"reference,omitempty"`
@@ -409,12 +457,12 @@ type CreateOwnerPaymentRequest struct {
 
 // UpdateOwnerPaymentRequest represents a request to update an owner payment
 type UpdateOwnerPaymentRequest struct {
-	Description *string  `json:"description,omitempty"`
-	Amount      *float64 `json:"amount,omitempty" binding:"omitempty,min=0"`
-	PaymentDate *string  `json:"payment_date,omitempty"`
-	PaymentType *string  `json:"payment_type,omitempty" binding:"omitempty,oneof=reimbursement loan_repayment other"`
-	Reference   *string  `json:"reference,omitempty"`
-	Notes       *string  `json:"notes,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Amount      *Money  `json:"amount,omitempty" binding:"omitempty,min=0"`
+	PaymentDate *string `json:"payment_date,omitempty"`
+	PaymentType *string `json:"payment_type,omitempty" binding:"omitempty,oneof=reimbursement loan_repayment other"`
+	Reference   *string `json:"reference,omitempty"`
+	Notes       *string `json:"notes,omitempty"`
 }
 
 // PaginatedResponse represents a paginated API response
@@ -425,3 +473,1059 @@ type PaginatedResponse[T any] struct {
 	Limit      int `json:"limit"`
 	TotalPages int `json:"total_pages"`
 }
+
+// Account represents an account in a company's chart of accounts
+type Account struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Code        string         `json:"code" gorm:"not null;uniqueIndex:idx_accounts_company_code"`
+	Name        string         `json:"name" gorm:"not null"`
+	Type        string         `json:"type" gorm:"not null"`      // asset, liability, equity, revenue, expense
+	GIFICode    string         `json:"gifi_code" gorm:"not null"` // CRA General Index of Financial Information code
+	Description *string        `json:"description"`
+	IsSystem    bool           `json:"is_system" gorm:"default:false"` // Used by automatic postings; cannot be deleted
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CompanyID   uint           `json:"company_id" gorm:"not null;uniqueIndex:idx_accounts_company_code"`
+	Company     Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CreateAccountRequest represents a request to create an account
+type CreateAccountRequest struct {
+	Code        string  `json:"code" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	Type        string  `json:"type" binding:"required,oneof=asset liability equity revenue expense"`
+	GIFICode    string  `json:"gifi_code" binding:"required,len=4,numeric"`
+	Description *string `json:"description,omitempty"`
+	CompanyID   uint    `json:"company_id" binding:"required"`
+}
+
+// UpdateAccountRequest represents a request to update an account
+type UpdateAccountRequest struct {
+	Name        *string `json:"name,omitempty"`
+	GIFICode    *string `json:"gifi_code,omitempty" binding:"omitempty,len=4,numeric"`
+	Description *string `json:"description,omitempty"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// JournalEntry represents a balanced double-entry journal entry in the general ledger.
+// Entries are never edited or deleted; changes to a source document are recorded by
+// posting a reversing entry followed by a fresh entry.
+type JournalEntry struct {
+	ID           uint          `json:"id" gorm:"primaryKey"`
+	EntryDate    time.Time     `json:"entry_date" gorm:"not null;index"`
+	Description  string        `json:"description" gorm:"not null"`
+	SourceType   string        `json:"source_type" gorm:"not null;index:idx_journal_entries_source"` // "expense", "invoice", "income_entry", "dividend", "hst_payment", "owner_payment", "capital_asset", "depreciation_entry", "manual"
+	SourceID     uint          `json:"source_id" gorm:"not null;index:idx_journal_entries_source"`
+	ReversalOfID *uint         `json:"reversal_of_id"` // Set on entries that reverse an earlier entry
+	ReversedByID *uint         `json:"reversed_by_id"` // Set on entries that have been reversed
+	CompanyID    uint          `json:"company_id" gorm:"not null;index"`
+	Company      Company       `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Lines        []JournalLine `json:"lines,omitempty" gorm:"foreignKey:JournalEntryID"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// JournalLine represents a single debit or credit within a journal entry
+type JournalLine struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	JournalEntryID uint      `json:"journal_entry_id" gorm:"not null;index"`
+	AccountCode    string    `json:"account_code" gorm:"not null;index"`
+	Debit          Money     `json:"debit" gorm:"not null;default:0"`
+	Credit         Money     `json:"credit" gorm:"not null;default:0"`
+	Memo           *string   `json:"memo"`
+	CompanyID      uint      `json:"company_id" gorm:"not null;index"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CreateJournalEntryRequest represents a request to post a manual journal entry
+type CreateJournalEntryRequest struct {
+	EntryDate   string                     `json:"entry_date" binding:"required"`
+	Description string                     `json:"description" binding:"required"`
+	CompanyID   uint                       `json:"company_id" binding:"required"`
+	Lines       []CreateJournalLineRequest `json:"lines" binding:"required,min=2,dive"`
+}
+
+// CreateJournalLineRequest represents a single line of a manual journal entry
+type CreateJournalLineRequest struct {
+	AccountCode string  `json:"account_code" binding:"required"`
+	Debit       Money   `json:"debit" binding:"min=0"`
+	Credit      Money   `json:"credit" binding:"min=0"`
+	Memo        *string `json:"memo,omitempty"`
+}
+
+// AccountingPeriod represents a fiscal period of a company. Once closed, records dated
+// within the period can no longer be created, updated or deleted until it is reopened.
+type AccountingPeriod struct {
+	ID           uint                    `json:"id" gorm:"primaryKey"`
+	StartDate    time.Time               `json:"start_date" gorm:"not null"`
+	EndDate      time.Time               `json:"end_date" gorm:"not null"`
+	Status       string                  `json:"status" gorm:"not null;default:'closed'"` // "closed", "open"
+	ClosedAt     *time.Time              `json:"closed_at"`
+	ClosedByID   *uint                   `json:"closed_by_id"`
+	ReopenedAt   *time.Time              `json:"reopened_at"`
+	ReopenedByID *uint                   `json:"reopened_by_id"`
+	Notes        *string                 `json:"notes"`
+	CompanyID    uint                    `json:"company_id" gorm:"not null;index"`
+	Company      Company                 `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Events       []AccountingPeriodEvent `json:"events,omitempty" gorm:"foreignKey:AccountingPeriodID"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// AccountingPeriodEvent is the audit trail of closing and reopening an accounting period
+type AccountingPeriodEvent struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	AccountingPeriodID uint      `json:"accounting_period_id" gorm:"not null;index"`
+	Action             string    `json:"action" gorm:"not null"` // "close", "reopen"
+	Reason             *string   `json:"reason"`
+	UserID             uint      `json:"user_id" gorm:"not null"`
+	User               User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CompanyID          uint      `json:"company_id" gorm:"not null;index"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// CloseAccountingPeriodRequest represents a request to close an accounting period
+type CloseAccountingPeriodRequest struct {
+	CompanyID uint    `json:"company_id" binding:"required"`
+	StartDate string  `json:"start_date" binding:"required"`
+	EndDate   string  `json:"end_date" binding:"required"`
+	Notes     *string `json:"notes,omitempty"`
+}
+
+// ReopenAccountingPeriodRequest represents a request to reopen a closed accounting period
+type ReopenAccountingPeriodRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ExchangeRate is the value of one unit of a foreign currency in Canadian dollars on a
+// date, such as the Bank of Canada daily average rate
+type ExchangeRate struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Currency  string    `json:"currency" gorm:"not null;uniqueIndex:idx_exchange_rates_currency_date"` // ISO 4217 code, e.g. "USD"
+	RateDate  time.Time `json:"rate_date" gorm:"not null;uniqueIndex:idx_exchange_rates_currency_date"`
+	Rate      float64   `json:"rate" gorm:"not null"`                    // CAD per unit of currency
+	Source    string    `json:"source" gorm:"not null;default:'manual'"` // "bank_of_canada", "manual"
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateExchangeRateRequest represents a request to record an exchange rate
+type CreateExchangeRateRequest struct {
+	Currency string  `json:"currency" binding:"required,len=3,alpha"`
+	RateDate string  `json:"rate_date" binding:"required"`
+	Rate     float64 `json:"rate" binding:"required,gt=0"`
+}
+
+// BankAccount represents a company bank account whose statements are reconciled
+// against a cash account in the general ledger
+type BankAccount struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	Name           string         `json:"name" gorm:"not null"`
+	Institution    *string        `json:"institution"`
+	AccountNumber  *string        `json:"account_number"`                              // Last digits only
+	AccountCode    string         `json:"account_code" gorm:"not null;default:'1000'"` // Ledger account the bank account is reconciled against
+	OpeningBalance Money          `json:"opening_balance" gorm:"not null;default:0"`   // Statement balance on the opening date
+	OpeningDate    time.Time      `json:"opening_date" gorm:"not null"`
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+	CompanyID      uint           `json:"company_id" gorm:"not null;index"`
+	Company        Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BankTransaction represents a line of a bank statement. A line is matched to the
+// source record that accounts for it in the books, such as an expense or income entry.
+type BankTransaction struct {
+	ID                   uint                `json:"id" gorm:"primaryKey"`
+	BankAccountID        uint                `json:"bank_account_id" gorm:"not null;index;uniqueIndex:idx_bank_transactions_account_fitid"`
+	BankAccount          BankAccount         `json:"bank_account,omitempty" gorm:"foreignKey:BankAccountID"`
+	PostedDate           time.Time           `json:"posted_date" gorm:"not null;index"`
+	Description          string              `json:"description" gorm:"not null"`
+	Amount               Money               `json:"amount" gorm:"not null"` // Positive for deposits, negative for withdrawals
+	Reference            *string             `json:"reference"`
+	FITID                *string             `json:"fitid" gorm:"uniqueIndex:idx_bank_transactions_account_fitid"` // Financial institution transaction ID from OFX imports
+	Status               string              `json:"status" gorm:"not null;default:'unmatched'"`                   // unmatched, matched
+	MatchedSourceType    *string             `json:"matched_source_type" gorm:"index:idx_bank_transactions_match"` // Journal source type, e.g. "expense"
+	MatchedSourceID      *uint               `json:"matched_source_id" gorm:"index:idx_bank_transactions_match"`
+	MatchedAt            *time.Time          `json:"matched_at"`
+	MatchedByID          *uint               `json:"matched_by_id"`
+	CategorizationRuleID *uint               `json:"categorization_rule_id"` // Rule that matched the line when it was imported
+	CategorizationRule   *CategorizationRule `json:"categorization_rule,omitempty" gorm:"foreignKey:CategorizationRuleID"`
+	CompanyID            uint                `json:"company_id" gorm:"not null;index"`
+	Company              Company             `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt            time.Time           `json:"created_at"`
+	UpdatedAt            time.Time           `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt      `json:"-" gorm:"index"`
+}
+
+// CreateBankAccountRequest represents a request to create a bank account
+type CreateBankAccountRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	Institution    *string `json:"institution,omitempty"`
+	AccountNumber  *string `json:"account_number,omitempty"`
+	AccountCode    string  `json:"account_code,omitempty"` // Defaults to the cash account
+	OpeningBalance Money   `json:"opening_balance"`
+	OpeningDate    string  `json:"opening_date" binding:"required"`
+	CompanyID      uint    `json:"company_id" binding:"required"`
+}
+
+// UpdateBankAccountRequest represents a request to update a bank account
+type UpdateBankAccountRequest struct {
+	Name           *string `json:"name,omitempty"`
+	Institution    *string `json:"institution,omitempty"`
+	AccountNumber  *string `json:"account_number,omitempty"`
+	AccountCode    *string `json:"account_code,omitempty"`
+	OpeningBalance *Money  `json:"opening_balance,omitempty"`
+	OpeningDate    *string `json:"opening_date,omitempty"`
+	IsActive       *bool   `json:"is_active,omitempty"`
+}
+
+// CreateBankTransactionsRequest represents a request to add statement lines to a bank account
+type CreateBankTransactionsRequest struct {
+	BankAccountID uint                           `json:"bank_account_id" binding:"required"`
+	Transactions  []CreateBankTransactionRequest `json:"transactions" binding:"required,min=1,dive"`
+}
+
+// CreateBankTransactionRequest represents a single statement line
+type CreateBankTransactionRequest struct {
+	PostedDate  string  `json:"posted_date" binding:"required"`
+	Description string  `json:"description" binding:"required"`
+	Amount      Money   `json:"amount" binding:"required"`
+	Reference   *string `json:"reference,omitempty"`
+}
+
+// MatchBankTransactionRequest represents a request to match a statement line to a record
+type MatchBankTransactionRequest struct {
+	SourceType string `json:"source_type" binding:"required"`
+	SourceID   uint   `json:"source_id" binding:"required"`
+}
+
+// CreateRecordFromBankTransactionRequest represents a request to record an unmatched
+// statement line as a new expense, income entry or owner payment
+type CreateRecordFromBankTransactionRequest struct {
+	RecordType  string  `json:"record_type" binding:"required,oneof=expense income_entry owner_payment"`
+	Description *string `json:"description,omitempty"` // Defaults to the statement description
+	CategoryID  *uint   `json:"category_id,omitempty"` // Required for expenses
+	HSTAmount   Money   `json:"hst_amount" binding:"min=0"`
+	IncomeType  *string `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	ClientID    *uint   `json:"client_id,omitempty"`
+	PaymentType *string `json:"payment_type,omitempty" binding:"omitempty,oneof=reimbursement loan_repayment other"`
+	LearnRule   bool    `json:"learn_rule"` // Learn a categorization rule from this record
+}
+
+// BulkCreateRecordsFromBankTransactionsRequest represents a request to record unmatched
+// statement lines in bulk, withdrawals as expenses and deposits as income entries
+type BulkCreateRecordsFromBankTransactionsRequest struct {
+	BankTransactionIDs []uint  `json:"bank_transaction_ids" binding:"required,min=1"`
+	ExpenseCategoryID  *uint   `json:"expense_category_id,omitempty"` // Required when withdrawals are selected
+	IncomeType         *string `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	ClientID           *uint   `json:"client_id,omitempty"`
+	IncludesHST        bool    `json:"includes_hst"` // Split HST out of each amount at the company's HST rate
+}
+
+// CSVImportProfile is a saved column mapping for importing the CSV statements of a card
+// issuer or bank. Columns are named by their header or by 1-based position.
+type CSVImportProfile struct {
+	ID                uint             `json:"id" gorm:"primaryKey"`
+	Name              string           `json:"name" gorm:"not null"`
+	HasHeader         bool             `json:"has_header" gorm:"not null"`
+	SkipRows          int              `json:"skip_rows" gorm:"not null;default:0"` // Non-blank lines before the header or first row
+	Delimiter         string           `json:"delimiter" gorm:"not null;default:','"`
+	DateColumn        string           `json:"date_column" gorm:"not null"`
+	DateFormat        string           `json:"date_format" gorm:"not null"` // e.g. YYYY-MM-DD, MM/DD/YYYY, DD MMM YYYY
+	DescriptionColumn string           `json:"description_column" gorm:"not null"`
+	AmountColumn      *string          `json:"amount_column"`                   // Signed amount
+	DebitColumn       *string          `json:"debit_column"`                    // Money out, when debits and credits are in separate columns
+	CreditColumn      *string          `json:"credit_column"`                   // Money in, when debits and credits are in separate columns
+	ReferenceColumn   *string          `json:"reference_column"`                // Optional
+	DebitsNegative    bool             `json:"debits_negative" gorm:"not null"` // Whether money out is negative in the amount column
+	Currency          string           `json:"currency" gorm:"not null;default:'CAD'"`
+	PaidBy            string           `json:"paid_by" gorm:"not null;default:'corp'"` // "corp" or "owner", for expenses
+	ExpenseCategoryID *uint            `json:"expense_category_id"`                    // Category of imported expenses
+	ExpenseCategory   *ExpenseCategory `json:"expense_category,omitempty" gorm:"foreignKey:ExpenseCategoryID"`
+	IncomeType        string           `json:"income_type" gorm:"not null;default:'other'"` // Type of imported income entries
+	IncludesHST       bool             `json:"includes_hst" gorm:"not null"`                // Split HST out of each amount at the company's HST rate
+	CompanyID         uint             `json:"company_id" gorm:"not null;index"`
+	Company           Company          `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// CreateCSVImportProfileRequest represents a request to create a CSV import profile
+type CreateCSVImportProfileRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	HasHeader         *bool   `json:"has_header,omitempty"` // Defaults to true
+	SkipRows          int     `json:"skip_rows" binding:"min=0"`
+	Delimiter         string  `json:"delimiter,omitempty"` // Defaults to a comma
+	DateColumn        string  `json:"date_column" binding:"required"`
+	DateFormat        string  `json:"date_format" binding:"required"`
+	DescriptionColumn string  `json:"description_column" binding:"required"`
+	AmountColumn      *string `json:"amount_column,omitempty"`
+	DebitColumn       *string `json:"debit_column,omitempty"`
+	CreditColumn      *string `json:"credit_column,omitempty"`
+	ReferenceColumn   *string `json:"reference_column,omitempty"`
+	DebitsNegative    *bool   `json:"debits_negative,omitempty"` // Defaults to true
+	Currency          string  `json:"currency,omitempty"`
+	PaidBy            string  `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	ExpenseCategoryID *uint   `json:"expense_category_id,omitempty"`
+	IncomeType        string  `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	IncludesHST       bool    `json:"includes_hst"`
+	CompanyID         uint    `json:"company_id" binding:"required"`
+}
+
+// UpdateCSVImportProfileRequest represents a request to update a CSV import profile
+type UpdateCSVImportProfileRequest struct {
+	Name              *string `json:"name,omitempty"`
+	HasHeader         *bool   `json:"has_header,omitempty"`
+	SkipRows          *int    `json:"skip_rows,omitempty" binding:"omitempty,min=0"`
+	Delimiter         *string `json:"delimiter,omitempty"`
+	DateColumn        *string `json:"date_column,omitempty"`
+	DateFormat        *string `json:"date_format,omitempty"`
+	DescriptionColumn *string `json:"description_column,omitempty"`
+	AmountColumn      *string `json:"amount_column,omitempty"` // Empty to clear
+	DebitColumn       *string `json:"debit_column,omitempty"`  // Empty to clear
+	CreditColumn      *string `json:"credit_column,omitempty"` // Empty to clear
+	ReferenceColumn   *string `json:"reference_column,omitempty"`
+	DebitsNegative    *bool   `json:"debits_negative,omitempty"`
+	Currency          *string `json:"currency,omitempty"`
+	PaidBy            *string `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	ExpenseCategoryID *uint   `json:"expense_category_id,omitempty"`
+	IncomeType        *string `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	IncludesHST       *bool   `json:"includes_hst,omitempty"`
+}
+
+// CategorizationRule assigns a category, HST treatment and clean description to imported
+// bank and card activity whose description matches its pattern. Rules are tried in
+// priority order and the first match applies.
+type CategorizationRule struct {
+	ID                 uint             `json:"id" gorm:"primaryKey"`
+	Name               string           `json:"name" gorm:"not null"`
+	Priority           int              `json:"priority" gorm:"not null"` // Lower runs first
+	IsActive           bool             `json:"is_active" gorm:"default:true"`
+	MatchType          string           `json:"match_type" gorm:"not null;default:'contains'"` // contains (case-insensitive) or regex
+	Pattern            string           `json:"pattern" gorm:"not null"`
+	Direction          *string          `json:"direction"`             // out or in; both when not set
+	MinAmount          *Money           `json:"min_amount"`            // Inclusive, on the absolute amount
+	MaxAmount          *Money           `json:"max_amount"`            // Inclusive, on the absolute amount
+	BankAccountID      *uint            `json:"bank_account_id"`       // Only lines of this bank account
+	CSVImportProfileID *uint            `json:"csv_import_profile_id"` // Only rows imported with this profile
+	CategoryID         *uint            `json:"category_id"`
+	Category           *ExpenseCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	PaidBy             *string          `json:"paid_by"`     // "corp" or "owner", for CSV imports
+	HSTRate            *float64         `json:"hst_rate"`    // HST included in the amount, e.g. 0.13; 0 for none
+	Description        *string          `json:"description"` // Clean description; regex rules may use $1 for groups
+	IncomeType         *string          `json:"income_type"`
+	Learned            bool             `json:"learned" gorm:"default:false"` // Learned from a manual categorization
+	CompanyID          uint             `json:"company_id" gorm:"not null;index"`
+	Company            Company          `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// CreateCategorizationRuleRequest represents a request to create a categorization rule
+type CreateCategorizationRuleRequest struct {
+	Name               string   `json:"name" binding:"required"`
+	Priority           *int     `json:"priority,omitempty"` // Defaults to 100
+	MatchType          string   `json:"match_type,omitempty" binding:"omitempty,oneof=contains regex"`
+	Pattern            string   `json:"pattern" binding:"required"`
+	Direction          *string  `json:"direction,omitempty" binding:"omitempty,oneof=out in"`
+	MinAmount          *Money   `json:"min_amount,omitempty"`
+	MaxAmount          *Money   `json:"max_amount,omitempty"`
+	BankAccountID      *uint    `json:"bank_account_id,omitempty"`
+	CSVImportProfileID *uint    `json:"csv_import_profile_id,omitempty"`
+	CategoryID         *uint    `json:"category_id,omitempty"`
+	PaidBy             *string  `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	HSTRate            *float64 `json:"hst_rate,omitempty" binding:"omitempty,min=0,max=1"`
+	Description        *string  `json:"description,omitempty"`
+	IncomeType         *string  `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	CompanyID          uint     `json:"company_id" binding:"required"`
+}
+
+// UpdateCategorizationRuleRequest represents a request to update a categorization rule.
+// Conditions and actions are replaced as given; empty strings and zero IDs clear them.
+type UpdateCategorizationRuleRequest struct {
+	Name               *string  `json:"name,omitempty"`
+	Priority           *int     `json:"priority,omitempty"`
+	IsActive           *bool    `json:"is_active,omitempty"`
+	MatchType          *string  `json:"match_type,omitempty" binding:"omitempty,oneof=contains regex"`
+	Pattern            *string  `json:"pattern,omitempty"`
+	Direction          *string  `json:"direction,omitempty" binding:"omitempty,oneof=out in ''"`
+	MinAmount          *Money   `json:"min_amount,omitempty"`
+	MaxAmount          *Money   `json:"max_amount,omitempty"`
+	BankAccountID      *uint    `json:"bank_account_id,omitempty"`
+	CSVImportProfileID *uint    `json:"csv_import_profile_id,omitempty"`
+	CategoryID         *uint    `json:"category_id,omitempty"`
+	PaidBy             *string  `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner ''"`
+	HSTRate            *float64 `json:"hst_rate,omitempty" binding:"omitempty,min=0,max=1"`
+	Description        *string  `json:"description,omitempty"`
+	IncomeType         *string  `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other ''"`
+}
+
+// TestCategorizationRulesRequest represents a request to try a company's rules, or a
+// draft rule, against sample bank activity
+type TestCategorizationRulesRequest struct {
+	CompanyID          uint                             `json:"company_id" binding:"required"`
+	Description        string                           `json:"description" binding:"required"`
+	Amount             Money                            `json:"amount" binding:"required"` // Positive for money in, negative for money out
+	BankAccountID      *uint                            `json:"bank_account_id,omitempty"`
+	CSVImportProfileID *uint                            `json:"csv_import_profile_id,omitempty"`
+	Rule               *CreateCategorizationRuleRequest `json:"rule,omitempty"` // Test this draft instead of the saved rules
+}
+
+// LearnCategorizationRuleRequest represents a request to learn a rule from an expense or
+// income entry that was categorized by hand
+type LearnCategorizationRuleRequest struct {
+	SourceType string  `json:"source_type" binding:"required,oneof=expense income_entry"`
+	SourceID   uint    `json:"source_id" binding:"required"`
+	Pattern    *string `json:"pattern,omitempty"` // Defaults to the merchant part of the description
+	Name       *string `json:"name,omitempty"`
+}
+
+// RecurringTemplate describes an expense, income entry or owner payment that repeats on
+// a schedule, such as rent or a software subscription. The scheduler records each due
+// occurrence once.
+type RecurringTemplate struct {
+	ID                 uint                  `json:"id" gorm:"primaryKey"`
+	Name               string                `json:"name" gorm:"not null"`
+	RecordType         string                `json:"record_type" gorm:"not null"` // expense, income_entry or owner_payment
+	Description        string                `json:"description" gorm:"not null"` // Description of the recorded transactions
+	Cadence            string                `json:"cadence" gorm:"not null"`     // monthly, quarterly, yearly or days
+	IntervalDays       *int                  `json:"interval_days"`               // Days between occurrences, for the days cadence
+	StartDate          time.Time             `json:"start_date" gorm:"not null"`  // First occurrence; later ones fall on the same day
+	EndDate            *time.Time            `json:"end_date"`                    // Last possible occurrence; open-ended when not set
+	NextOccurrenceDate *time.Time            `json:"next_occurrence_date"`        // Not set once the schedule has ended
+	LastOccurrenceDate *time.Time            `json:"last_occurrence_date"`
+	IsActive           bool                  `json:"is_active" gorm:"default:true"`
+	Amount             Money                 `json:"amount" gorm:"not null"` // Before HST
+	ChargeHST          bool                  `json:"charge_hst" gorm:"not null"`
+	HSTAmount          *Money                `json:"hst_amount"` // Fixed HST; when not set, HST is at the company's rate
+	Currency           string                `json:"currency" gorm:"not null;default:'CAD'"`
+	CategoryID         *uint                 `json:"category_id"` // Expenses
+	Category           *ExpenseCategory      `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	PaidBy             *string               `json:"paid_by"`     // Expenses: "corp" or "owner"
+	IncomeType         *string               `json:"income_type"` // Income entries
+	ClientID           *uint                 `json:"client_id"`   // Income entries
+	Client             *Client               `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	PaymentType        *string               `json:"payment_type"` // Owner payments
+	Reference          *string               `json:"reference"`    // Owner payments
+	CompanyID          uint                  `json:"company_id" gorm:"not null;index"`
+	Company            Company               `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Occurrences        []RecurringOccurrence `json:"occurrences,omitempty" gorm:"foreignKey:TemplateID"`
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt        `json:"-" gorm:"index"`
+}
+
+// RecurringOccurrence records that a recurring template's occurrence on a date was
+// processed. The unique index keeps an occurrence from being recorded twice.
+type RecurringOccurrence struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	TemplateID     uint      `json:"template_id" gorm:"not null;uniqueIndex:idx_recurring_occurrence"`
+	OccurrenceDate time.Time `json:"occurrence_date" gorm:"not null;uniqueIndex:idx_recurring_occurrence"`
+	Status         string    `json:"status" gorm:"not null"` // created, or skipped when the date is in a closed period
+	SourceType     *string   `json:"source_type"`            // Record created for the occurrence
+	SourceID       *uint     `json:"source_id"`
+	Note           *string   `json:"note"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateRecurringTemplateRequest represents a request to create a recurring template
+type CreateRecurringTemplateRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	RecordType   string  `json:"record_type" binding:"required,oneof=expense income_entry owner_payment"`
+	Description  string  `json:"description" binding:"required"`
+	Cadence      string  `json:"cadence" binding:"required,oneof=monthly quarterly yearly days"`
+	IntervalDays *int    `json:"interval_days,omitempty" binding:"omitempty,min=1"`
+	StartDate    string  `json:"start_date" binding:"required"`
+	EndDate      *string `json:"end_date,omitempty"`
+	Amount       Money   `json:"amount" binding:"required,min=0"`
+	ChargeHST    bool    `json:"charge_hst"`
+	HSTAmount    *Money  `json:"hst_amount,omitempty" binding:"omitempty,min=0"`
+	Currency     string  `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	CategoryID   *uint   `json:"category_id,omitempty"`
+	PaidBy       *string `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	IncomeType   *string `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	ClientID     *uint   `json:"client_id,omitempty"`
+	PaymentType  *string `json:"payment_type,omitempty" binding:"omitempty,oneof=reimbursement loan_repayment other"`
+	Reference    *string `json:"reference,omitempty"`
+	CompanyID    uint    `json:"company_id" binding:"required"`
+}
+
+// UpdateRecurringTemplateRequest represents a request to update a recurring template.
+// Changing the schedule moves the next occurrence to the first date after the last one
+// recorded.
+type UpdateRecurringTemplateRequest struct {
+	Name           *string `json:"name,omitempty"`
+	Description    *string `json:"description,omitempty"`
+	Cadence        *string `json:"cadence,omitempty" binding:"omitempty,oneof=monthly quarterly yearly days"`
+	IntervalDays   *int    `json:"interval_days,omitempty" binding:"omitempty,min=1"`
+	StartDate      *string `json:"start_date,omitempty"`
+	EndDate        *string `json:"end_date,omitempty"` // Empty to make the schedule open-ended
+	IsActive       *bool   `json:"is_active,omitempty"`
+	Amount         *Money  `json:"amount,omitempty" binding:"omitempty,min=0"`
+	ChargeHST      *bool   `json:"charge_hst,omitempty"`
+	HSTAmount      *Money  `json:"hst_amount,omitempty" binding:"omitempty,min=0"`
+	ClearHSTAmount bool    `json:"clear_hst_amount"` // Go back to HST at the company's rate
+	Currency       *string `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	CategoryID     *uint   `json:"category_id,omitempty"`
+	PaidBy         *string `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"`
+	IncomeType     *string `json:"income_type,omitempty" binding:"omitempty,oneof=client capital other"`
+	ClientID       *uint   `json:"client_id,omitempty"`
+	PaymentType    *string `json:"payment_type,omitempty" binding:"omitempty,oneof=reimbursement loan_repayment other"`
+	Reference      *string `json:"reference,omitempty"`
+}
+
+// Vendor is a supplier a company buys from. Expenses and capital assets record their
+// vendor so spending can be totalled by supplier and input tax credits supported with
+// the supplier's HST registration number.
+type Vendor struct {
+	ID                uint             `json:"id" gorm:"primaryKey"`
+	Name              string           `json:"name" gorm:"not null"`
+	ContactPerson     *string          `json:"contact_person"`
+	Email             *string          `json:"email"`
+	Phone             *string          `json:"phone"`
+	Address           *string          `json:"address"`
+	HSTNumber         *string          `json:"hst_number"`          // GST/HST registration number, e.g. 123456789RT0001
+	DefaultCategoryID *uint            `json:"default_category_id"` // Category of new expenses that give none
+	DefaultCategory   *ExpenseCategory `json:"default_category,omitempty" gorm:"foreignKey:DefaultCategoryID"`
+	Notes             *string          `json:"notes"`
+	CompanyID         uint             `json:"company_id" gorm:"not null;index"`
+	Company           Company          `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// CreateVendorRequest represents a request to create a vendor
+type CreateVendorRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	ContactPerson     *string `json:"contact_person,omitempty"`
+	Email             *string `json:"email,omitempty"`
+	Phone             *string `json:"phone,omitempty"`
+	Address           *string `json:"address,omitempty"`
+	HSTNumber         *string `json:"hst_number,omitempty"`
+	DefaultCategoryID *uint   `json:"default_category_id,omitempty"`
+	Notes             *string `json:"notes,omitempty"`
+	CompanyID         uint    `json:"company_id" binding:"required"`
+}
+
+// UpdateVendorRequest represents a request to update a vendor
+type UpdateVendorRequest struct {
+	Name              *string `json:"name,omitempty"`
+	ContactPerson     *string `json:"contact_person,omitempty"`
+	Email             *string `json:"email,omitempty"`
+	Phone             *string `json:"phone,omitempty"`
+	Address           *string `json:"address,omitempty"`
+	HSTNumber         *string `json:"hst_number,omitempty"`
+	DefaultCategoryID *uint   `json:"default_category_id,omitempty"` // 0 to clear
+	Notes             *string `json:"notes,omitempty"`
+}
+
+// Bill is a vendor's invoice the company pays later. It posts to accounts payable on its
+// bill date and is settled by one or more bill payments. Bills are in CAD.
+type Bill struct {
+	ID         uint                    `json:"id" gorm:"primaryKey"`
+	BillNumber string                  `json:"bill_number" gorm:"not null"` // The vendor's invoice number
+	VendorID   uint                    `json:"vendor_id" gorm:"not null;index"`
+	Vendor     Vendor                  `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+	BillDate   time.Time               `json:"bill_date" gorm:"not null"`
+	DueDate    time.Time               `json:"due_date" gorm:"not null"`
+	Status     string                  `json:"status" gorm:"not null;default:'open'"` // open, partially_paid, paid, void
+	Subtotal   Money                   `json:"subtotal" gorm:"not null"`
+	HSTAmount  Money                   `json:"hst_amount" gorm:"not null"`
+	Total      Money                   `json:"total" gorm:"not null"`
+	AmountPaid Money                   `json:"amount_paid" gorm:"not null"`
+	Notes      *string                 `json:"notes"`
+	ProjectID  *uint                   `json:"project_id" gorm:"index"`
+	Project    *Project                `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	CompanyID  uint                    `json:"company_id" gorm:"not null;index"`
+	Company    Company                 `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Items      []BillItem              `json:"items,omitempty" gorm:"foreignKey:BillID"`
+	Payments   []BillPaymentAllocation `json:"payments,omitempty" gorm:"foreignKey:BillID"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt          `json:"-" gorm:"index"`
+}
+
+// BillItem is a line of a bill, expensed to the account of its category
+type BillItem struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	BillID      uint            `json:"bill_id" gorm:"not null;index"`
+	Description string          `json:"description" gorm:"not null"`
+	CategoryID  uint            `json:"category_id" gorm:"not null"`
+	Category    ExpenseCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Amount      Money           `json:"amount" gorm:"not null"` // Before HST
+	HSTAmount   Money           `json:"hst_amount" gorm:"not null"`
+	ProjectID   *uint           `json:"project_id" gorm:"index"` // Overrides the bill's project
+	Project     *Project        `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// BillPayment is a payment to a vendor that settles one or more of its bills
+type BillPayment struct {
+	ID          uint                    `json:"id" gorm:"primaryKey"`
+	VendorID    uint                    `json:"vendor_id" gorm:"not null;index"`
+	Vendor      Vendor                  `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+	PaymentDate time.Time               `json:"payment_date" gorm:"not null"`
+	Amount      Money                   `json:"amount" gorm:"not null"`
+	PaidBy      string                  `json:"paid_by" gorm:"not null;default:'corp'"` // "corp" or "owner"
+	Reference   *string                 `json:"reference"`                              // Cheque number, transfer reference, etc.
+	Notes       *string                 `json:"notes"`
+	CompanyID   uint                    `json:"company_id" gorm:"not null;index"`
+	Company     Company                 `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Allocations []BillPaymentAllocation `json:"allocations,omitempty" gorm:"foreignKey:BillPaymentID"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt          `json:"-" gorm:"index"`
+}
+
+// BillPaymentAllocation is the part of a bill payment applied to one bill
+type BillPaymentAllocation struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	BillPaymentID uint         `json:"bill_payment_id" gorm:"not null;index"`
+	BillPayment   *BillPayment `json:"bill_payment,omitempty" gorm:"foreignKey:BillPaymentID"`
+	BillID        uint         `json:"bill_id" gorm:"not null;index"`
+	Bill          *Bill        `json:"bill,omitempty" gorm:"foreignKey:BillID"`
+	Amount        Money        `json:"amount" gorm:"not null"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// CreateBillRequest represents a request to create a bill
+type CreateBillRequest struct {
+	BillNumber string                  `json:"bill_number" binding:"required"`
+	VendorID   uint                    `json:"vendor_id" binding:"required"`
+	BillDate   string                  `json:"bill_date" binding:"required"`
+	DueDate    string                  `json:"due_date" binding:"required"`
+	Notes      *string                 `json:"notes,omitempty"`
+	ProjectID  *uint                   `json:"project_id,omitempty"`
+	CompanyID  uint                    `json:"company_id" binding:"required"`
+	Items      []CreateBillItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// CreateBillItemRequest represents a request to create a bill line
+type CreateBillItemRequest struct {
+	Description string `json:"description" binding:"required"`
+	CategoryID  uint   `json:"category_id"` // Defaults to the vendor's default category
+	Amount      Money  `json:"amount" binding:"required,min=0"`
+	HSTAmount   *Money `json:"hst_amount,omitempty" binding:"omitempty,min=0"` // Defaults to HST at the company's rate
+	ProjectID   *uint  `json:"project_id,omitempty"`                           // Defaults to the bill's project
+}
+
+// UpdateBillRequest represents a request to update a bill. Bills with payments cannot be
+// changed; items, when given, replace the existing lines.
+type UpdateBillRequest struct {
+	BillNumber *string                 `json:"bill_number,omitempty"`
+	BillDate   *string                 `json:"bill_date,omitempty"`
+	DueDate    *string                 `json:"due_date,omitempty"`
+	Notes      *string                 `json:"notes,omitempty"`
+	ProjectID  *uint                   `json:"project_id,omitempty"` // 0 to clear
+	Items      []CreateBillItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+}
+
+// CreateBillPaymentRequest represents a request to pay one or more bills of a vendor
+type CreateBillPaymentRequest struct {
+	VendorID    uint                                 `json:"vendor_id" binding:"required"`
+	PaymentDate string                               `json:"payment_date" binding:"required"`
+	PaidBy      string                               `json:"paid_by,omitempty" binding:"omitempty,oneof=corp owner"` // Defaults to corp
+	Reference   *string                              `json:"reference,omitempty"`
+	Notes       *string                              `json:"notes,omitempty"`
+	CompanyID   uint                                 `json:"company_id" binding:"required"`
+	Allocations []CreateBillPaymentAllocationRequest `json:"allocations" binding:"required,min=1,dive"`
+}
+
+// CreateBillPaymentAllocationRequest represents the amount of a payment applied to a bill
+type CreateBillPaymentAllocationRequest struct {
+	BillID uint  `json:"bill_id" binding:"required"`
+	Amount Money `json:"amount" binding:"required,min=0"`
+}
+
+// Budget is a company's plan for one fiscal year, with an amount for each month of the
+// year per expense category and revenue account
+type Budget struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	Name       string         `json:"name" gorm:"not null"`
+	FiscalYear int            `json:"fiscal_year" gorm:"not null;index"` // Calendar year in which the fiscal year ends
+	Notes      *string        `json:"notes"`
+	CompanyID  uint           `json:"company_id" gorm:"not null;index"`
+	Company    Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Lines      []BudgetLine   `json:"lines,omitempty" gorm:"foreignKey:BudgetID"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BudgetLine is the budget of one expense category or revenue account, phased over the
+// months of the fiscal year
+type BudgetLine struct {
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	BudgetID     uint             `json:"budget_id" gorm:"not null;index"`
+	LineType     string           `json:"line_type" gorm:"not null"` // expense, revenue
+	CategoryID   *uint            `json:"category_id"`               // Expense lines
+	Category     *ExpenseCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	AccountCode  *string          `json:"account_code"` // Revenue lines
+	AnnualAmount Money            `json:"annual_amount" gorm:"not null"`
+	Months       []BudgetMonth    `json:"months,omitempty" gorm:"foreignKey:BudgetLineID"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// BudgetMonth is the amount budgeted on a line for one month of the fiscal year
+type BudgetMonth struct {
+	ID           uint  `json:"id" gorm:"primaryKey"`
+	BudgetLineID uint  `json:"budget_line_id" gorm:"not null;index"`
+	Month        int   `json:"month" gorm:"not null"` // 1 is the first month of the fiscal year
+	Amount       Money `json:"amount" gorm:"not null"`
+}
+
+// CreateBudgetRequest represents a request to create a budget
+type CreateBudgetRequest struct {
+	Name       string              `json:"name" binding:"required"`
+	FiscalYear int                 `json:"fiscal_year" binding:"required"`
+	Notes      *string             `json:"notes,omitempty"`
+	CompanyID  uint                `json:"company_id" binding:"required"`
+	Lines      []BudgetLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// BudgetLineRequest represents a budget line. Give either an annual amount, spread evenly
+// over the twelve months, or the twelve monthly amounts.
+type BudgetLineRequest struct {
+	LineType       string  `json:"line_type" binding:"required,oneof=expense revenue"`
+	CategoryID     *uint   `json:"category_id,omitempty"`  // Required on expense lines
+	AccountCode    *string `json:"account_code,omitempty"` // Required on revenue lines
+	AnnualAmount   *Money  `json:"annual_amount,omitempty" binding:"omitempty,min=0"`
+	MonthlyAmounts []Money `json:"monthly_amounts,omitempty" binding:"omitempty,len=12,dive,min=0"`
+}
+
+// UpdateBudgetRequest represents a request to update a budget. Lines, when given, replace
+// the existing lines.
+type UpdateBudgetRequest struct {
+	Name  *string             `json:"name,omitempty"`
+	Notes *string             `json:"notes,omitempty"`
+	Lines []BudgetLineRequest `json:"lines,omitempty" binding:"omitempty,dive"`
+}
+
+// Project is a client engagement or internal cost centre. Invoices, invoice items,
+// expenses, income entries and capital assets tagged with a project make up its P&L.
+type Project struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"not null"`
+	Code        *string        `json:"code"` // Short reference such as ACME-01
+	Description *string        `json:"description"`
+	ClientID    *uint          `json:"client_id" gorm:"index"`
+	Client      *Client        `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	Status      string         `json:"status" gorm:"not null;default:'active'"` // active, completed, archived
+	StartDate   *time.Time     `json:"start_date"`
+	EndDate     *time.Time     `json:"end_date"`
+	CompanyID   uint           `json:"company_id" gorm:"not null;index"`
+	Company     Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CreateProjectRequest represents a request to create a project
+type CreateProjectRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Code        *string `json:"code,omitempty"`
+	Description *string `json:"description,omitempty"`
+	ClientID    *uint   `json:"client_id,omitempty"`
+	Status      string  `json:"status,omitempty" binding:"omitempty,oneof=active completed archived"` // Defaults to active
+	StartDate   *string `json:"start_date,omitempty"`
+	EndDate     *string `json:"end_date,omitempty"`
+	CompanyID   uint    `json:"company_id" binding:"required"`
+}
+
+// UpdateProjectRequest represents a request to update a project
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Code        *string `json:"code,omitempty"`
+	Description *string `json:"description,omitempty"`
+	ClientID    *uint   `json:"client_id,omitempty"` // 0 to clear
+	Status      *string `json:"status,omitempty" binding:"omitempty,oneof=active completed archived"`
+	StartDate   *string `json:"start_date,omitempty"` // Empty to clear
+	EndDate     *string `json:"end_date,omitempty"`   // Empty to clear
+}
+
+// InvoiceTemplate is a company's branding for invoice PDFs
+type InvoiceTemplate struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CompanyID    uint      `json:"company_id" gorm:"not null;uniqueIndex"`
+	Company      Company   `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	PrimaryColor string    `json:"primary_color" gorm:"not null;default:'#1F4E79'"` // Hex colour of the header band and table headings
+	AccentColor  string    `json:"accent_color" gorm:"not null;default:'#EAF1F8'"`  // Hex colour of alternate table rows
+	LogoPath     *string   `json:"-"`                                               // Uploaded PNG or JPEG logo
+	HasLogo      bool      `json:"has_logo" gorm:"-"`
+	PaymentTerms *string   `json:"payment_terms"` // e.g. "Net 30"; defaults to the days until the due date
+	FooterText   *string   `json:"footer_text"`   // Printed at the bottom of every page
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UpdateInvoiceTemplateRequest represents a request to update a company's invoice template
+type UpdateInvoiceTemplateRequest struct {
+	PrimaryColor *string `json:"primary_color,omitempty"`
+	AccentColor  *string `json:"accent_color,omitempty"`
+	PaymentTerms *string `json:"payment_terms,omitempty"` // Empty to clear
+	FooterText   *string `json:"footer_text,omitempty"`   // Empty to clear
+}
+
+// Payment is money received from a client. It is allocated to one or more of the client's
+// invoices in the payment's currency; any amount left unallocated is a credit the client
+// can apply to later invoices.
+type Payment struct {
+	ID                uint                `json:"id" gorm:"primaryKey"`
+	ClientID          uint                `json:"client_id" gorm:"not null;index"`
+	Client            Client              `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	PaymentDate       time.Time           `json:"payment_date" gorm:"not null"`
+	Amount            Money               `json:"amount" gorm:"not null"`
+	Currency          string              `json:"currency" gorm:"not null;default:'CAD'"`  // ISO 4217 code of the amounts
+	ExchangeRate      float64             `json:"exchange_rate" gorm:"not null;default:1"` // CAD per unit of currency on the payment date
+	Method            string              `json:"method" gorm:"not null;default:'other'"`  // cash, cheque, e_transfer, bank_transfer, credit_card, other
+	Reference         *string             `json:"reference"`                               // Cheque number, transfer reference, etc.
+	Notes             *string             `json:"notes"`
+	AccountCode       string              `json:"account_code" gorm:"not null"` // Asset account the payment was deposited to
+	BankTransactionID *uint               `json:"bank_transaction_id" gorm:"index"`
+	BankTransaction   *BankTransaction    `json:"bank_transaction,omitempty" gorm:"foreignKey:BankTransactionID"`
+	UnappliedAmount   Money               `json:"unapplied_amount" gorm:"not null"` // Client credit not yet allocated to an invoice
+	CompanyID         uint                `json:"company_id" gorm:"not null;index"`
+	Company           Company             `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Allocations       []PaymentAllocation `json:"allocations,omitempty" gorm:"foreignKey:PaymentID"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt      `json:"-" gorm:"index"`
+}
+
+// PaymentAllocation is the part of a payment applied to one invoice
+type PaymentAllocation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PaymentID   uint      `json:"payment_id" gorm:"not null;index"`
+	Payment     *Payment  `json:"payment,omitempty" gorm:"foreignKey:PaymentID"`
+	InvoiceID   uint      `json:"invoice_id" gorm:"not null;index"`
+	Invoice     *Invoice  `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
+	Amount      Money     `json:"amount" gorm:"not null"`
+	AppliedDate time.Time `json:"applied_date" gorm:"not null"` // The payment date, or the date a client credit was applied
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreatePaymentRequest represents a request to record a payment from a client
+type CreatePaymentRequest struct {
+	ClientID          uint                       `json:"client_id" binding:"required"`
+	PaymentDate       string                     `json:"payment_date" binding:"required"`
+	Amount            Money                      `json:"amount" binding:"required,gt=0"`
+	Currency          string                     `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`                                                // Defaults to CAD
+	ExchangeRate      *float64                   `json:"exchange_rate,omitempty" binding:"omitempty,gt=0"`                                                  // Defaults to the recorded rate on the payment date
+	Method            string                     `json:"method,omitempty" binding:"omitempty,oneof=cash cheque e_transfer bank_transfer credit_card other"` // Defaults to other
+	Reference         *string                    `json:"reference,omitempty"`
+	Notes             *string                    `json:"notes,omitempty"`
+	BankTransactionID *uint                      `json:"bank_transaction_id,omitempty"` // Statement line the payment was deposited in
+	CompanyID         uint                       `json:"company_id" binding:"required"`
+	Allocations       []PaymentAllocationRequest `json:"allocations,omitempty" binding:"omitempty,dive"`
+}
+
+// PaymentAllocationRequest represents the amount of a payment applied to an invoice
+type PaymentAllocationRequest struct {
+	InvoiceID uint  `json:"invoice_id" binding:"required"`
+	Amount    Money `json:"amount" binding:"required,gt=0"`
+}
+
+// ApplyPaymentCreditRequest represents a request to apply the unallocated part of a
+// payment to invoices
+type ApplyPaymentCreditRequest struct {
+	AppliedDate string                     `json:"applied_date,omitempty"` // Defaults to today
+	Allocations []PaymentAllocationRequest `json:"allocations" binding:"required,min=1,dive"`
+}
+
+// CreditNote reverses part or all of an issued invoice's subtotal and HST. Its total is a
+// credit the client can apply to open invoice balances or be refunded. Credit notes are in
+// their invoice's currency and rate.
+type CreditNote struct {
+	ID               uint                    `json:"id" gorm:"primaryKey"`
+	CreditNoteNumber string                  `json:"credit_note_number" gorm:"not null;uniqueIndex:idx_credit_notes_company_number"`
+	InvoiceID        uint                    `json:"invoice_id" gorm:"not null;index"` // The invoice credited
+	Invoice          *Invoice                `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
+	ClientID         uint                    `json:"client_id" gorm:"not null;index"`
+	Client           Client                  `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	IssueDate        time.Time               `json:"issue_date" gorm:"not null"`
+	Reason           *string                 `json:"reason"`
+	Subtotal         Money                   `json:"subtotal" gorm:"not null"`
+	HSTAmount        Money                   `json:"hst_amount" gorm:"not null"`
+	Total            Money                   `json:"total" gorm:"not null"`
+	Currency         string                  `json:"currency" gorm:"not null;default:'CAD'"`
+	ExchangeRate     float64                 `json:"exchange_rate" gorm:"not null;default:1"` // The invoice's rate
+	AmountApplied    Money                   `json:"amount_applied" gorm:"not null"`          // Applied to invoices or refunded
+	Status           string                  `json:"status" gorm:"not null;default:'open'"`   // open, partially_applied, applied
+	CompanyID        uint                    `json:"company_id" gorm:"not null;index;uniqueIndex:idx_credit_notes_company_number"`
+	Company          Company                 `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Items            []CreditNoteItem        `json:"items,omitempty" gorm:"foreignKey:CreditNoteID"`
+	Applications     []CreditNoteApplication `json:"applications,omitempty" gorm:"foreignKey:CreditNoteID"`
+	CreatedAt        time.Time               `json:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt          `json:"-" gorm:"index"`
+}
+
+// CreditNoteItem is a line of a credit note, usually crediting a line of its invoice
+type CreditNoteItem struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CreditNoteID  uint      `json:"credit_note_id" gorm:"not null;index"`
+	InvoiceItemID *uint     `json:"invoice_item_id"`
+	Description   string    `json:"description" gorm:"not null"`
+	Quantity      float64   `json:"quantity" gorm:"not null"`
+	Unit          *string   `json:"unit"`
+	UnitPrice     Money     `json:"unit_price" gorm:"not null"`
+	Total         Money     `json:"total" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CreditNoteApplication is part of a credit note applied to an invoice, or refunded to the
+// client when it has no invoice
+type CreditNoteApplication struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	CreditNoteID uint        `json:"credit_note_id" gorm:"not null;index"`
+	CreditNote   *CreditNote `json:"credit_note,omitempty" gorm:"foreignKey:CreditNoteID"`
+	InvoiceID    *uint       `json:"invoice_id" gorm:"index"`
+	Invoice      *Invoice    `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
+	Amount       Money       `json:"amount" gorm:"not null"`
+	AppliedDate  time.Time   `json:"applied_date" gorm:"not null"`
+	Reference    *string     `json:"reference"` // Refund cheque number, transfer reference, etc.
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// CreateCreditNoteRequest represents a request to credit an issued invoice
+type CreateCreditNoteRequest struct {
+	InvoiceID      uint                          `json:"invoice_id" binding:"required"`
+	IssueDate      string                        `json:"issue_date" binding:"required"`
+	Reason         *string                       `json:"reason,omitempty"`
+	ApplyToInvoice bool                          `json:"apply_to_invoice"` // Apply the credit to the invoice's open balance
+	Items          []CreateCreditNoteItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// CreateCreditNoteItemRequest represents a request to create a credit note line. Lines
+// crediting an invoice line default to its description, unit and unit price.
+type CreateCreditNoteItemRequest struct {
+	InvoiceItemID *uint   `json:"invoice_item_id,omitempty"`
+	Description   string  `json:"description,omitempty"`
+	Quantity      float64 `json:"quantity" binding:"required,gt=0"`
+	UnitPrice     *Money  `json:"unit_price,omitempty" binding:"omitempty,min=0"`
+}
+
+// ApplyCreditNoteRequest represents a request to apply a credit note to an invoice of its client
+type ApplyCreditNoteRequest struct {
+	InvoiceID   uint   `json:"invoice_id" binding:"required"`
+	Amount      Money  `json:"amount" binding:"required,gt=0"`
+	AppliedDate string `json:"applied_date,omitempty"` // Defaults to today
+}
+
+// RefundCreditNoteRequest represents a request to refund a credit note to its client
+type RefundCreditNoteRequest struct {
+	Amount     Money   `json:"amount" binding:"required,gt=0"`
+	RefundDate string  `json:"refund_date,omitempty"` // Defaults to today
+	Reference  *string `json:"reference,omitempty"`
+}
+
+// Estimate represents a quote sent to a client before invoicing. Accepted estimates are
+// converted into invoices, which link back to them.
+type Estimate struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	EstimateNumber string         `json:"estimate_number" gorm:"not null;uniqueIndex:idx_estimates_company_number"`
+	ClientID       uint           `json:"client_id" gorm:"not null"`
+	Client         Client         `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	IssueDate      time.Time      `json:"issue_date" gorm:"not null"`
+	ExpiryDate     time.Time      `json:"expiry_date" gorm:"not null"`
+	Subtotal       Money          `json:"subtotal" gorm:"not null"`
+	HSTAmount      Money          `json:"hst_amount" gorm:"not null"`
+	Total          Money          `json:"total" gorm:"not null"`
+	Currency       string         `json:"currency" gorm:"not null;default:'CAD'"`
+	Status         string         `json:"status" gorm:"not null;default:'draft'"` // draft, sent, accepted, declined, expired
+	Description    *string        `json:"description"`
+	Notes          *string        `json:"notes"`
+	ProjectID      *uint          `json:"project_id" gorm:"index"`
+	Project        *Project       `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	CompanyID      uint           `json:"company_id" gorm:"not null;uniqueIndex:idx_estimates_company_number"`
+	Company        Company        `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Items          []EstimateItem `json:"items,omitempty" gorm:"foreignKey:EstimateID"`
+	Invoice        *Invoice       `json:"invoice,omitempty" gorm:"foreignKey:EstimateID"` // Set once converted
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// EstimateItem represents a line item in an estimate
+type EstimateItem struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	EstimateID  uint           `json:"estimate_id" gorm:"not null;index"`
+	Description string         `json:"description" gorm:"not null"`
+	Quantity    float64        `json:"quantity" gorm:"not null"`
+	Unit        *string        `json:"unit"`
+	UnitPrice   Money          `json:"unit_price" gorm:"not null"`
+	Total       Money          `json:"total" gorm:"not null"`
+	ProjectID   *uint          `json:"project_id" gorm:"index"` // Overrides the estimate's project
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// RecurringInvoice is a profile that issues the same invoice to a client on a schedule,
+// such as a monthly retainer. The scheduler generates each invoice once.
+type RecurringInvoice struct {
+	ID              uint                         `json:"id" gorm:"primaryKey"`
+	Name            string                       `json:"name" gorm:"not null"`
+	ClientID        uint                         `json:"client_id" gorm:"not null;index"`
+	Client          Client                       `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	Cadence         string                       `json:"cadence" gorm:"not null"`                    // monthly, quarterly, yearly or days
+	IntervalDays    *int                         `json:"interval_days"`                              // Days between invoices, for the days cadence
+	StartDate       time.Time                    `json:"start_date" gorm:"not null"`                 // First issue date; later ones fall on the same day
+	EndDate         *time.Time                   `json:"end_date"`                                   // Last possible issue date; open-ended when not set
+	MaxOccurrences  *int                         `json:"max_occurrences"`                            // Ends after this many invoices; unlimited when not set
+	OccurrenceCount int                          `json:"occurrence_count" gorm:"not null;default:0"` // Invoices generated so far
+	NextIssueDate   *time.Time                   `json:"next_issue_date"`                            // Not set once the schedule has ended
+	LastIssueDate   *time.Time                   `json:"last_issue_date"`
+	DueDays         int                          `json:"due_days" gorm:"not null"`  // Days from issue to due date
+	AutoSend        bool                         `json:"auto_send" gorm:"not null"` // Issue invoices as sent rather than draft
+	IsActive        bool                         `json:"is_active" gorm:"default:true"`
+	Currency        string                       `json:"currency" gorm:"not null;default:'CAD'"`
+	Description     *string                      `json:"description"`
+	Notes           *string                      `json:"notes"`
+	ProjectID       *uint                        `json:"project_id" gorm:"index"`
+	Project         *Project                     `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	CompanyID       uint                         `json:"company_id" gorm:"not null;index"`
+	Company         Company                      `json:"company,omitempty" gorm:"foreignKey:CompanyID"`
+	Items           []RecurringInvoiceItem       `json:"items,omitempty" gorm:"foreignKey:RecurringInvoiceID"`
+	Occurrences     []RecurringInvoiceOccurrence `json:"occurrences,omitempty" gorm:"foreignKey:RecurringInvoiceID"`
+	CreatedAt       time.Time                    `json:"created_at"`
+	UpdatedAt       time.Time                    `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt               `json:"-" gorm:"index"`
+}
+
+// RecurringInvoiceItem is a line of the invoices a recurring invoice profile generates
+type RecurringInvoiceItem struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	RecurringInvoiceID uint      `json:"recurring_invoice_id" gorm:"not null;index"`
+	Description        string    `json:"description" gorm:"not null"`
+	Quantity           float64   `json:"quantity" gorm:"not null"`
+	Unit               *string   `json:"unit"`
+	UnitPrice          Money     `json:"unit_price" gorm:"not null"`
+	ProjectID          *uint     `json:"project_id"` // Overrides the profile's project
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// RecurringInvoiceOccurrence records that a recurring invoice's issue date was processed.
+// The unique index keeps an invoice from being generated twice for the same date.
+type RecurringInvoiceOccurrence struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	RecurringInvoiceID uint      `json:"recurring_invoice_id" gorm:"not null;uniqueIndex:idx_recurring_invoice_occurrence"`
+	IssueDate          time.Time `json:"issue_date" gorm:"not null;uniqueIndex:idx_recurring_invoice_occurrence"`
+	Status             string    `json:"status" gorm:"not null"` // created, or skipped when the date is in a closed period
+	InvoiceID          *uint     `json:"invoice_id"`
+	Invoice            *Invoice  `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
+	Note               *string   `json:"note"`
+	CreatedAt          time.Time `json:"created_at"`
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Money is an exact amount of dollars held as an integer number of cents. It is stored
+// as NUMERIC(14,2) and encoded in JSON as a number with two decimal places. Amounts are
+// added and compared as integers; multiplying by a rate rounds half-up to the cent.
+type Money int64
+
+// NewMoney converts a dollar amount to Money, rounding half-up to the cent
+func NewMoney(amount float64) Money {
+	return Money(roundHalfUp(amount * 100))
+}
+
+// roundHalfUp rounds to the nearest integer with halves rounded away from zero. The value
+// is first rounded to six decimals so binary float error cannot move an exact half.
+func roundHalfUp(value float64) int64 {
+	return int64(math.Round(math.Round(value*1e6) / 1e6))
+}
+
+// Cents returns the amount in cents
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Float64 returns the amount in dollars, for display and ratios only
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// MulRate multiplies the amount by a rate, such as an HST or tax rate, rounding half-up to the cent
+func (m Money) MulRate(rate float64) Money {
+	return Money(roundHalfUp(float64(m) * rate))
+}
+
+// Abs returns the absolute value of the amount
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// String formats the amount as dollars with two decimal places, e.g. "-1234.50"
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// ParseMoney parses a decimal dollar amount such as "1234.5" or "-0.125" exactly,
+// rounding half-up to the cent
+func ParseMoney(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	// Only one sign is allowed and it must be followed by digits
+	if strings.TrimLeft(value, ".") == "" || value[0] == '-' || value[0] == '+' {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	// Fall back to float parsing for exponent notation
+	if strings.ContainsAny(value, "eE") {
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+		if negative {
+			amount = -amount
+		}
+		return NewMoney(amount), nil
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	dollars, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	for _, r := range fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+	}
+
+	// Take two decimals and round half-up on the third
+	fraction += "000"
+	cents, _ := strconv.ParseInt(fraction[:2], 10, 64)
+	if fraction[2] >= '5' {
+		cents++
+	}
+
+	total := Money(dollars*100 + cents)
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// MarshalJSON encodes the amount as a JSON number with two decimal places
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "null" {
+		return nil
+	}
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as a decimal string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads a NUMERIC, integer or floating point column
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case int64:
+		*m = Money(v * 100)
+	case float64:
+		*m = NewMoney(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+	return nil
+}
+
+// GormDataType returns the generic column type
+func (Money) GormDataType() string {
+	return "numeric"
+}
+
+// GormDBDataType returns the column type for the database
+func (Money) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return "numeric(14,2)"
+}